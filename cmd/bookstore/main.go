@@ -1,28 +1,169 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/sergekukharev/agent-test-writer-validator/internal/alerting"
 	"github.com/sergekukharev/agent-test-writer-validator/internal/api"
+	"github.com/sergekukharev/agent-test-writer-validator/internal/calc"
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
 	"github.com/sergekukharev/agent-test-writer-validator/internal/storage"
 )
 
 func main() {
 	addr := flag.String("addr", ":8080", "listen address")
+	rulesPath := flag.String("pricing-rules", "", "path to a JSON pricing rules file (defaults to built-in rules)")
+	agesPath := flag.String("age-policy", "", "path to a JSON file with classic and recent age thresholds per genre")
+	costMethod := flag.String("cost-method", "fifo", "how sales are costed: fifo or weighted_average")
+	alertRulesPath := flag.String("alert-rules", "", "path to a JSON stock alert rules file (defaults to a low-stock rule at 5 copies)")
+	alertWebhook := flag.String("alert-webhook", "", "URL to post stock alerts to")
+	alertSMTP := flag.String("alert-smtp", "", "host:port of an SMTP relay to mail stock alerts through")
+	alertFrom := flag.String("alert-email-from", "bookstore@localhost", "sender of stock alert mail")
+	alertTo := flag.String("alert-email-to", "", "comma-separated recipients of stock alert mail")
+	alertLimit := flag.Int("alert-limit", 30, "stock alerts sent per minute, 0 for no limit")
 	flag.Parse()
 
-	repo := storage.NewBookRepository()
-	handler := api.NewHandler(repo)
+	rules := calc.DefaultPricingRules
+	if *rulesPath != "" {
+		var err error
+		if rules, err = loadPricingRules(*rulesPath); err != nil {
+			log.Fatalf("pricing rules: %v", err)
+		}
+	}
+	pricing, err := calc.NewPricingEngine(rules)
+	if err != nil {
+		log.Fatalf("pricing rules: %v", err)
+	}
+
+	ages := &domain.DefaultAgePolicy
+	if *agesPath != "" {
+		if ages, err = loadAgePolicy(*agesPath); err != nil {
+			log.Fatalf("age policy: %v", err)
+		}
+	}
+
+	alertRules := alerting.DefaultRules
+	if *alertRulesPath != "" {
+		if alertRules, err = loadAlertRules(*alertRulesPath); err != nil {
+			log.Fatalf("alert rules: %v", err)
+		}
+	}
+	sinks := []alerting.Sink{alerting.LogSink{}}
+	if *alertWebhook != "" {
+		sinks = append(sinks, alerting.WebhookSink{URL: *alertWebhook})
+	}
+	if *alertSMTP != "" && *alertTo != "" {
+		sinks = append(sinks, alerting.EmailSink{Addr: *alertSMTP, From: *alertFrom, To: strings.Split(*alertTo, ",")})
+	}
+
+	clock := domain.SystemClock{}
+	alerter, err := alerting.NewAlerter(alertRules, sinks, alerting.Options{Limit: *alertLimit, Clock: clock})
+	if err != nil {
+		log.Fatalf("alert rules: %v", err)
+	}
+	books := storage.NewBookRepository()
+	inventory := domain.NewInventory(clock)
+	if err := inventory.SetCostMethod(domain.CostMethod(*costMethod)); err != nil {
+		log.Fatalf("cost method: %v", err)
+	}
+	inventory.OnStockChange(alerter.Observe)
+	notices := storage.NewNotificationRepository()
+	inventory.OnAllocation(func(a domain.Allocation) {
+		notices.Record(a)
+		log.Printf("notified %s: %s", a.Backorder.Customer(), domain.AllocationMessage(a))
+	})
+	carts := storage.NewCartRepository()
+	prices := storage.NewPriceHistoryRepository()
+	reorder := storage.NewReorderPolicyRepository()
+	orders := storage.NewPurchaseOrderRepository()
+	handler := api.NewHandler(api.Deps{
+		Books:      books,
+		Carts:      carts,
+		Promotions: storage.NewPromotionRepository(),
+		Customers:  storage.NewCustomerRepository(),
+		PriceLists: storage.NewPriceListRepository(),
+		Prices:     prices,
+		Suppliers:  storage.NewSupplierRepository(),
+		Reorder:    reorder,
+		Orders:     orders,
+		Inventory:  inventory,
+		Notices:    notices,
+		Alerts:     alerter,
+		Pricing:    pricing,
+		Quotes:     calc.DefaultQuoteOptions,
+		Clock:      clock,
+		Ages:       ages,
+	})
 	mux := handler.Routes()
 
 	var h http.Handler = mux
 	h = api.LoggingMiddleware(h)
 	h = api.RecoveryMiddleware(h)
 
+	go func() {
+		for now := range time.Tick(time.Minute) {
+			carts.DeleteExpired(now)
+			if expired := inventory.ExpireReservations(now); len(expired) > 0 {
+				log.Printf("released %d expired reservations", len(expired))
+			}
+			if n := inventory.AllocateBackorders(); n > 0 {
+				log.Printf("allocated stock to %d backorders", n)
+			}
+			if n := storage.ApplyScheduledPrices(books, inventory, prices, now); n > 0 {
+				log.Printf("applied scheduled prices to %d books", n)
+			}
+			if drafts := storage.GenerateReorderDrafts(inventory, reorder, orders, now); len(drafts) > 0 {
+				log.Printf("drafted %d purchase orders for review", len(drafts))
+			}
+		}
+	}()
+
 	log.Printf("bookstore listening on %s", *addr)
 	if err := http.ListenAndServe(*addr, h); err != nil {
 		log.Fatalf("server error: %v", err)
 	}
 }
+
+func loadPricingRules(path string) ([]calc.PricingRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return calc.LoadPricingRules(f)
+}
+
+func loadAlertRules(path string) ([]alerting.Rule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return alerting.LoadRules(f)
+}
+
+func loadAgePolicy(path string) (*domain.AgePolicy, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var policy domain.AgePolicy
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&policy); err != nil {
+		return nil, fmt.Errorf("decode age policy: %w", err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
@@ -0,0 +1,303 @@
+package alerting
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+// Status tracks an alert from when it is raised until the stock recovers.
+type Status string
+
+const (
+	StatusOpen         Status = "open"
+	StatusAcknowledged Status = "acknowledged" // someone is on it; stays until the stock recovers
+	StatusResolved     Status = "resolved"
+)
+
+// Delivery records one attempt to send an alert to a sink.
+type Delivery struct {
+	Sink  string    `json:"sink"`
+	Error string    `json:"error,omitempty"`
+	At    time.Time `json:"at"`
+}
+
+// Alert is a raised warning about the stock of one book in one condition at
+// one location.
+type Alert struct {
+	ID             string           `json:"id"`
+	Rule           string           `json:"rule"`
+	Kind           Kind             `json:"kind"`
+	ISBN           string           `json:"isbn"`
+	Title          string           `json:"title"`
+	Location       string           `json:"location"`
+	Condition      domain.Condition `json:"condition"`
+	Available      int              `json:"available"`
+	Threshold      int              `json:"threshold"`
+	Status         Status           `json:"status"`
+	Suppressed     bool             `json:"suppressed,omitempty"` // held back by the rate limit rather than sent
+	Deliveries     []Delivery       `json:"deliveries,omitempty"`
+	RaisedAt       time.Time        `json:"raised_at"`
+	AcknowledgedBy string           `json:"acknowledged_by,omitempty"`
+	AcknowledgedAt time.Time        `json:"acknowledged_at,omitzero"`
+	ResolvedAt     time.Time        `json:"resolved_at,omitzero"`
+}
+
+// Message describes the alert in one line, e.g. "Low stock: Dune
+// (9780306406157) at main: 2 available, threshold 5".
+func (a Alert) Message() string {
+	where := a.Location
+	if a.Condition.IsUsed() {
+		where += fmt.Sprintf(" (%s)", a.Condition)
+	}
+	if a.Kind == KindOutOfStock {
+		return fmt.Sprintf("Out of stock: %s (%s) at %s", a.Title, a.ISBN, where)
+	}
+	return fmt.Sprintf("Low stock: %s (%s) at %s: %d available, threshold %d", a.Title, a.ISBN, where, a.Available, a.Threshold)
+}
+
+func (a Alert) clone() Alert {
+	a.Deliveries = slices.Clone(a.Deliveries)
+	return a
+}
+
+// Options tune an Alerter.
+type Options struct {
+	Limit  int           // alerts sent per Window, 0 for no limit; further alerts are recorded but suppressed
+	Window time.Duration // defaults to a minute
+	Clock  domain.Clock  // defaults to the system clock
+}
+
+// Alerter evaluates its rules on every stock change. A rule raises one
+// alert per book, condition and location when the stock first falls below
+// its threshold or runs out, and raises again only when the stock recovers
+// or moves between low and out of stock. It is safe for concurrent use.
+type Alerter struct {
+	mu     sync.Mutex
+	rules  []Rule
+	sinks  []Sink
+	limit  int
+	window time.Duration
+	clock  domain.Clock
+	alerts map[string]*Alert // keyed by alert ID
+	order  []string          // alert IDs, oldest first
+	firing map[string]string // the ID of the standing alert, keyed by rule and variant
+	seen   map[string]int    // the Seq of the latest change observed, keyed by variant
+	sent   []time.Time       // when the alerts in the current window were sent
+	wg     sync.WaitGroup
+}
+
+// NewAlerter validates the rules against the sinks they name.
+func NewAlerter(rules []Rule, sinks []Sink, opts Options) (*Alerter, error) {
+	names := make(map[string]bool, len(sinks))
+	for _, s := range sinks {
+		if names[s.Name()] {
+			return nil, fmt.Errorf("duplicate alert sink: %s", s.Name())
+		}
+		names[s.Name()] = true
+	}
+	seen := make(map[string]bool, len(rules))
+	normalized := make([]Rule, 0, len(rules))
+	for _, r := range rules {
+		r, err := r.normalize()
+		if err != nil {
+			return nil, err
+		}
+		if seen[r.Name] {
+			return nil, fmt.Errorf("duplicate alert rule: %s", r.Name)
+		}
+		seen[r.Name] = true
+		for _, name := range r.Sinks {
+			if !names[name] {
+				return nil, fmt.Errorf("rule %s: unknown sink %q", r.Name, name)
+			}
+		}
+		normalized = append(normalized, r)
+	}
+	if opts.Limit < 0 {
+		return nil, errors.New("alert rate limit must not be negative")
+	}
+	if opts.Window <= 0 {
+		opts.Window = time.Minute
+	}
+	if opts.Clock == nil {
+		opts.Clock = domain.SystemClock{}
+	}
+	return &Alerter{
+		rules:  normalized,
+		sinks:  slices.Clone(sinks),
+		limit:  opts.Limit,
+		window: opts.Window,
+		clock:  opts.Clock,
+		alerts: make(map[string]*Alert),
+		firing: make(map[string]string),
+		seen:   make(map[string]int),
+	}, nil
+}
+
+// Rules returns the alerter's rules in the order they were configured.
+func (a *Alerter) Rules() []Rule {
+	rules := make([]Rule, 0, len(a.rules))
+	for _, r := range a.rules {
+		r.Sinks = slices.Clone(r.Sinks)
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// Observe evaluates every rule against a changed stock entry. Register it
+// with domain.Inventory.OnStockChange. A change older than one already
+// observed for the same entry is stale and ignored.
+func (a *Alerter) Observe(c domain.StockChange) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	v := variant(c.Entry)
+	if c.Seq <= a.seen[v] {
+		return
+	}
+	a.seen[v] = c.Seq
+	for _, r := range a.rules {
+		if !r.matches(c.Entry) {
+			continue
+		}
+		key := r.Name + "|" + v
+		kind, due := r.evaluate(c.Entry)
+		if id, ok := a.firing[key]; ok {
+			if due && a.alerts[id].Kind == kind {
+				continue
+			}
+			a.resolveLocked(id, c.At)
+			delete(a.firing, key)
+		}
+		if due {
+			a.firing[key] = a.raiseLocked(r, kind, c)
+		}
+	}
+}
+
+func variant(e domain.StockEntry) string {
+	return e.Book().ISBN().String() + "@" + e.Location() + "#" + string(e.Condition())
+}
+
+// raiseLocked records a new alert and sends it to the rule's sinks unless
+// the rate limit is spent. The caller must hold the lock.
+func (a *Alerter) raiseLocked(r Rule, kind Kind, c domain.StockChange) string {
+	alert := &Alert{
+		ID:        domain.NewID("alr"),
+		Rule:      r.Name,
+		Kind:      kind,
+		ISBN:      c.Entry.Book().ISBN().String(),
+		Title:     c.Entry.Book().Title(),
+		Location:  c.Entry.Location(),
+		Condition: c.Entry.Condition(),
+		Available: c.Entry.Available(),
+		Threshold: r.Threshold,
+		Status:    StatusOpen,
+		RaisedAt:  c.At,
+	}
+	a.alerts[alert.ID] = alert
+	a.order = append(a.order, alert.ID)
+	if !a.allowLocked() {
+		alert.Suppressed = true
+		return alert.ID
+	}
+	for _, s := range a.sinks {
+		if len(r.Sinks) == 0 || slices.Contains(r.Sinks, s.Name()) {
+			a.dispatch(s, alert.clone())
+		}
+	}
+	return alert.ID
+}
+
+// allowLocked reports whether another alert may be sent in the current
+// window, counting it if so. The caller must hold the lock.
+func (a *Alerter) allowLocked() bool {
+	if a.limit == 0 {
+		return true
+	}
+	now := a.clock.Now()
+	a.sent = slices.DeleteFunc(a.sent, func(t time.Time) bool { return !t.After(now.Add(-a.window)) })
+	if len(a.sent) >= a.limit {
+		return false
+	}
+	a.sent = append(a.sent, now)
+	return true
+}
+
+// dispatch sends the alert in the background, so that slow sinks do not
+// hold up stock changes, and records the outcome on the alert.
+func (a *Alerter) dispatch(s Sink, alert Alert) {
+	a.wg.Go(func() {
+		d := Delivery{Sink: s.Name()}
+		if err := s.Send(alert); err != nil {
+			d.Error = err.Error()
+		}
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		d.At = a.clock.Now()
+		stored := a.alerts[alert.ID]
+		stored.Deliveries = append(stored.Deliveries, d)
+	})
+}
+
+// Wait blocks until every alert sent so far has been delivered or failed.
+func (a *Alerter) Wait() {
+	a.wg.Wait()
+}
+
+// resolveLocked marks an alert resolved once its stock has recovered or
+// moved on. The caller must hold the lock.
+func (a *Alerter) resolveLocked(id string, at time.Time) {
+	alert := a.alerts[id]
+	alert.Status, alert.ResolvedAt = StatusResolved, at
+}
+
+// Alerts returns every alert raised, newest first.
+func (a *Alerter) Alerts() []Alert {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	result := make([]Alert, 0, len(a.order))
+	for _, id := range slices.Backward(a.order) {
+		result = append(result, a.alerts[id].clone())
+	}
+	return result
+}
+
+// Find returns the alert with the given ID.
+func (a *Alerter) Find(id string) (Alert, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	alert, ok := a.alerts[id]
+	if !ok {
+		return Alert{}, fmt.Errorf("alert %s not found", id)
+	}
+	return alert.clone(), nil
+}
+
+// Acknowledge records that someone is dealing with an open alert. The alert
+// still stands, and is not raised again, until the stock recovers.
+func (a *Alerter) Acknowledge(id, actor string) (Alert, error) {
+	actor = strings.TrimSpace(actor)
+	if actor == "" {
+		return Alert{}, errors.New("acknowledging actor must not be empty")
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	alert, ok := a.alerts[id]
+	if !ok {
+		return Alert{}, fmt.Errorf("alert %s not found", id)
+	}
+	switch alert.Status {
+	case StatusAcknowledged:
+		return Alert{}, fmt.Errorf("alert %s was already acknowledged by %s", id, alert.AcknowledgedBy)
+	case StatusResolved:
+		return Alert{}, fmt.Errorf("alert %s is resolved", id)
+	}
+	alert.Status, alert.AcknowledgedBy, alert.AcknowledgedAt = StatusAcknowledged, actor, a.clock.Now()
+	return alert.clone(), nil
+}
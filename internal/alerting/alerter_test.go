@@ -0,0 +1,304 @@
+package alerting
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+type recordingSink struct {
+	name string
+	err  error
+	mu   sync.Mutex
+	sent []Alert
+}
+
+func (s *recordingSink) Name() string { return s.name }
+
+func (s *recordingSink) Send(a Alert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, a)
+	return s.err
+}
+
+func (s *recordingSink) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sent)
+}
+
+func testWatchedInventory(t *testing.T, clock domain.Clock, alerter *Alerter, isbns ...string) (*domain.Inventory, []domain.Book) {
+	t.Helper()
+	inv := domain.NewInventory(clock)
+	inv.OnStockChange(alerter.Observe)
+	author, _ := domain.NewAuthor("Jane", "Doe")
+	price, _ := domain.NewMoney(1000, "EUR")
+	var books []domain.Book
+	for _, s := range isbns {
+		isbn, err := domain.NewISBN(s)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		book, _ := domain.NewBook(isbn, "Stocked", author, price, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), domain.GenreFiction)
+		entry, _ := domain.NewStockEntry(book, 6)
+		if err := inv.Add(entry); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		books = append(books, book)
+	}
+	return inv, books
+}
+
+func TestAlerter_RaisesOncePerEpisodeAndResolvesOnRecovery(t *testing.T) {
+	sink := &recordingSink{name: "log"}
+	alerter, err := NewAlerter(DefaultRules, []Sink{sink}, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	inv, books := testWatchedInventory(t, nil, alerter, "9780306406157")
+	isbn := books[0].ISBN()
+
+	inv.Reserve(isbn, "", 2, domain.MovementInfo{}) // 4 left: low
+	inv.Reserve(isbn, "", 1, domain.MovementInfo{}) // 3 left: still low, no new alert
+	inv.Reserve(isbn, "", 3, domain.MovementInfo{}) // out of stock: escalates
+	alerter.Wait()
+	alerts := alerter.Alerts()
+	if sink.count() != 2 || alerts[0].Kind != KindOutOfStock || alerts[1].Kind != KindLowStock {
+		t.Fatalf("got alerts %+v, want low stock then out of stock", alerts)
+	}
+	if alerts[0].Status != StatusOpen || alerts[1].Status != StatusResolved {
+		t.Errorf("got %s and %s, want the out of stock alert open and the low stock one resolved", alerts[0].Status, alerts[1].Status)
+	}
+	if len(alerts[0].Deliveries) != 1 || alerts[0].Deliveries[0].Sink != "log" {
+		t.Errorf("unexpected deliveries %+v", alerts[0].Deliveries)
+	}
+
+	inv.Restock(isbn, "", 10, domain.MovementInfo{})
+	inv.Reserve(isbn, "", 8, domain.MovementInfo{})
+	alerter.Wait()
+	if sink.count() != 3 || alerter.Alerts()[0].Kind != KindLowStock {
+		t.Errorf("got %d alerts sent, want a fresh low stock alert after recovery", sink.count())
+	}
+	if a, _ := alerter.Find(alerts[0].ID); a.Status != StatusResolved || a.ResolvedAt.IsZero() {
+		t.Errorf("got %s, want the out of stock alert resolved by the restock", a.Status)
+	}
+}
+
+func TestAlerter_IgnoresStaleChanges(t *testing.T) {
+	alerter, _ := NewAlerter(DefaultRules, []Sink{&recordingSink{name: "log"}}, Options{})
+	inv, books := testWatchedInventory(t, nil, alerter, "9780306406157")
+	var changes []domain.StockChange
+	inv.OnStockChange(func(c domain.StockChange) { changes = append(changes, c) })
+	inv.Reserve(books[0].ISBN(), "", 6, domain.MovementInfo{})
+	inv.Restock(books[0].ISBN(), "", 10, domain.MovementInfo{})
+
+	// Delivered out of order, as concurrent requests may: the out of stock
+	// balance arrives after the restock that ended it.
+	alerter.Observe(changes[1])
+	alerter.Observe(changes[0])
+	alerter.Wait()
+	if alerts := alerter.Alerts(); len(alerts) != 0 {
+		t.Errorf("got %+v, want no alert for stock that has recovered", alerts)
+	}
+}
+
+func TestAlerter_RuleLocationIgnoresCase(t *testing.T) {
+	sink := &recordingSink{name: "log"}
+	alerter, err := NewAlerter([]Rule{{Name: "shop", Threshold: 5, Location: "Shop-1"}}, []Sink{sink}, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	inv, books := testWatchedInventory(t, nil, alerter, "9780306406157")
+	shop, _ := domain.NewLocation("SHOP-1", "High street", domain.LocationStore)
+	if err := inv.AddLocation(shop); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entry, _ := domain.NewStockEntry(books[0], 2)
+	if err := inv.Add(entry.WithLocation("Shop-1", "")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	alerter.Wait()
+	if alerts := alerter.Alerts(); len(alerts) != 1 || alerts[0].Location != "shop-1" {
+		t.Errorf("got %+v, want one alert at shop-1", alerts)
+	}
+}
+
+func TestAlerter_RateLimitSuppressesButRecords(t *testing.T) {
+	clock := domain.NewFakeClock(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	sink := &recordingSink{name: "log"}
+	rules := []Rule{{Name: "out", Threshold: 0}}
+	alerter, err := NewAlerter(rules, []Sink{sink}, Options{Limit: 1, Window: time.Minute, Clock: clock})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	inv, books := testWatchedInventory(t, clock, alerter, "9780306406157", "9780140449136", "9780262033848")
+
+	inv.Reserve(books[0].ISBN(), "", 6, domain.MovementInfo{})
+	inv.Reserve(books[1].ISBN(), "", 6, domain.MovementInfo{})
+	clock.Advance(time.Minute)
+	inv.Reserve(books[2].ISBN(), "", 6, domain.MovementInfo{})
+	alerter.Wait()
+
+	if sink.count() != 2 {
+		t.Errorf("got %d alerts sent, want 2", sink.count())
+	}
+	alerts := alerter.Alerts()
+	if len(alerts) != 3 || !alerts[1].Suppressed || alerts[0].Suppressed || len(alerts[1].Deliveries) != 0 {
+		t.Errorf("want three alerts with only the second suppressed, got %+v", alerts)
+	}
+}
+
+func TestAlerter_Acknowledge(t *testing.T) {
+	sink := &recordingSink{name: "log", err: errors.New("disk full")}
+	alerter, _ := NewAlerter(DefaultRules, []Sink{sink}, Options{})
+	inv, books := testWatchedInventory(t, nil, alerter, "9780306406157")
+	inv.Reserve(books[0].ISBN(), "", 6, domain.MovementInfo{})
+	alerter.Wait()
+
+	alert := alerter.Alerts()[0]
+	if alert.Deliveries[0].Error != "disk full" {
+		t.Errorf("got delivery %+v, want the sink's error recorded", alert.Deliveries[0])
+	}
+	if _, err := alerter.Acknowledge(alert.ID, " "); err == nil {
+		t.Error("expected error acknowledging without an actor")
+	}
+	acked, err := alerter.Acknowledge(alert.ID, "alice")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if acked.Status != StatusAcknowledged || acked.AcknowledgedBy != "alice" {
+		t.Errorf("got %s by %q, want acknowledged by alice", acked.Status, acked.AcknowledgedBy)
+	}
+	if _, err := alerter.Acknowledge(alert.ID, "bob"); err == nil {
+		t.Error("expected error acknowledging twice")
+	}
+}
+
+func TestNewAlerter_ValidatesRules(t *testing.T) {
+	sinks := []Sink{LogSink{}}
+	for name, rules := range map[string][]Rule{
+		"unnamed":       {{Threshold: 5}},
+		"negative":      {{Name: "x", Threshold: -1}},
+		"bad isbn":      {{Name: "x", ISBN: "123"}},
+		"bad condition": {{Name: "x", Condition: "mint"}},
+		"unknown sink":  {{Name: "x", Sinks: []string{"pager"}}},
+		"duplicate":     {{Name: "x"}, {Name: "x"}},
+	} {
+		if _, err := NewAlerter(rules, sinks, Options{}); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	rules, err := LoadRules(strings.NewReader(`[
+		{"name": "used", "threshold": 1, "condition": "good", "sinks": ["email"]}
+	]`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rules) != 1 || rules[0].Condition != domain.ConditionGood || rules[0].Sinks[0] != "email" {
+		t.Errorf("unexpected rules: %+v", rules)
+	}
+	if _, err := LoadRules(strings.NewReader(`[{"name": "x", "level": 3}]`)); err == nil {
+		t.Error("expected error for an unknown field")
+	}
+}
+
+func TestWebhookSink_PostsAlert(t *testing.T) {
+	var got Alert
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/hooks/stock" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	alert := Alert{ID: "alr_1", Kind: KindOutOfStock, ISBN: "9780306406157"}
+	if err := (WebhookSink{URL: srv.URL + "/hooks/stock"}).Send(alert); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.ID != "alr_1" || got.Kind != KindOutOfStock {
+		t.Errorf("got %+v, want the alert posted", got)
+	}
+	if err := (WebhookSink{URL: srv.URL + "/missing"}).Send(alert); err == nil {
+		t.Error("expected error when the webhook answers 404")
+	}
+}
+
+// smtpStandIn accepts one message on a local port and hands back what it
+// was sent.
+func smtpStandIn(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+		reply("220 localhost")
+		var data strings.Builder
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "DATA"):
+				reply("354 go ahead")
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+					data.WriteString(l)
+				}
+				received <- data.String()
+				reply("250 ok")
+			case strings.HasPrefix(cmd, "QUIT"):
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+	return ln.Addr().String(), received
+}
+
+func TestEmailSink_SendsThroughSMTP(t *testing.T) {
+	addr, received := smtpStandIn(t)
+	sink := EmailSink{Addr: addr, From: "stock@shop.test", To: []string{"buyer@shop.test"}}
+	alert := Alert{ID: "alr_1", Kind: KindLowStock, ISBN: "9780306406157", Title: "Dune", Location: "main", Available: 2, Threshold: 5}
+	if err := sink.Send(alert); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case msg := <-received:
+		if !strings.Contains(msg, "Subject: Low stock: Dune (9780306406157) at main: 2 available, threshold 5") {
+			t.Errorf("unexpected message:\n%s", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+}
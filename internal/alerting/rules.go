@@ -0,0 +1,101 @@
+// Package alerting raises low-stock and out-of-stock alerts as stock
+// changes and sends them to pluggable sinks.
+package alerting
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+// Kind says what an alert warns about.
+type Kind string
+
+const (
+	KindLowStock   Kind = "low_stock"
+	KindOutOfStock Kind = "out_of_stock"
+)
+
+// Rule watches the stock entries it matches. Zero-valued filters are
+// ignored; every filter that is set must match.
+type Rule struct {
+	Name      string           `json:"name"`
+	Threshold int              `json:"threshold"`           // alert when fewer than this many copies are available; 0 alerts on out of stock only
+	Location  string           `json:"location,omitempty"`  // every location if empty; case-insensitive
+	Genre     domain.Genre     `json:"genre,omitempty"`     // every genre if empty
+	ISBN      string           `json:"isbn,omitempty"`      // every book if empty
+	Condition domain.Condition `json:"condition,omitempty"` // new copies if empty; used grades are watched only when named
+	Sinks     []string         `json:"sinks,omitempty"`     // names of the sinks to alert, every sink if empty
+	Disabled  bool             `json:"disabled,omitempty"`
+}
+
+// DefaultRules warn when fewer than five new copies of a book are available
+// at a location.
+var DefaultRules = []Rule{
+	{Name: "low-stock", Threshold: 5},
+}
+
+// LoadRules decodes a JSON array of alert rules.
+func LoadRules(r io.Reader) ([]Rule, error) {
+	var rules []Rule
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&rules); err != nil {
+		return nil, fmt.Errorf("decode alert rules: %w", err)
+	}
+	return rules, nil
+}
+
+// normalize validates the rule and returns it with its location, ISBN and
+// condition in canonical form.
+func (r Rule) normalize() (Rule, error) {
+	if strings.TrimSpace(r.Name) == "" {
+		return Rule{}, errors.New("alert rule name must not be empty")
+	}
+	if r.Threshold < 0 {
+		return Rule{}, fmt.Errorf("rule %s: threshold must not be negative", r.Name)
+	}
+	r.Location = strings.ToLower(strings.TrimSpace(r.Location))
+	if r.ISBN != "" {
+		isbn, err := domain.NewISBN(r.ISBN)
+		if err != nil {
+			return Rule{}, fmt.Errorf("rule %s: %w", r.Name, err)
+		}
+		r.ISBN = isbn.String()
+	}
+	condition, err := domain.ParseCondition(string(r.Condition))
+	if err != nil {
+		return Rule{}, fmt.Errorf("rule %s: %w", r.Name, err)
+	}
+	r.Condition = condition
+	return r, nil
+}
+
+func (r Rule) matches(e domain.StockEntry) bool {
+	switch {
+	case r.Disabled:
+		return false
+	case r.Location != "" && r.Location != e.Location():
+		return false
+	case r.Genre != "" && r.Genre != e.Book().Genre():
+		return false
+	case r.ISBN != "" && r.ISBN != e.Book().ISBN().String():
+		return false
+	}
+	return r.Condition == e.Condition()
+}
+
+// evaluate returns the kind of alert the entry's stock calls for, if any.
+func (r Rule) evaluate(e domain.StockEntry) (Kind, bool) {
+	switch {
+	case e.Available() == 0:
+		return KindOutOfStock, true
+	case e.IsLowStock(r.Threshold):
+		return KindLowStock, true
+	}
+	return "", false
+}
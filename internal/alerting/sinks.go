@@ -0,0 +1,83 @@
+package alerting
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Sink delivers alerts somewhere people will see them. Send may be called
+// from several goroutines at once.
+type Sink interface {
+	Name() string // unique among an alerter's sinks, referenced by rules
+	Send(a Alert) error
+}
+
+// LogSink writes alerts to a logger, the standard logger if nil.
+type LogSink struct {
+	Logger *log.Logger
+}
+
+func (LogSink) Name() string { return "log" }
+
+func (s LogSink) Send(a Alert) error {
+	logger := s.Logger
+	if logger == nil {
+		logger = log.Default()
+	}
+	logger.Printf("alert %s: %s", a.ID, a.Message())
+	return nil
+}
+
+// WebhookSink posts alerts as JSON to a URL.
+type WebhookSink struct {
+	URL    string
+	Client *http.Client // defaults to a client with a ten second timeout
+}
+
+func (WebhookSink) Name() string { return "webhook" }
+
+func (s WebhookSink) Send(a Alert) error {
+	body, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Post(s.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
+
+// EmailSink mails alerts through an SMTP server that accepts mail without
+// authentication, such as a local relay.
+type EmailSink struct {
+	Addr string // host:port of the SMTP server
+	From string
+	To   []string
+}
+
+func (EmailSink) Name() string { return "email" }
+
+func (s EmailSink) Send(a Alert) error {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", s.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(s.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", a.Message())
+	fmt.Fprintf(&msg, "Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&msg, "%s\r\n\r\nRule: %s\r\nRaised: %s\r\nAlert: %s\r\n", a.Message(), a.Rule, a.RaisedAt.Format(time.RFC3339), a.ID)
+	return smtp.SendMail(s.Addr, nil, s.From, s.To, []byte(msg.String()))
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/alerting"
+)
+
+type AlertDeliveryResponse struct {
+	Sink  string    `json:"sink"`
+	Error string    `json:"error,omitempty"`
+	At    time.Time `json:"at"`
+}
+
+type AlertResponse struct {
+	ID             string                  `json:"id"`
+	Rule           string                  `json:"rule"`
+	Kind           string                  `json:"kind"`
+	Message        string                  `json:"message"`
+	ISBN           string                  `json:"isbn"`
+	Title          string                  `json:"title"`
+	Location       string                  `json:"location"`
+	Condition      string                  `json:"condition"`
+	Available      int                     `json:"available"`
+	Threshold      int                     `json:"threshold"`
+	Status         string                  `json:"status"`
+	Suppressed     bool                    `json:"suppressed"` // held back by the rate limit
+	Deliveries     []AlertDeliveryResponse `json:"deliveries"`
+	RaisedAt       time.Time               `json:"raised_at"`
+	AcknowledgedBy string                  `json:"acknowledged_by,omitempty"`
+	AcknowledgedAt time.Time               `json:"acknowledged_at,omitzero"`
+	ResolvedAt     time.Time               `json:"resolved_at,omitzero"`
+}
+
+type AlertListResponse struct {
+	Alerts []AlertResponse `json:"alerts"`
+	Count  int             `json:"count"`
+}
+
+type AlertRuleResponse struct {
+	Name      string   `json:"name"`
+	Threshold int      `json:"threshold"`
+	Location  string   `json:"location,omitempty"`
+	Genre     string   `json:"genre,omitempty"`
+	ISBN      string   `json:"isbn,omitempty"`
+	Condition string   `json:"condition"`
+	Sinks     []string `json:"sinks"` // empty means every sink
+	Disabled  bool     `json:"disabled"`
+}
+
+// ListAlerts lists stock alerts, newest first, optionally narrowed to
+// ?status=open, acknowledged or resolved, ?kind=... and ?isbn=....
+func (h *Handler) ListAlerts(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	resp := AlertListResponse{Alerts: []AlertResponse{}}
+	for _, a := range h.alerts.Alerts() {
+		if s := q.Get("status"); s != "" && string(a.Status) != s {
+			continue
+		}
+		if k := q.Get("kind"); k != "" && string(a.Kind) != k {
+			continue
+		}
+		if isbn := q.Get("isbn"); isbn != "" && a.ISBN != isbn {
+			continue
+		}
+		resp.Alerts = append(resp.Alerts, toAlertResponse(a))
+	}
+	resp.Count = len(resp.Alerts)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) GetAlert(w http.ResponseWriter, r *http.Request) {
+	a, err := h.alerts.Find(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "alert not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, toAlertResponse(a))
+}
+
+// AcknowledgeAlert records that the member of staff in the X-Actor header is
+// dealing with an open alert.
+func (h *Handler) AcknowledgeAlert(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, err := h.alerts.Find(id); err != nil {
+		writeError(w, http.StatusNotFound, "alert not found")
+		return
+	}
+	actor := r.Header.Get(actorHeader)
+	if actor == "" {
+		writeError(w, http.StatusBadRequest, actorHeader+" header is required")
+		return
+	}
+	a, err := h.alerts.Acknowledge(id, actor)
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, toAlertResponse(a))
+}
+
+// ListAlertRules returns the rules stock changes are checked against.
+func (h *Handler) ListAlertRules(w http.ResponseWriter, r *http.Request) {
+	rules := h.alerts.Rules()
+	resp := make([]AlertRuleResponse, 0, len(rules))
+	for _, rule := range rules {
+		sinks := rule.Sinks
+		if sinks == nil {
+			sinks = []string{}
+		}
+		resp = append(resp, AlertRuleResponse{
+			Name:      rule.Name,
+			Threshold: rule.Threshold,
+			Location:  rule.Location,
+			Genre:     string(rule.Genre),
+			ISBN:      rule.ISBN,
+			Condition: string(rule.Condition),
+			Sinks:     sinks,
+			Disabled:  rule.Disabled,
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func toAlertResponse(a alerting.Alert) AlertResponse {
+	resp := AlertResponse{
+		ID:             a.ID,
+		Rule:           a.Rule,
+		Kind:           string(a.Kind),
+		Message:        a.Message(),
+		ISBN:           a.ISBN,
+		Title:          a.Title,
+		Location:       a.Location,
+		Condition:      string(a.Condition),
+		Available:      a.Available,
+		Threshold:      a.Threshold,
+		Status:         string(a.Status),
+		Suppressed:     a.Suppressed,
+		Deliveries:     make([]AlertDeliveryResponse, 0, len(a.Deliveries)),
+		RaisedAt:       a.RaisedAt,
+		AcknowledgedBy: a.AcknowledgedBy,
+		AcknowledgedAt: a.AcknowledgedAt,
+		ResolvedAt:     a.ResolvedAt,
+	}
+	for _, d := range a.Deliveries {
+		resp.Deliveries = append(resp.Deliveries, AlertDeliveryResponse(d))
+	}
+	return resp
+}
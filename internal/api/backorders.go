@@ -0,0 +1,175 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+type CreateBackorderRequest struct {
+	ISBN     string `json:"isbn"`
+	Customer string `json:"customer"`
+	Location string `json:"location"`
+	Quantity int    `json:"quantity"`
+}
+
+type BackorderResponse struct {
+	ID           string    `json:"id"`
+	ISBN         string    `json:"isbn"`
+	Location     string    `json:"location"`
+	Customer     string    `json:"customer"`
+	Kind         string    `json:"kind"`
+	Quantity     int       `json:"quantity"`
+	Allocated    int       `json:"allocated"`
+	Outstanding  int       `json:"outstanding"`
+	Status       string    `json:"status"`
+	Reservations []string  `json:"reservations"`
+	CreatedAt    time.Time `json:"created_at"`
+	ReleaseAt    time.Time `json:"release_at,omitzero"`
+}
+
+type BackorderListResponse struct {
+	Backorders []BackorderResponse `json:"backorders"`
+	Count      int                 `json:"count"`
+}
+
+type NotificationResponse struct {
+	Customer    string    `json:"customer"`
+	Message     string    `json:"message"`
+	Backorder   string    `json:"backorder"`
+	Reservation string    `json:"reservation"`
+	ISBN        string    `json:"isbn"`
+	Quantity    int       `json:"quantity"`
+	At          time.Time `json:"at"`
+}
+
+type NotificationListResponse struct {
+	Notifications []NotificationResponse `json:"notifications"`
+	Count         int                    `json:"count"`
+}
+
+// CreateBackorder queues a customer for copies that are out of stock, or
+// pre-orders a book that is not yet released. Copies already available are
+// allocated straight away.
+func (h *Handler) CreateBackorder(w http.ResponseWriter, r *http.Request) {
+	var req CreateBackorderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	book, err := h.repo.FindByISBN(req.ISBN)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "book not found")
+		return
+	}
+	if req.Quantity <= 0 {
+		writeError(w, http.StatusBadRequest, "quantity must be positive")
+		return
+	}
+	if req.Customer == "" {
+		writeError(w, http.StatusBadRequest, "customer must not be empty")
+		return
+	}
+	location, ok := h.resolveLocation(w, req.Location)
+	if !ok {
+		return
+	}
+	bo, err := h.inventory.PlaceBackorder(book, location, req.Quantity, req.Customer, h.clock.Now())
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, toBackorderResponse(bo))
+}
+
+// ListBackorders lists backorders in queue order, optionally narrowed to
+// ?customer=..., ?isbn=... and ?status=....
+func (h *Handler) ListBackorders(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	resp := BackorderListResponse{Backorders: []BackorderResponse{}}
+	for _, bo := range h.inventory.Backorders() {
+		if c := q.Get("customer"); c != "" && bo.Customer() != c {
+			continue
+		}
+		if isbn := q.Get("isbn"); isbn != "" && bo.ISBN().String() != isbn {
+			continue
+		}
+		if s := q.Get("status"); s != "" && string(bo.Status()) != s {
+			continue
+		}
+		resp.Backorders = append(resp.Backorders, toBackorderResponse(bo))
+	}
+	resp.Count = len(resp.Backorders)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) GetBackorder(w http.ResponseWriter, r *http.Request) {
+	bo, err := h.inventory.FindBackorder(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "backorder not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, toBackorderResponse(bo))
+}
+
+// CancelBackorder takes a waiting backorder out of the queue. Copies already
+// allocated stay reserved.
+func (h *Handler) CancelBackorder(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, err := h.inventory.FindBackorder(id); err != nil {
+		writeError(w, http.StatusNotFound, "backorder not found")
+		return
+	}
+	bo, err := h.inventory.CancelBackorder(id)
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, toBackorderResponse(bo))
+}
+
+// ListNotifications returns the allocation notices sent to ?customer=....
+func (h *Handler) ListNotifications(w http.ResponseWriter, r *http.Request) {
+	customer := r.URL.Query().Get("customer")
+	if customer == "" {
+		writeError(w, http.StatusBadRequest, "customer is required")
+		return
+	}
+	notices := h.notifications.FindByCustomer(customer)
+	resp := NotificationListResponse{Notifications: make([]NotificationResponse, 0, len(notices)), Count: len(notices)}
+	for _, a := range notices {
+		resp.Notifications = append(resp.Notifications, toNotificationResponse(a))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func toBackorderResponse(bo domain.Backorder) BackorderResponse {
+	return BackorderResponse{
+		ID:           bo.ID(),
+		ISBN:         bo.ISBN().String(),
+		Location:     bo.Location(),
+		Customer:     bo.Customer(),
+		Kind:         string(bo.Kind()),
+		Quantity:     bo.Quantity(),
+		Allocated:    bo.Allocated(),
+		Outstanding:  bo.Outstanding(),
+		Status:       string(bo.Status()),
+		Reservations: append([]string{}, bo.Reservations()...),
+		CreatedAt:    bo.CreatedAt(),
+		ReleaseAt:    bo.ReleaseAt(),
+	}
+}
+
+func toNotificationResponse(a domain.Allocation) NotificationResponse {
+	return NotificationResponse{
+		Customer:    a.Backorder.Customer(),
+		Message:     domain.AllocationMessage(a),
+		Backorder:   a.Backorder.ID(),
+		Reservation: a.Reservation.ID(),
+		ISBN:        a.Reservation.ISBN().String(),
+		Quantity:    a.Reservation.Quantity(),
+		At:          a.At,
+	}
+}
@@ -0,0 +1,258 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/calc"
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+// cartTTL is how long a cart survives without being modified.
+const cartTTL = 24 * time.Hour
+
+type CreateCartRequest struct {
+	Currency string `json:"currency"`
+	Country  string `json:"country"`
+	Region   string `json:"region"`
+}
+
+type AddCartLineRequest struct {
+	ISBN     string `json:"isbn"`
+	Quantity int    `json:"quantity"`
+}
+
+type CartResponse struct {
+	ID         string             `json:"id"`
+	CustomerID string             `json:"customer_id,omitempty"`
+	Currency   string             `json:"currency"`
+	Lines      []CartLineResponse `json:"lines"`
+	Promotions []string           `json:"promotions"`
+	ExpiresAt  time.Time          `json:"expires_at"`
+}
+
+type CartLineResponse struct {
+	ISBN     string `json:"isbn"`
+	Quantity int    `json:"quantity"`
+}
+
+type QuoteResponse struct {
+	CartID       string                     `json:"cart_id"`
+	Lines        []QuoteLineResponse        `json:"lines"`
+	Subtotal     string                     `json:"subtotal"`
+	Promotions   []AppliedPromotionResponse `json:"promotions,omitempty"`
+	DiscountName string                     `json:"discount_name,omitempty"`
+	Discount     string                     `json:"discount"`
+	TaxMode      string                     `json:"tax_mode"`
+	TaxSummary   []TaxSummaryResponse       `json:"tax_summary"`
+	Tax          string                     `json:"tax"`
+	Shipping     string                     `json:"shipping"`
+	Total        string                     `json:"total"`
+}
+
+type AppliedPromotionResponse struct {
+	Code     string `json:"code"`
+	Discount string `json:"discount"`
+}
+
+type QuoteLineResponse struct {
+	ISBN                string `json:"isbn"`
+	Title               string `json:"title"`
+	Format              string `json:"format"`
+	Quantity            int    `json:"quantity"`
+	UnitPrice           string `json:"unit_price"`
+	BulkDiscountPercent int    `json:"bulk_discount_percent"`
+	Total               string `json:"total"`
+	Discount            string `json:"discount"`
+	TaxRate             string `json:"tax_rate"`
+	Tax                 string `json:"tax"`
+}
+
+type TaxSummaryResponse struct {
+	Format  string `json:"format"`
+	TaxRate string `json:"tax_rate"`
+	Taxable string `json:"taxable"`
+	Tax     string `json:"tax"`
+}
+
+func (h *Handler) CreateCart(w http.ResponseWriter, r *http.Request) {
+	var req CreateCartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Currency == "" {
+		req.Currency = "EUR"
+	}
+
+	customerID, err := h.requestCustomer(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cart, err := domain.NewCart(domain.NewID("cart"), req.Currency, h.clock.Now(), cartTTL)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	cart.AssignCustomer(customerID)
+	if err := cart.ShipTo(req.Country, req.Region); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.carts.Save(cart)
+	writeJSON(w, http.StatusCreated, toCartResponse(cart))
+}
+
+func (h *Handler) AddCartLine(w http.ResponseWriter, r *http.Request) {
+	var req AddCartLineRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	book, err := h.repo.FindByISBN(req.ISBN)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "book not found")
+		return
+	}
+
+	id, now := r.PathValue("id"), h.clock.Now()
+	if _, err := h.carts.FindByID(id, now); err != nil {
+		writeError(w, http.StatusNotFound, "cart not found")
+		return
+	}
+
+	cart, err := h.carts.Update(id, now, func(c *domain.Cart) error {
+		if book.Price().Currency() != c.Currency() {
+			return fmt.Errorf("book is priced in %s, cart is in %s", book.Price().Currency(), c.Currency())
+		}
+		if err := c.AddLine(book.ISBN(), req.Quantity); err != nil {
+			return err
+		}
+		c.Touch(now, cartTTL)
+		return nil
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, toCartResponse(cart))
+}
+
+func (h *Handler) GetCartQuote(w http.ResponseWriter, r *http.Request) {
+	now := h.clock.Now()
+	cart, err := h.carts.FindByID(r.PathValue("id"), now)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "cart not found")
+		return
+	}
+
+	quote, err := h.quoteCart(cart, now)
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, toQuoteResponse(cart, quote))
+}
+
+// quoteCart prices every line of the cart with the pricing rules in effect at
+// now and the price list of the cart's customer.
+func (h *Handler) quoteCart(cart domain.Cart, now time.Time) (calc.Quote, error) {
+	list := h.customerPriceList(cart.CustomerID())
+	opts := h.quotes
+	opts.Tiers = list.TiersOr(opts.Tiers)
+
+	items := make([]calc.QuoteItem, 0, len(cart.Lines()))
+	for _, l := range cart.Lines() {
+		book, err := h.repo.FindByISBN(l.ISBN().String())
+		if err != nil {
+			return calc.Quote{}, fmt.Errorf("book %s is no longer available", l.ISBN())
+		}
+		unit := h.pricing.Explain(h.pricingContext(book, now, list))
+		items = append(items, calc.QuoteItem{
+			Book:         book,
+			Quantity:     l.Quantity(),
+			UnitPrice:    unit.Final,
+			MinUnitPrice: unit.Floor,
+		})
+	}
+	promos, err := h.cartPromotions(cart, now)
+	if err != nil {
+		return calc.Quote{}, err
+	}
+	country, region := cart.Destination()
+	return calc.QuoteCart(calc.QuoteRequest{
+		Currency:     cart.Currency(),
+		Items:        items,
+		Promotions:   promos,
+		Jurisdiction: calc.Jurisdiction{Country: country, Region: region},
+		At:           now,
+	}, opts)
+}
+
+func toCartResponse(c domain.Cart) CartResponse {
+	resp := CartResponse{
+		ID:         c.ID(),
+		CustomerID: c.CustomerID(),
+		Currency:   c.Currency(),
+		Lines:      make([]CartLineResponse, 0, len(c.Lines())),
+		Promotions: c.PromotionCodes(),
+		ExpiresAt:  c.ExpiresAt(),
+	}
+	for _, l := range c.Lines() {
+		resp.Lines = append(resp.Lines, CartLineResponse{ISBN: l.ISBN().String(), Quantity: l.Quantity()})
+	}
+	return resp
+}
+
+func toQuoteResponse(c domain.Cart, q calc.Quote) QuoteResponse {
+	resp := QuoteResponse{
+		CartID:       c.ID(),
+		Lines:        make([]QuoteLineResponse, 0, len(q.Lines)),
+		Subtotal:     q.Subtotal.Display(),
+		DiscountName: q.DiscountName,
+		Discount:     q.Discount.Display(),
+		TaxMode:      string(q.TaxMode),
+		TaxSummary:   make([]TaxSummaryResponse, 0, len(q.TaxSummary)),
+		Tax:          q.Tax.Display(),
+		Shipping:     q.Shipping.Display(),
+		Total:        q.Total.Display(),
+	}
+	for _, p := range q.Promotions {
+		resp.Promotions = append(resp.Promotions, AppliedPromotionResponse{Code: p.Code, Discount: p.Discount.Display()})
+	}
+	for _, l := range q.Lines {
+		resp.Lines = append(resp.Lines, QuoteLineResponse{
+			ISBN:                l.Book.ISBN().String(),
+			Title:               l.Book.Title(),
+			Format:              string(l.Book.Format()),
+			Quantity:            l.Quantity,
+			UnitPrice:           l.UnitPrice.Display(),
+			BulkDiscountPercent: l.BulkDiscountPercent,
+			Total:               l.Total.Display(),
+			Discount:            l.Discount.Display(),
+			TaxRate:             displayBasisPoints(l.TaxBasisPoints),
+			Tax:                 l.Tax.Display(),
+		})
+	}
+	for _, t := range q.TaxSummary {
+		resp.TaxSummary = append(resp.TaxSummary, TaxSummaryResponse{
+			Format:  string(t.Format),
+			TaxRate: displayBasisPoints(t.BasisPoints),
+			Taxable: t.Taxable.Display(),
+			Tax:     t.Tax.Display(),
+		})
+	}
+	return resp
+}
+
+// displayBasisPoints formats a rate such as 550 as "5.50%".
+func displayBasisPoints(bp int) string {
+	return fmt.Sprintf("%d.%02d%%", bp/100, bp%100)
+}
@@ -0,0 +1,232 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/calc"
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+type AddCopyRequest struct {
+	Serial    string `json:"serial"`
+	Location  string `json:"location"`  // defaults to the main warehouse
+	Condition string `json:"condition"` // defaults to new
+	CopyDetailsRequest
+}
+
+// CopyDetailsRequest describes a serialized copy. On revision every field is
+// replaced.
+type CopyDetailsRequest struct {
+	PriceCents int      `json:"price_cents"` // in the book's currency
+	PrintedAt  string   `json:"printed_at"`  // YYYY, YYYY-MM or YYYY-MM-DD; the copy's own printing
+	Provenance string   `json:"provenance"`
+	Signature  string   `json:"signature"`
+	Photos     []string `json:"photos"` // relative paths to condition photos
+}
+
+type HoldCopyRequest struct {
+	Holder     string `json:"holder"`
+	TTLSeconds int    `json:"ttl_seconds"`
+}
+
+type CopyResponse struct {
+	Serial       string    `json:"serial"`
+	ISBN         string    `json:"isbn"`
+	Title        string    `json:"title"`
+	Location     string    `json:"location"`
+	Condition    string    `json:"condition"`
+	Price        string    `json:"price"`
+	SellingPrice string    `json:"selling_price"` // price after the pricing rules, as GET /books/{isbn}/price?copy= quotes it
+	PrintedAt    string    `json:"printed_at,omitempty"`
+	Provenance   string    `json:"provenance,omitempty"`
+	Signature    string    `json:"signature,omitempty"`
+	Photos       []string  `json:"photos"`
+	Status       string    `json:"status"`
+	Reservation  string    `json:"reservation,omitempty"`
+	AddedAt      time.Time `json:"added_at"`
+}
+
+type CopyListResponse struct {
+	ISBN   string         `json:"isbn"`
+	Copies []CopyResponse `json:"copies"`
+	Count  int            `json:"count"`
+}
+
+// AddCopy puts a serialized copy of a catalogued book into stock, such as a
+// signed or first-edition copy sold at its own price.
+func (h *Handler) AddCopy(w http.ResponseWriter, r *http.Request) {
+	book, err := h.repo.FindByISBN(r.PathValue("isbn"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "book not found")
+		return
+	}
+	var req AddCopyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	condition, ok := parseCondition(w, req.Condition)
+	if !ok {
+		return
+	}
+	location, ok := h.resolveLocation(w, req.Location)
+	if !ok {
+		return
+	}
+	price, err := domain.NewMoney(req.PriceCents, book.Price().Currency())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	c, err := domain.NewSerialCopy(req.Serial, condition, price)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if c, err = describeCopy(c, req.CopyDetailsRequest); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	added, err := h.inventory.AddCopy(book, location, c, movementInfo(r, "", ""))
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, h.toCopyResponse(added))
+}
+
+// ListCopies lists a book's serialized copies, optionally narrowed to
+// ?status=available, reserved or sold.
+func (h *Handler) ListCopies(w http.ResponseWriter, r *http.Request) {
+	isbn, err := domain.NewISBN(r.PathValue("isbn"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	status := r.URL.Query().Get("status")
+	resp := CopyListResponse{ISBN: isbn.String(), Copies: []CopyResponse{}}
+	for _, c := range h.inventory.Copies(isbn) {
+		if status != "" && string(c.Status()) != status {
+			continue
+		}
+		resp.Copies = append(resp.Copies, h.toCopyResponse(c))
+	}
+	resp.Count = len(resp.Copies)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) GetCopy(w http.ResponseWriter, r *http.Request) {
+	c, err := h.inventory.FindCopy(r.PathValue("serial"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "copy not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, h.toCopyResponse(c))
+}
+
+// ReviseCopy replaces an unsold copy's price and description.
+func (h *Handler) ReviseCopy(w http.ResponseWriter, r *http.Request) {
+	c, err := h.inventory.FindCopy(r.PathValue("serial"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "copy not found")
+		return
+	}
+	var req CopyDetailsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	price, err := domain.NewMoney(req.PriceCents, c.Price().Currency())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	revised, err := domain.NewSerialCopy(c.Serial(), c.Condition(), price)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if revised, err = describeCopy(revised, req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if revised, err = h.inventory.ReviseCopy(revised); err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, h.toCopyResponse(revised))
+}
+
+// HoldCopy reserves one serialized copy for a holder.
+func (h *Handler) HoldCopy(w http.ResponseWriter, r *http.Request) {
+	serial := r.PathValue("serial")
+	if _, err := h.inventory.FindCopy(serial); err != nil {
+		writeError(w, http.StatusNotFound, "copy not found")
+		return
+	}
+	var req HoldCopyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Holder == "" {
+		writeError(w, http.StatusBadRequest, "holder must not be empty")
+		return
+	}
+	ttl, err := reservationTTL(req.TTLSeconds)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	res, err := h.inventory.HoldCopy(serial, req.Holder, h.clock.Now(), ttl)
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, toReservationResponse(res))
+}
+
+func describeCopy(c domain.SerialCopy, req CopyDetailsRequest) (domain.SerialCopy, error) {
+	if req.PrintedAt != "" {
+		printed, err := domain.ParsePublicationDate(req.PrintedAt)
+		if err != nil {
+			return domain.SerialCopy{}, err
+		}
+		c = c.WithPrinting(printed)
+	}
+	return c.WithProvenance(req.Provenance).WithSignature(req.Signature).WithPhotos(req.Photos...)
+}
+
+// copyPricingContext prices a copy on its own: it is one of a kind, sells at
+// its own price rather than a price list's net price, and its cost is not
+// tracked on its own.
+func (h *Handler) copyPricingContext(c domain.SerialCopy, now time.Time) calc.PricingContext {
+	ctx := h.pricingContext(c.Offer(), now, nil)
+	ctx.Stock, ctx.StockKnown = 1, true
+	ctx.UnitCost, ctx.CostKnown = domain.Money{}, false
+	return ctx
+}
+
+func (h *Handler) toCopyResponse(c domain.SerialCopy) CopyResponse {
+	resp := CopyResponse{
+		Serial:       c.Serial(),
+		ISBN:         c.Book().ISBN().String(),
+		Title:        c.Book().Title(),
+		Location:     c.Location(),
+		Condition:    string(c.Condition()),
+		Price:        c.Price().Display(),
+		SellingPrice: h.pricing.UnitPrice(h.copyPricingContext(c, h.clock.Now())).Display(),
+		Provenance:   c.Provenance(),
+		Signature:    c.Signature(),
+		Photos:       c.Photos(),
+		Status:       string(c.Status()),
+		Reservation:  c.Reservation(),
+		AddedAt:      c.AddedAt(),
+	}
+	if printed, ok := c.Printing(); ok {
+		resp.PrintedAt = printed.String()
+	}
+	return resp
+}
@@ -0,0 +1,248 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/calc"
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+// customerHeader identifies the requesting customer for customer-specific
+// pricing. The server does no authentication of its own, so the header is
+// taken on trust: whoever sets it gets that customer's net prices and tiers.
+// Deployments that serve customers directly must put the API behind a gateway
+// that authenticates the caller and sets or strips the header.
+const customerHeader = "X-Customer-ID"
+
+type PriceListRequest struct {
+	Name   string            `json:"name"`
+	Tiers  []DiscountTierDTO `json:"tiers"`
+	Prices []NetPriceDTO     `json:"prices"`
+}
+
+type DiscountTierDTO struct {
+	MinQuantity int `json:"min_quantity"`
+	Percent     int `json:"percent"`
+}
+
+type NetPriceDTO struct {
+	ISBN       string `json:"isbn"`
+	PriceCents int    `json:"price_cents"`
+	Currency   string `json:"currency"`
+}
+
+type PriceListResponse struct {
+	Name   string            `json:"name"`
+	Tiers  []DiscountTierDTO `json:"tiers,omitempty"`
+	Prices []NetPriceDTO     `json:"prices"`
+}
+
+type CreateCustomerRequest struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	PriceList string `json:"price_list"`
+}
+
+type AssignPriceListRequest struct {
+	PriceList string `json:"price_list"`
+}
+
+type CustomerResponse struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	PriceList string `json:"price_list,omitempty"`
+}
+
+func (h *Handler) ListPriceLists(w http.ResponseWriter, r *http.Request) {
+	lists := h.priceLists.FindAll()
+	resp := make([]PriceListResponse, 0, len(lists))
+	for _, pl := range lists {
+		resp = append(resp, toPriceListResponse(pl))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) GetPriceList(w http.ResponseWriter, r *http.Request) {
+	pl, err := h.priceLists.FindByName(r.PathValue("name"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "price list not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, toPriceListResponse(pl))
+}
+
+// PutPriceList creates or replaces the named price list.
+func (h *Handler) PutPriceList(w http.ResponseWriter, r *http.Request) {
+	var req PriceListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	req.Name = r.PathValue("name")
+
+	pl, err := req.toPriceList()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.priceLists.Save(pl)
+	writeJSON(w, http.StatusOK, toPriceListResponse(pl))
+}
+
+func (h *Handler) DeletePriceList(w http.ResponseWriter, r *http.Request) {
+	if err := h.priceLists.Delete(r.PathValue("name")); err != nil {
+		writeError(w, http.StatusNotFound, "price list not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) CreateCustomer(w http.ResponseWriter, r *http.Request) {
+	var req CreateCustomerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.ID == "" {
+		req.ID = domain.NewID("cust")
+	}
+	if req.Kind == "" {
+		req.Kind = string(domain.CustomerRetail)
+	}
+	if _, err := h.customers.FindByID(req.ID); err == nil {
+		writeError(w, http.StatusConflict, "customer already exists")
+		return
+	}
+	if err := h.checkPriceListExists(req.PriceList); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c, err := domain.NewCustomer(req.ID, req.Name, domain.CustomerKind(req.Kind), req.PriceList)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.customers.Save(c)
+	writeJSON(w, http.StatusCreated, toCustomerResponse(c))
+}
+
+func (h *Handler) GetCustomer(w http.ResponseWriter, r *http.Request) {
+	c, err := h.customers.FindByID(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "customer not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, toCustomerResponse(c))
+}
+
+func (h *Handler) AssignPriceList(w http.ResponseWriter, r *http.Request) {
+	c, err := h.customers.FindByID(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "customer not found")
+		return
+	}
+	var req AssignPriceListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := h.checkPriceListExists(req.PriceList); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.AssignPriceList(req.PriceList)
+	h.customers.Save(c)
+	writeJSON(w, http.StatusOK, toCustomerResponse(c))
+}
+
+// requestCustomer returns the customer named by the X-Customer-ID header, or
+// "" if the header is absent. An unknown customer is an error. The header is
+// trusted as is; see customerHeader.
+func (h *Handler) requestCustomer(r *http.Request) (string, error) {
+	id := r.Header.Get(customerHeader)
+	if id == "" {
+		return "", nil
+	}
+	if _, err := h.customers.FindByID(id); err != nil {
+		return "", fmt.Errorf("unknown customer %s", id)
+	}
+	return id, nil
+}
+
+// customerPriceList resolves the price list assigned to a customer. It returns
+// nil for anonymous customers and customers on standard pricing.
+func (h *Handler) customerPriceList(customerID string) *calc.PriceList {
+	if customerID == "" {
+		return nil
+	}
+	c, err := h.customers.FindByID(customerID)
+	if err != nil || c.PriceList() == "" {
+		return nil
+	}
+	pl, err := h.priceLists.FindByName(c.PriceList())
+	if err != nil {
+		return nil
+	}
+	return &pl
+}
+
+func (h *Handler) checkPriceListExists(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, err := h.priceLists.FindByName(name); err != nil {
+		return fmt.Errorf("unknown price list %s", name)
+	}
+	return nil
+}
+
+func (req PriceListRequest) toPriceList() (calc.PriceList, error) {
+	if req.Name == "" {
+		return calc.PriceList{}, errors.New("price list name must not be empty")
+	}
+	pl := calc.PriceList{Name: req.Name, Overrides: make(map[string]domain.Money, len(req.Prices))}
+	for _, p := range req.Prices {
+		isbn, err := domain.NewISBN(p.ISBN)
+		if err != nil {
+			return calc.PriceList{}, err
+		}
+		price, err := domain.NewMoney(p.PriceCents, p.Currency)
+		if err != nil {
+			return calc.PriceList{}, err
+		}
+		pl.Overrides[isbn.String()] = price
+	}
+	for _, t := range req.Tiers {
+		pl.Tiers = append(pl.Tiers, calc.DiscountTier{MinQuantity: t.MinQuantity, Percent: t.Percent})
+	}
+	return pl, pl.Validate()
+}
+
+func toPriceListResponse(pl calc.PriceList) PriceListResponse {
+	resp := PriceListResponse{Name: pl.Name, Prices: make([]NetPriceDTO, 0, len(pl.Overrides))}
+	for _, t := range pl.Tiers {
+		resp.Tiers = append(resp.Tiers, DiscountTierDTO{MinQuantity: t.MinQuantity, Percent: t.Percent})
+	}
+	for isbn, price := range pl.Overrides {
+		resp.Prices = append(resp.Prices, NetPriceDTO{ISBN: isbn, PriceCents: price.Amount(), Currency: price.Currency()})
+	}
+	slices.SortFunc(resp.Prices, func(a, b NetPriceDTO) int { return strings.Compare(a.ISBN, b.ISBN) })
+	return resp
+}
+
+func toCustomerResponse(c domain.Customer) CustomerResponse {
+	return CustomerResponse{
+		ID:        c.ID(),
+		Name:      c.Name(),
+		Kind:      string(c.Kind()),
+		PriceList: c.PriceList(),
+	}
+}
@@ -2,39 +2,205 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"slices"
+	"strconv"
 	"time"
 
+	"github.com/sergekukharev/agent-test-writer-validator/internal/alerting"
+	"github.com/sergekukharev/agent-test-writer-validator/internal/calc"
 	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
 	"github.com/sergekukharev/agent-test-writer-validator/internal/storage"
 )
 
+// Deps bundles the repositories and services the handler serves requests from.
+type Deps struct {
+	Books      *storage.BookRepository
+	Carts      *storage.CartRepository
+	Promotions *storage.PromotionRepository
+	Customers  *storage.CustomerRepository
+	PriceLists *storage.PriceListRepository
+	Prices     *storage.PriceHistoryRepository
+	Suppliers  *storage.SupplierRepository
+	Reorder    *storage.ReorderPolicyRepository
+	Orders     *storage.PurchaseOrderRepository
+	Inventory  *domain.Inventory
+	Notices    *storage.NotificationRepository
+	Alerts     *alerting.Alerter
+	Pricing    *calc.PricingEngine
+	Quotes     calc.QuoteOptions
+	Clock      domain.Clock      // defaults to the system clock
+	Ages       *domain.AgePolicy // classic and recent thresholds, defaults to domain.DefaultAgePolicy
+}
+
 type Handler struct {
-	repo *storage.BookRepository
+	repo            *storage.BookRepository
+	carts           *storage.CartRepository
+	promotions      *storage.PromotionRepository
+	customers       *storage.CustomerRepository
+	priceLists      *storage.PriceListRepository
+	prices          *storage.PriceHistoryRepository
+	suppliers       *storage.SupplierRepository
+	reorderPolicies *storage.ReorderPolicyRepository
+	purchaseOrders  *storage.PurchaseOrderRepository
+	notifications   *storage.NotificationRepository
+	alerts          *alerting.Alerter
+	inventory       *domain.Inventory
+	pricing         *calc.PricingEngine
+	quotes          calc.QuoteOptions
+	clock           domain.Clock
+	ages            *domain.AgePolicy
 }
 
-func NewHandler(repo *storage.BookRepository) *Handler {
-	return &Handler{repo: repo}
+func NewHandler(d Deps) *Handler {
+	if d.Clock == nil {
+		d.Clock = domain.SystemClock{}
+	}
+	return &Handler{
+		repo:            d.Books,
+		carts:           d.Carts,
+		promotions:      d.Promotions,
+		customers:       d.Customers,
+		priceLists:      d.PriceLists,
+		prices:          d.Prices,
+		suppliers:       d.Suppliers,
+		reorderPolicies: d.Reorder,
+		purchaseOrders:  d.Orders,
+		notifications:   d.Notices,
+		alerts:          d.Alerts,
+		inventory:       d.Inventory,
+		pricing:         d.Pricing,
+		quotes:          d.Quotes,
+		clock:           d.Clock,
+		ages:            d.Ages,
+	}
 }
 
 func (h *Handler) Routes() *http.ServeMux {
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /books", h.ListBooks)
 	mux.HandleFunc("GET /books/{isbn}", h.GetBook)
+	mux.HandleFunc("GET /books/{isbn}/price", h.GetPrice)
+	mux.HandleFunc("GET /books/{isbn}/price-history", h.GetPriceHistory)
+	mux.HandleFunc("POST /books/{isbn}/price-history", h.SchedulePrice)
 	mux.HandleFunc("POST /books", h.CreateBook)
 	mux.HandleFunc("DELETE /books/{isbn}", h.DeleteBook)
+	mux.HandleFunc("POST /carts", h.CreateCart)
+	mux.HandleFunc("POST /carts/{id}/lines", h.AddCartLine)
+	mux.HandleFunc("GET /carts/{id}/quote", h.GetCartQuote)
+	mux.HandleFunc("POST /carts/{id}/promotions", h.ApplyCartPromotion)
+	mux.HandleFunc("DELETE /carts/{id}/promotions/{code}", h.RemoveCartPromotion)
+	mux.HandleFunc("GET /promotions", h.ListPromotions)
+	mux.HandleFunc("POST /promotions", h.CreatePromotion)
+	mux.HandleFunc("GET /promotions/{code}", h.GetPromotion)
+	mux.HandleFunc("PUT /promotions/{code}", h.UpdatePromotion)
+	mux.HandleFunc("DELETE /promotions/{code}", h.DeletePromotion)
+	mux.HandleFunc("POST /promotions/{code}/validate", h.ValidatePromotion)
+	mux.HandleFunc("POST /promotions/{code}/redeem", h.RedeemPromotion)
+	mux.HandleFunc("GET /price-lists", h.ListPriceLists)
+	mux.HandleFunc("GET /price-lists/{name}", h.GetPriceList)
+	mux.HandleFunc("PUT /price-lists/{name}", h.PutPriceList)
+	mux.HandleFunc("DELETE /price-lists/{name}", h.DeletePriceList)
+	mux.HandleFunc("POST /customers", h.CreateCustomer)
+	mux.HandleFunc("GET /customers/{id}", h.GetCustomer)
+	mux.HandleFunc("PUT /customers/{id}/price-list", h.AssignPriceList)
+	mux.HandleFunc("GET /locations", h.ListLocations)
+	mux.HandleFunc("POST /locations", h.CreateLocation)
+	mux.HandleFunc("POST /backorders", h.CreateBackorder)
+	mux.HandleFunc("GET /backorders", h.ListBackorders)
+	mux.HandleFunc("GET /backorders/{id}", h.GetBackorder)
+	mux.HandleFunc("POST /backorders/{id}/cancel", h.CancelBackorder)
+	mux.HandleFunc("GET /notifications", h.ListNotifications)
+	mux.HandleFunc("GET /alerts", h.ListAlerts)
+	mux.HandleFunc("GET /alerts/{id}", h.GetAlert)
+	mux.HandleFunc("POST /alerts/{id}/acknowledge", h.AcknowledgeAlert)
+	mux.HandleFunc("GET /alert-rules", h.ListAlertRules)
+	mux.HandleFunc("GET /suppliers", h.ListSuppliers)
+	mux.HandleFunc("POST /suppliers", h.CreateSupplier)
+	mux.HandleFunc("GET /suppliers/{code}", h.GetSupplier)
+	mux.HandleFunc("PUT /suppliers/{code}", h.UpdateSupplier)
+	mux.HandleFunc("GET /reorder-policies", h.ListReorderPolicies)
+	mux.HandleFunc("GET /inventory/{isbn}/reorder-policy", h.GetReorderPolicy)
+	mux.HandleFunc("PUT /inventory/{isbn}/reorder-policy", h.SetReorderPolicy)
+	mux.HandleFunc("DELETE /inventory/{isbn}/reorder-policy", h.DeleteReorderPolicy)
+	mux.HandleFunc("POST /purchase-orders/suggest", h.SuggestPurchaseOrders)
+	mux.HandleFunc("GET /purchase-orders", h.ListPurchaseOrders)
+	mux.HandleFunc("POST /purchase-orders", h.CreatePurchaseOrder)
+	mux.HandleFunc("GET /purchase-orders/{id}", h.GetPurchaseOrder)
+	mux.HandleFunc("PUT /purchase-orders/{id}/lines/{isbn}", h.SetPurchaseOrderLine)
+	mux.HandleFunc("POST /purchase-orders/{id}/approve", h.ApprovePurchaseOrder)
+	mux.HandleFunc("POST /purchase-orders/{id}/send", h.SendPurchaseOrder)
+	mux.HandleFunc("POST /purchase-orders/{id}/receive", h.ReceivePurchaseOrder)
+	mux.HandleFunc("POST /purchase-orders/{id}/close", h.ClosePurchaseOrder)
+	mux.HandleFunc("POST /transfers", h.CreateTransfer)
+	mux.HandleFunc("GET /transfers", h.ListTransfers)
+	mux.HandleFunc("GET /transfers/{id}", h.GetTransfer)
+	mux.HandleFunc("POST /transfers/{id}/receive", h.ReceiveTransfer)
+	mux.HandleFunc("POST /transfers/{id}/close", h.CloseTransfer)
+	mux.HandleFunc("POST /transfers/{id}/cancel", h.CancelTransfer)
+	mux.HandleFunc("POST /stock-counts", h.CreateStockCount)
+	mux.HandleFunc("GET /stock-counts", h.ListStockCounts)
+	mux.HandleFunc("GET /stock-counts/{id}", h.GetStockCount)
+	mux.HandleFunc("PUT /stock-counts/{id}/lines/{isbn}", h.RecordCount)
+	mux.HandleFunc("POST /stock-counts/{id}/submit", h.SubmitStockCount)
+	mux.HandleFunc("GET /stock-counts/{id}/variances", h.StockCountVariances)
+	mux.HandleFunc("POST /stock-counts/{id}/approve", h.ApproveStockCount)
+	mux.HandleFunc("POST /stock-counts/{id}/cancel", h.CancelStockCount)
+	mux.HandleFunc("GET /inventory", h.ListStock)
+	mux.HandleFunc("POST /inventory", h.CreateStock)
+	mux.HandleFunc("GET /inventory/low-stock", h.LowStock)
+	mux.HandleFunc("GET /inventory/value", h.InventoryValue)
+	mux.HandleFunc("GET /inventory/reconciliation", h.Reconcile)
+	mux.HandleFunc("GET /inventory/{isbn}", h.GetStock)
+	mux.HandleFunc("GET /inventory/{isbn}/movements", h.ListMovements)
+	mux.HandleFunc("PUT /inventory/{isbn}/bin", h.AssignBin)
+	mux.HandleFunc("POST /inventory/{isbn}/reserve", h.HoldStock)
+	mux.HandleFunc("POST /inventory/{isbn}/restock", h.Restock)
+	mux.HandleFunc("POST /inventory/{isbn}/returns", h.ReturnStock)
+	mux.HandleFunc("POST /inventory/{isbn}/lots", h.ReceiveLot)
+	mux.HandleFunc("GET /inventory/{isbn}/variants", h.ListVariants)
+	mux.HandleFunc("PUT /inventory/{isbn}/variants/{condition}/price", h.SetVariantPrice)
+	mux.HandleFunc("GET /inventory/{isbn}/copies", h.ListCopies)
+	mux.HandleFunc("POST /inventory/{isbn}/copies", h.AddCopy)
+	mux.HandleFunc("GET /copies/{serial}", h.GetCopy)
+	mux.HandleFunc("PUT /copies/{serial}", h.ReviseCopy)
+	mux.HandleFunc("POST /copies/{serial}/reserve", h.HoldCopy)
+	mux.HandleFunc("GET /reservations", h.ListReservations)
+	mux.HandleFunc("POST /reservations", h.CreateReservations)
+	mux.HandleFunc("GET /reservations/{id}", h.GetReservation)
+	mux.HandleFunc("POST /reservations/{id}/extend", h.ExtendReservation)
+	mux.HandleFunc("POST /reservations/{id}/confirm", h.ConfirmReservation)
+	mux.HandleFunc("POST /reservations/{id}/fulfil", h.FulfilReservation)
+	mux.HandleFunc("DELETE /reservations/{id}", h.ReleaseReservation)
+	mux.HandleFunc("GET /reports/margins", h.MarginReport)
+	mux.HandleFunc("GET /reports/valuation", h.ValuationReport)
 	return mux
 }
 
+// ListBooks lists the catalogue. ?condition=... narrows it to the books with
+// copies available in that condition.
 func (h *Handler) ListBooks(w http.ResponseWriter, r *http.Request) {
 	books := h.repo.FindAll()
+	if c := r.URL.Query().Get("condition"); c != "" {
+		condition, ok := parseCondition(w, c)
+		if !ok {
+			return
+		}
+		books = slices.DeleteFunc(books, func(b domain.Book) bool {
+			return !slices.ContainsFunc(h.inventory.Variants(b.ISBN()), func(v domain.StockEntry) bool {
+				return v.Condition() == condition && v.Available() > 0
+			})
+		})
+	}
 
 	resp := ListResponse{
 		Books: make([]BookResponse, 0, len(books)),
 		Count: len(books),
 	}
+	now := h.clock.Now()
 	for _, b := range books {
-		resp.Books = append(resp.Books, toBookResponse(b))
+		resp.Books = append(resp.Books, h.toBookResponse(b, now))
 	}
 	writeJSON(w, http.StatusOK, resp)
 }
@@ -46,17 +212,156 @@ func (h *Handler) GetBook(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusNotFound, "book not found")
 		return
 	}
-	writeJSON(w, http.StatusOK, toBookResponse(book))
+	writeJSON(w, http.StatusOK, h.toBookResponse(book, h.clock.Now()))
+}
+
+// GetPrice returns the rule-adjusted unit price and the bulk-discounted total
+// for ?quantity=N copies (default 1). With ?explain=true the response also
+// lists every adjustment step that produced the total. Requests carrying an
+// X-Customer-ID header are priced with that customer's price list.
+// ?as_of=<date or RFC 3339 time> previews the price at another moment, using
+// the list price scheduled for then and evaluating age and date rules at it.
+// ?condition=... prices used copies in that condition from their own price,
+// and ?copy=<serial> prices one serialized copy; customer net prices and bulk
+// tiers apply to new copies only.
+func (h *Handler) GetPrice(w http.ResponseWriter, r *http.Request) {
+	isbn := r.PathValue("isbn")
+	book, err := h.repo.FindByISBN(isbn)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "book not found")
+		return
+	}
+
+	quantity := 1
+	if q := r.URL.Query().Get("quantity"); q != "" {
+		quantity, err = strconv.Atoi(q)
+		if err != nil || quantity <= 0 {
+			writeError(w, http.StatusBadRequest, "quantity must be a positive integer")
+			return
+		}
+	}
+
+	explain := false
+	if e := r.URL.Query().Get("explain"); e != "" {
+		explain, err = strconv.ParseBool(e)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "explain must be a boolean")
+			return
+		}
+	}
+
+	customerID, err := h.requestCustomer(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	list := h.customerPriceList(customerID)
+
+	condition, ok := parseCondition(w, r.URL.Query().Get("condition"))
+	if !ok {
+		return
+	}
+
+	now := h.clock.Now()
+	if a := r.URL.Query().Get("as_of"); a != "" {
+		now, err = parseAsOf(a)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		// Preview with the list price that will be (or was) in effect then.
+		if price, err := h.prices.PriceAt(isbn, now); err == nil {
+			if book, err = book.WithPrice(price); err != nil {
+				writeError(w, http.StatusConflict, err.Error())
+				return
+			}
+		}
+	}
+
+	ctx := h.pricingContext(book, now, list)
+	if condition.IsUsed() {
+		variant, ok := h.findVariant(w, r, condition)
+		if !ok {
+			return
+		}
+		ctx.Book = variant.Offer()
+		ctx.Stock, ctx.StockKnown = variant.Available(), true
+		ctx.UnitCost, ctx.CostKnown = variant.AverageUnitCost()
+		// Price lists hold net prices for new copies; used copies keep
+		// their own price.
+		ctx.PriceList = nil
+	}
+	serial := r.URL.Query().Get("copy")
+	if serial != "" {
+		c, err := h.inventory.FindCopy(serial)
+		if err != nil || c.Book().ISBN() != book.ISBN() {
+			writeError(w, http.StatusNotFound, "copy not found")
+			return
+		}
+		if c.Status() == domain.CopySold {
+			writeError(w, http.StatusConflict, "copy is sold")
+			return
+		}
+		if quantity != 1 {
+			writeError(w, http.StatusBadRequest, "a copy is priced one at a time")
+			return
+		}
+		ctx = h.copyPricingContext(c, now)
+		condition = c.Condition()
+	}
+	unit := h.pricing.Explain(ctx)
+	// Like net prices, the customer's bulk tiers apply to new copies only;
+	// ctx carries the price list only when pricing them.
+	line := calc.ExplainLineTotal(unit, quantity, ctx.PriceList.TiersOr(calc.StandardTiers))
+	resp := PriceResponse{
+		ISBN:      book.ISBN().String(),
+		Condition: string(condition),
+		Serial:    serial,
+		Quantity:  quantity,
+		UnitPrice: unit.Final.Display(),
+		Total:     line.Final.Display(),
+	}
+	if explain {
+		resp.Explanation = toPriceStepResponses(line)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// parseAsOf accepts a full RFC 3339 time or a plain YYYY-MM-DD date, which
+// means midnight UTC.
+func parseAsOf(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, errors.New("as_of must be a YYYY-MM-DD date or an RFC 3339 time")
+	}
+	return t, nil
+}
+
+// pricingContext gathers what the pricing rules may inspect about a book,
+// including its stock level and average cost when it is held in inventory.
+func (h *Handler) pricingContext(book domain.Book, now time.Time, list *calc.PriceList) calc.PricingContext {
+	ctx := calc.PricingContext{Book: book, Now: now, PriceList: list, Ages: h.ages}
+	if entry, err := h.inventory.Find(book.ISBN()); err == nil {
+		ctx.Stock, ctx.StockKnown = entry.Available(), true
+		ctx.UnitCost, ctx.CostKnown = entry.AverageUnitCost()
+	}
+	return ctx
 }
 
 type CreateBookRequest struct {
-	ISBN      string `json:"isbn"`
-	Title     string `json:"title"`
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	PriceCents int   `json:"price_cents"`
-	Currency  string `json:"currency"`
-	Genre     string `json:"genre"`
+	ISBN        string `json:"isbn"`
+	Title       string `json:"title"`
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name"`
+	PriceCents  int    `json:"price_cents"`
+	Currency    string `json:"currency"`
+	Genre       string `json:"genre"`
+	Format      string `json:"format"`
+	Publisher   string `json:"publisher"`
+	PublishedAt string `json:"published_at"` // YYYY, YYYY-MM, YYYY-MM-DD or RFC 3339; defaults to now
 }
 
 func (h *Handler) CreateBook(w http.ResponseWriter, r *http.Request) {
@@ -84,14 +389,33 @@ func (h *Handler) CreateBook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	book, err := domain.NewBook(isbn, req.Title, author, price, time.Now(), domain.Genre(req.Genre))
+	book, err := domain.NewBook(isbn, req.Title, author, price, h.clock.Now(), domain.Genre(req.Genre))
 	if err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	if req.PublishedAt != "" {
+		published, err := domain.ParsePublicationDate(req.PublishedAt)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		book = book.WithPublication(published)
+	}
+	book = book.WithPublisher(req.Publisher)
+	if req.Format != "" {
+		if book, err = book.WithFormat(domain.Format(req.Format)); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
 
+	if err := h.prices.Record(book, h.clock.Now()); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	h.repo.Save(book)
-	writeJSON(w, http.StatusCreated, toBookResponse(book))
+	writeJSON(w, http.StatusCreated, h.toBookResponse(book, h.clock.Now()))
 }
 
 func (h *Handler) DeleteBook(w http.ResponseWriter, r *http.Request) {
@@ -103,13 +427,31 @@ func (h *Handler) DeleteBook(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func toBookResponse(b domain.Book) BookResponse {
+func toPriceStepResponses(b calc.PriceBreakdown) []PriceStepResponse {
+	steps := make([]PriceStepResponse, 0, len(b.Steps))
+	for _, st := range b.Steps {
+		steps = append(steps, PriceStepResponse{
+			Rule:   st.Name,
+			Input:  st.Input.Display(),
+			Delta:  st.Delta.Display(),
+			Result: st.Result.Display(),
+		})
+	}
+	return steps
+}
+
+func (h *Handler) toBookResponse(b domain.Book, now time.Time) BookResponse {
 	return BookResponse{
-		ISBN:      b.ISBN().String(),
-		Title:     b.Title(),
-		Author:    b.Author().FullName(),
-		Price:     b.Price().Display(),
-		Genre:     string(b.Genre()),
-		IsClassic: b.IsClassic(),
+		ISBN:        b.ISBN().String(),
+		Title:       b.Title(),
+		Author:      b.Author().FullName(),
+		Price:       b.Price().Display(),
+		Genre:       string(b.Genre()),
+		Format:      string(b.Format()),
+		Publisher:   b.Publisher(),
+		PublishedAt: b.Publication().String(),
+		IsClassic:   h.ages.IsClassic(b, now),
+		IsRecent:    h.ages.IsRecent(b, now),
+		Variants:    h.bookVariants(b.ISBN()),
 	}
 }
@@ -0,0 +1,431 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+type CreateStockRequest struct {
+	ISBN       string `json:"isbn"`
+	Total      int    `json:"total"`
+	Location   string `json:"location"` // defaults to the main warehouse
+	Bin        string `json:"bin"`
+	Condition  string `json:"condition"`   // new (default), like_new, very_good, good or acceptable
+	PriceCents int    `json:"price_cents"` // used copies only, in the book's currency
+}
+
+type StockQuantityRequest struct {
+	Location  string `json:"location"`
+	Condition string `json:"condition"` // defaults to new
+	Quantity  int    `json:"quantity"`
+	Reason    string `json:"reason"`    // reason code for the ledger, defaults by movement type
+	Reference string `json:"reference"` // e.g. a delivery note number
+}
+
+type StockResponse struct {
+	ISBN      string            `json:"isbn"`
+	Title     string            `json:"title"`
+	Location  string            `json:"location,omitempty"` // empty when summed across locations
+	Bin       string            `json:"bin,omitempty"`
+	Condition string            `json:"condition,omitempty"` // set for used copies
+	Price     string            `json:"price,omitempty"`     // set for used copies
+	Total     int               `json:"total"`
+	Reserved  int               `json:"reserved"`
+	Available int               `json:"available"`
+	Damaged   int               `json:"damaged"`
+	InTransit int               `json:"in_transit"`
+	Locations []StockResponse   `json:"locations,omitempty"`
+	Variants  []VariantResponse `json:"variants,omitempty"` // set when used copies are stocked
+}
+
+type AssignBinRequest struct {
+	Location string `json:"location"`
+	Bin      string `json:"bin"`
+}
+
+type ReturnStockRequest struct {
+	Location  string `json:"location"`
+	Quantity  int    `json:"quantity"`
+	Condition string `json:"condition"` // sellable (default) or damaged
+	Reason    string `json:"reason"`
+	Reference string `json:"reference"` // e.g. the order the copies were sold on
+}
+
+type StockListResponse struct {
+	Entries []StockResponse `json:"entries"`
+	Count   int             `json:"count"`
+}
+
+type InventoryValueResponse struct {
+	Location string   `json:"location,omitempty"`
+	Titles   int      `json:"titles"`
+	Copies   int      `json:"copies"`
+	Values   []string `json:"values"` // one total per currency, at list price
+}
+
+type ReceiveLotRequest struct {
+	Location      string `json:"location"`
+	Quantity      int    `json:"quantity"`
+	UnitCostCents int    `json:"unit_cost_cents"`
+	Currency      string `json:"currency"`
+	Supplier      string `json:"supplier"`
+	Reference     string `json:"reference"`
+}
+
+type StockLotResponse struct {
+	Quantity   int       `json:"quantity"`
+	UnitCost   string    `json:"unit_cost"`
+	Supplier   string    `json:"supplier,omitempty"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+type StockCostResponse struct {
+	ISBN            string             `json:"isbn"`
+	Location        string             `json:"location"`
+	Total           int                `json:"total"`
+	AverageUnitCost string             `json:"average_unit_cost,omitempty"`
+	Lots            []StockLotResponse `json:"lots"`
+}
+
+// CreateStock starts tracking stock for a catalogued book. Used copies need
+// a condition and their own price.
+func (h *Handler) CreateStock(w http.ResponseWriter, r *http.Request) {
+	var req CreateStockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	book, err := h.repo.FindByISBN(req.ISBN)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "book not found")
+		return
+	}
+	location, ok := h.resolveLocation(w, req.Location)
+	if !ok {
+		return
+	}
+	condition, ok := parseCondition(w, req.Condition)
+	if !ok {
+		return
+	}
+	var entry domain.StockEntry
+	if condition.IsUsed() {
+		if req.PriceCents <= 0 {
+			writeError(w, http.StatusBadRequest, "price_cents must be positive for used copies")
+			return
+		}
+		price, _ := domain.NewMoney(req.PriceCents, book.Price().Currency())
+		entry, err = domain.NewUsedStockEntry(book, condition, price, req.Total)
+	} else {
+		if req.PriceCents != 0 {
+			writeError(w, http.StatusBadRequest, "new copies sell at the book's price")
+			return
+		}
+		entry, err = domain.NewStockEntry(book, req.Total)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	entry = entry.WithLocation(location, req.Bin)
+	if err := h.inventory.Create(entry, movementInfo(r, "", "")); err != nil {
+		if _, exists := h.inventory.FindVariant(book.ISBN(), location, condition); exists == nil {
+			err = errors.New("stock entry already exists")
+		}
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, toStockResponse(entry))
+}
+
+// ListStock lists stock at ?location=..., or every book summed across
+// locations if no location is given.
+func (h *Handler) ListStock(w http.ResponseWriter, r *http.Request) {
+	location, ok := h.queryLocation(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, toStockListResponse(h.inventory.EntriesAt(location)))
+}
+
+// GetStock returns a book's new stock summed across locations with a
+// breakdown per location and, when used copies are stocked, per condition;
+// or only the new stock at ?location=....
+func (h *Handler) GetStock(w http.ResponseWriter, r *http.Request) {
+	entry, ok := h.findStock(w, r)
+	if !ok {
+		return
+	}
+	location, ok := h.queryLocation(w, r)
+	if !ok {
+		return
+	}
+	if location != "" {
+		at, err := h.inventory.FindAt(entry.Book().ISBN(), location)
+		if err != nil {
+			writeError(w, http.StatusNotFound, "stock entry not found at location")
+			return
+		}
+		writeJSON(w, http.StatusOK, toStockResponse(at))
+		return
+	}
+	resp := toStockResponse(entry)
+	for _, e := range h.inventory.StockByLocation(entry.Book().ISBN()) {
+		resp.Locations = append(resp.Locations, toStockResponse(e))
+	}
+	resp.Variants = h.bookVariants(entry.Book().ISBN())
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// AssignBin shelves a book's stock at a location in a named bin.
+func (h *Handler) AssignBin(w http.ResponseWriter, r *http.Request) {
+	entry, ok := h.findStock(w, r)
+	if !ok {
+		return
+	}
+	var req AssignBinRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	updated, err := h.inventory.AssignBin(entry.Book().ISBN(), req.Location, req.Bin)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "stock entry not found at location")
+		return
+	}
+	writeJSON(w, http.StatusOK, toStockResponse(updated))
+}
+
+// Restock adds uncosted copies in the requested condition; use ReceiveLot
+// for deliveries with a known cost.
+func (h *Handler) Restock(w http.ResponseWriter, r *http.Request) {
+	h.adjustStock(w, r, h.inventory.RestockVariant)
+}
+
+// ReturnStock takes returned copies back into sellable or damaged stock.
+func (h *Handler) ReturnStock(w http.ResponseWriter, r *http.Request) {
+	entry, ok := h.findStock(w, r)
+	if !ok {
+		return
+	}
+	var req ReturnStockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Quantity <= 0 {
+		writeError(w, http.StatusBadRequest, "quantity must be positive")
+		return
+	}
+	condition := domain.ReturnCondition(req.Condition)
+	switch condition {
+	case "":
+		condition = domain.ReturnSellable
+	case domain.ReturnSellable, domain.ReturnDamaged:
+	default:
+		writeError(w, http.StatusBadRequest, "condition must be sellable or damaged")
+		return
+	}
+	if _, err := h.inventory.FindAt(entry.Book().ISBN(), req.Location); err != nil {
+		writeError(w, http.StatusNotFound, "stock entry not found at location")
+		return
+	}
+	updated, err := h.inventory.Return(entry.Book().ISBN(), req.Location, req.Quantity, condition, movementInfo(r, req.Reason, req.Reference))
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, toStockResponse(updated))
+}
+
+// adjustStock applies a quantity change to the entry in the path in the
+// requested condition. Quantity checks fail with 400; changes the stock
+// level cannot absorb fail with 409.
+func (h *Handler) adjustStock(w http.ResponseWriter, r *http.Request, apply func(domain.ISBN, string, domain.Condition, int, domain.MovementInfo) (domain.StockEntry, error)) {
+	var req StockQuantityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	condition, ok := parseCondition(w, req.Condition)
+	if !ok {
+		return
+	}
+	entry, ok := h.findVariant(w, r, condition)
+	if !ok {
+		return
+	}
+	if req.Quantity <= 0 {
+		writeError(w, http.StatusBadRequest, "quantity must be positive")
+		return
+	}
+	if _, err := h.inventory.FindVariant(entry.Book().ISBN(), req.Location, condition); err != nil {
+		writeError(w, http.StatusNotFound, "stock entry not found at location")
+		return
+	}
+	updated, err := apply(entry.Book().ISBN(), req.Location, condition, req.Quantity, movementInfo(r, req.Reason, req.Reference))
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, toStockResponse(updated))
+}
+
+// LowStock lists entries with fewer than ?threshold=N available copies
+// (default 5), at ?location=... or summed across locations.
+func (h *Handler) LowStock(w http.ResponseWriter, r *http.Request) {
+	threshold := 5
+	if t := r.URL.Query().Get("threshold"); t != "" {
+		var err error
+		threshold, err = strconv.Atoi(t)
+		if err != nil || threshold <= 0 {
+			writeError(w, http.StatusBadRequest, "threshold must be a positive integer")
+			return
+		}
+	}
+	location, ok := h.queryLocation(w, r)
+	if !ok {
+		return
+	}
+	var low []domain.StockEntry
+	for _, e := range h.inventory.EntriesAt(location) {
+		if e.IsLowStock(threshold) {
+			low = append(low, e)
+		}
+	}
+	writeJSON(w, http.StatusOK, toStockListResponse(low))
+}
+
+// InventoryValue totals stock at list price, at ?location=... or across all
+// locations.
+func (h *Handler) InventoryValue(w http.ResponseWriter, r *http.Request) {
+	location, ok := h.queryLocation(w, r)
+	if !ok {
+		return
+	}
+	entries := h.inventory.EntriesAt(location)
+	resp := InventoryValueResponse{Location: location, Titles: len(entries), Values: []string{}}
+	for _, e := range entries {
+		resp.Copies += e.Total()
+	}
+	for _, v := range h.inventory.ValueByCurrency(location) {
+		resp.Values = append(resp.Values, v.Display())
+	}
+	slices.Sort(resp.Values)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) findStock(w http.ResponseWriter, r *http.Request) (domain.StockEntry, bool) {
+	isbn, err := domain.NewISBN(r.PathValue("isbn"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return domain.StockEntry{}, false
+	}
+	entry, err := h.inventory.Find(isbn)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "stock entry not found")
+		return domain.StockEntry{}, false
+	}
+	return entry, true
+}
+
+// ReceiveLot records a costed delivery of copies, creating the book's stock
+// entry if it has none yet.
+func (h *Handler) ReceiveLot(w http.ResponseWriter, r *http.Request) {
+	book, err := h.repo.FindByISBN(r.PathValue("isbn"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "book not found")
+		return
+	}
+
+	var req ReceiveLotRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Currency == "" {
+		req.Currency = book.Price().Currency()
+	}
+	cost, err := domain.NewMoney(req.UnitCostCents, req.Currency)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	lot, err := domain.NewStockLot(req.Quantity, cost, req.Supplier, h.clock.Now())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	location, ok := h.resolveLocation(w, req.Location)
+	if !ok {
+		return
+	}
+	entry, err := h.inventory.ReceiveLot(book, location, lot, movementInfo(r, "", req.Reference))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, toStockCostResponse(entry))
+}
+
+func toStockCostResponse(e domain.StockEntry) StockCostResponse {
+	resp := StockCostResponse{
+		ISBN:     e.Book().ISBN().String(),
+		Location: e.Location(),
+		Total:    e.Total(),
+		Lots:     make([]StockLotResponse, 0, len(e.Lots())),
+	}
+	if avg, ok := e.AverageUnitCost(); ok {
+		resp.AverageUnitCost = avg.Display()
+	}
+	for _, l := range e.Lots() {
+		resp.Lots = append(resp.Lots, StockLotResponse{
+			Quantity:   l.Quantity(),
+			UnitCost:   l.UnitCost().Display(),
+			Supplier:   l.Supplier(),
+			ReceivedAt: l.ReceivedAt(),
+		})
+	}
+	return resp
+}
+
+func toStockResponse(e domain.StockEntry) StockResponse {
+	resp := StockResponse{
+		ISBN:      e.Book().ISBN().String(),
+		Title:     e.Book().Title(),
+		Location:  e.Location(),
+		Bin:       e.Bin(),
+		Total:     e.Total(),
+		Reserved:  e.Reserved(),
+		Available: e.Available(),
+		Damaged:   e.Damaged(),
+		InTransit: e.InTransit(),
+	}
+	if e.Condition().IsUsed() {
+		resp.Condition, resp.Price = string(e.Condition()), e.Price().Display()
+	}
+	return resp
+}
+
+// toStockListResponse orders entries by ISBN and location so listings are stable.
+func toStockListResponse(entries []domain.StockEntry) StockListResponse {
+	resp := StockListResponse{Entries: make([]StockResponse, 0, len(entries)), Count: len(entries)}
+	for _, e := range entries {
+		resp.Entries = append(resp.Entries, toStockResponse(e))
+	}
+	slices.SortFunc(resp.Entries, func(a, b StockResponse) int {
+		if c := strings.Compare(a.ISBN, b.ISBN); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Location, b.Location)
+	})
+	return resp
+}
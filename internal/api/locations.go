@@ -0,0 +1,73 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+type LocationRequest struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+	Kind string `json:"kind"` // store or warehouse
+}
+
+type LocationResponse struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+func (h *Handler) ListLocations(w http.ResponseWriter, r *http.Request) {
+	locations := h.inventory.Locations()
+	resp := make([]LocationResponse, 0, len(locations))
+	for _, loc := range locations {
+		resp = append(resp, toLocationResponse(loc))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// CreateLocation registers a shop or warehouse that can hold stock.
+func (h *Handler) CreateLocation(w http.ResponseWriter, r *http.Request) {
+	var req LocationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	loc, err := domain.NewLocation(req.Code, req.Name, domain.LocationKind(req.Kind))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.inventory.AddLocation(loc); err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, toLocationResponse(loc))
+}
+
+// resolveLocation checks that a location named in a request exists. An empty
+// name means the default location.
+func (h *Handler) resolveLocation(w http.ResponseWriter, code string) (string, bool) {
+	loc, err := h.inventory.FindLocation(code)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "location not found")
+		return "", false
+	}
+	return loc.Code(), true
+}
+
+// queryLocation reads an optional ?location=... filter. An empty result
+// means all locations.
+func (h *Handler) queryLocation(w http.ResponseWriter, r *http.Request) (string, bool) {
+	code := r.URL.Query().Get("location")
+	if code == "" {
+		return "", true
+	}
+	return h.resolveLocation(w, code)
+}
+
+func toLocationResponse(loc domain.Location) LocationResponse {
+	return LocationResponse{Code: loc.Code(), Name: loc.Name(), Kind: string(loc.Kind())}
+}
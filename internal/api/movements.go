@@ -0,0 +1,122 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+// actorHeader names the member of staff or system behind a stock change; it
+// is recorded on every ledger movement the request causes.
+const actorHeader = "X-Actor"
+
+type MovementResponse struct {
+	Seq       int       `json:"seq"`
+	Location  string    `json:"location"`
+	Condition string    `json:"condition"`
+	Serial    string    `json:"serial,omitempty"`
+	Type      string    `json:"type"`
+	Quantity  int       `json:"quantity"`
+	Reason    string    `json:"reason"`
+	Actor     string    `json:"actor,omitempty"`
+	Reference string    `json:"reference,omitempty"`
+	UnitCost  string    `json:"unit_cost,omitempty"`
+	Cost      string    `json:"cost,omitempty"` // cost of goods sold or lost
+	At        time.Time `json:"at"`
+}
+
+type MovementListResponse struct {
+	ISBN      string             `json:"isbn"`
+	Movements []MovementResponse `json:"movements"`
+	Count     int                `json:"count"`
+}
+
+type ReconciliationResponse struct {
+	Balanced      bool                  `json:"balanced"`
+	Entries       int                   `json:"entries"`
+	Movements     int                   `json:"movements"`
+	Discrepancies []DiscrepancyResponse `json:"discrepancies"`
+}
+
+type DiscrepancyResponse struct {
+	ISBN      string `json:"isbn"`
+	Location  string `json:"location"`
+	Condition string `json:"condition"`
+	Measure   string `json:"measure"`
+	Ledger    int    `json:"ledger"`
+	Recorded  int    `json:"recorded"`
+}
+
+// ListMovements returns the stock ledger for a book in every condition,
+// oldest first.
+func (h *Handler) ListMovements(w http.ResponseWriter, r *http.Request) {
+	isbn, err := domain.NewISBN(r.PathValue("isbn"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(h.inventory.Variants(isbn)) == 0 {
+		writeError(w, http.StatusNotFound, "stock entry not found")
+		return
+	}
+	movements := h.inventory.Movements(isbn)
+	resp := MovementListResponse{ISBN: isbn.String(), Movements: make([]MovementResponse, 0, len(movements)), Count: len(movements)}
+	for _, m := range movements {
+		resp.Movements = append(resp.Movements, toMovementResponse(m))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Reconcile replays the ledger and reports balances that do not match it.
+func (h *Handler) Reconcile(w http.ResponseWriter, r *http.Request) {
+	rec := h.inventory.Reconcile()
+	resp := ReconciliationResponse{
+		Balanced:      rec.Balanced(),
+		Entries:       rec.Entries,
+		Movements:     rec.Movements,
+		Discrepancies: make([]DiscrepancyResponse, 0, len(rec.Discrepancies)),
+	}
+	for _, d := range rec.Discrepancies {
+		resp.Discrepancies = append(resp.Discrepancies, DiscrepancyResponse{
+			ISBN:      d.ISBN.String(),
+			Location:  d.Location,
+			Condition: string(d.Condition),
+			Measure:   d.Measure,
+			Ledger:    d.Ledger,
+			Recorded:  d.Recorded,
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// movementInfo attributes a stock change to the request's actor.
+func movementInfo(r *http.Request, reason, reference string) domain.MovementInfo {
+	return domain.MovementInfo{
+		Reason:    domain.ReasonCode(reason),
+		Actor:     r.Header.Get(actorHeader),
+		Reference: reference,
+	}
+}
+
+func toMovementResponse(m domain.Movement) MovementResponse {
+	resp := MovementResponse{
+		Seq:       m.Seq(),
+		Location:  m.Location(),
+		Condition: string(m.Condition()),
+		Serial:    m.Serial(),
+		Type:      string(m.Type()),
+		Quantity:  m.Quantity(),
+		Reason:    string(m.Reason()),
+		Actor:     m.Actor(),
+		Reference: m.Reference(),
+		At:        m.At(),
+	}
+	if lot, ok := m.Lot(); ok {
+		resp.UnitCost = lot.UnitCost().Display()
+	}
+	if cost, ok := m.Cost(); ok {
+		resp.Cost = cost.Display()
+	}
+	return resp
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+type SchedulePriceRequest struct {
+	PriceCents    int       `json:"price_cents"`
+	Currency      string    `json:"currency"`
+	EffectiveFrom time.Time `json:"effective_from"`
+	EffectiveTo   time.Time `json:"effective_to"`
+}
+
+type PriceHistoryResponse struct {
+	ISBN    string                `json:"isbn"`
+	Periods []PricePeriodResponse `json:"periods"`
+	At      *PriceAtResponse      `json:"at,omitempty"`
+}
+
+type PricePeriodResponse struct {
+	Price         string    `json:"price"`
+	EffectiveFrom time.Time `json:"effective_from"`
+	EffectiveTo   time.Time `json:"effective_to,omitzero"`
+	Status        string    `json:"status"` // past, current, overridden or scheduled
+}
+
+type PriceAtResponse struct {
+	Time  time.Time `json:"time"`
+	Price string    `json:"price"`
+}
+
+// GetPriceHistory returns the book's price timeline. With ?at=<RFC 3339 time>
+// it also reports the price in effect at that moment.
+func (h *Handler) GetPriceHistory(w http.ResponseWriter, r *http.Request) {
+	isbn := r.PathValue("isbn")
+	timeline, err := h.prices.FindByISBN(isbn)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "price history not found")
+		return
+	}
+
+	resp := toPriceHistoryResponse(timeline, h.clock.Now())
+	if at := r.URL.Query().Get("at"); at != "" {
+		t, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "at must be an RFC 3339 time")
+			return
+		}
+		price, ok := timeline.PriceAt(t)
+		if !ok {
+			writeError(w, http.StatusNotFound, "no price in effect at that time")
+			return
+		}
+		resp.At = &PriceAtResponse{Time: t, Price: price.Display()}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// SchedulePrice adds a future price period, e.g. a Black Friday price with an
+// end date after which the regular price resumes.
+func (h *Handler) SchedulePrice(w http.ResponseWriter, r *http.Request) {
+	book, err := h.repo.FindByISBN(r.PathValue("isbn"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "book not found")
+		return
+	}
+
+	var req SchedulePriceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Currency == "" {
+		req.Currency = book.Price().Currency()
+	}
+	price, err := domain.NewMoney(req.PriceCents, req.Currency)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	now := h.clock.Now()
+	timeline, err := h.prices.Schedule(book.ISBN().String(), price, req.EffectiveFrom, req.EffectiveTo, now)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, toPriceHistoryResponse(timeline, now))
+}
+
+func toPriceHistoryResponse(t domain.PriceTimeline, now time.Time) PriceHistoryResponse {
+	periods := t.Periods()
+	resp := PriceHistoryResponse{ISBN: t.ISBN().String(), Periods: make([]PricePeriodResponse, len(periods))}
+
+	// Walk backwards: the latest-starting period covering now is the current one.
+	foundCurrent := false
+	for i := len(periods) - 1; i >= 0; i-- {
+		p := periods[i]
+		status := "past"
+		switch {
+		case p.EffectiveFrom().After(now):
+			status = "scheduled"
+		case p.Contains(now) && !foundCurrent:
+			status = "current"
+			foundCurrent = true
+		case p.Contains(now):
+			status = "overridden"
+		}
+		resp.Periods[i] = PricePeriodResponse{
+			Price:         p.Price().Display(),
+			EffectiveFrom: p.EffectiveFrom(),
+			EffectiveTo:   p.EffectiveTo(),
+			Status:        status,
+		}
+	}
+	return resp
+}
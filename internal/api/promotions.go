@@ -0,0 +1,329 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/calc"
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+	"github.com/sergekukharev/agent-test-writer-validator/internal/storage"
+)
+
+type PromotionRequest struct {
+	Code           string    `json:"code"`
+	Kind           string    `json:"kind"`
+	Value          int       `json:"value"`
+	BuyQuantity    int       `json:"buy_quantity"`
+	GetQuantity    int       `json:"get_quantity"`
+	Genres         []string  `json:"genres"`
+	ValidFrom      time.Time `json:"valid_from"`
+	ValidUntil     time.Time `json:"valid_until"`
+	MaxRedemptions int       `json:"max_redemptions"`
+	MaxPerCustomer int       `json:"max_per_customer"`
+	Stackable      bool      `json:"stackable"`
+}
+
+type PromotionResponse struct {
+	Code           string    `json:"code"`
+	Kind           string    `json:"kind"`
+	Value          int       `json:"value,omitempty"`
+	BuyQuantity    int       `json:"buy_quantity,omitempty"`
+	GetQuantity    int       `json:"get_quantity,omitempty"`
+	Genres         []string  `json:"genres,omitempty"`
+	ValidFrom      time.Time `json:"valid_from,omitzero"`
+	ValidUntil     time.Time `json:"valid_until,omitzero"`
+	MaxRedemptions int       `json:"max_redemptions,omitempty"`
+	MaxPerCustomer int       `json:"max_per_customer,omitempty"`
+	Stackable      bool      `json:"stackable"`
+	Redemptions    int       `json:"redemptions"`
+}
+
+type PromotionListResponse struct {
+	Promotions []PromotionResponse `json:"promotions"`
+	Count      int                 `json:"count"`
+}
+
+type ValidatePromotionRequest struct {
+	CartID     string `json:"cart_id"`
+	CustomerID string `json:"customer_id"`
+}
+
+type ValidatePromotionResponse struct {
+	Code     string `json:"code"`
+	Valid    bool   `json:"valid"`
+	Reason   string `json:"reason,omitempty"`
+	Discount string `json:"discount,omitempty"`
+}
+
+type RedeemPromotionRequest struct {
+	CustomerID string `json:"customer_id"`
+}
+
+type ApplyPromotionRequest struct {
+	Code string `json:"code"`
+}
+
+func (h *Handler) ListPromotions(w http.ResponseWriter, r *http.Request) {
+	promos := h.promotions.FindAll()
+	resp := PromotionListResponse{
+		Promotions: make([]PromotionResponse, 0, len(promos)),
+		Count:      len(promos),
+	}
+	for _, p := range promos {
+		resp.Promotions = append(resp.Promotions, h.toPromotionResponse(p))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) GetPromotion(w http.ResponseWriter, r *http.Request) {
+	p, err := h.promotions.FindByCode(r.PathValue("code"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "promotion not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, h.toPromotionResponse(p))
+}
+
+func (h *Handler) CreatePromotion(w http.ResponseWriter, r *http.Request) {
+	var req PromotionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	p, err := req.toPromotion(req.Code)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.promotions.Create(p); err != nil {
+		writeError(w, http.StatusConflict, "promotion already exists")
+		return
+	}
+	writeJSON(w, http.StatusCreated, h.toPromotionResponse(p))
+}
+
+func (h *Handler) UpdatePromotion(w http.ResponseWriter, r *http.Request) {
+	code := r.PathValue("code")
+	if _, err := h.promotions.FindByCode(code); err != nil {
+		writeError(w, http.StatusNotFound, "promotion not found")
+		return
+	}
+	var req PromotionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	p, err := req.toPromotion(code)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.promotions.Save(p)
+	writeJSON(w, http.StatusOK, h.toPromotionResponse(p))
+}
+
+func (h *Handler) DeletePromotion(w http.ResponseWriter, r *http.Request) {
+	if err := h.promotions.Delete(r.PathValue("code")); err != nil {
+		writeError(w, http.StatusNotFound, "promotion not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ValidatePromotion checks whether a customer could use the code on a cart
+// right now and, if so, how much it would take off.
+func (h *Handler) ValidatePromotion(w http.ResponseWriter, r *http.Request) {
+	var req ValidatePromotionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	now := h.clock.Now()
+	code := domain.NormalizePromotionCode(r.PathValue("code"))
+	resp := ValidatePromotionResponse{Code: code}
+
+	if _, err := h.promotions.CheckRedeemable(code, req.CustomerID, now); err != nil {
+		resp.Reason = err.Error()
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	if req.CartID != "" {
+		cart, err := h.carts.FindByID(req.CartID, now)
+		if err != nil {
+			writeError(w, http.StatusNotFound, "cart not found")
+			return
+		}
+		cart.ApplyPromotionCode(code)
+		quote, err := h.quoteCart(cart, now)
+		if err != nil {
+			resp.Reason = err.Error()
+			writeJSON(w, http.StatusOK, resp)
+			return
+		}
+		for _, ap := range quote.Promotions {
+			if ap.Code == code {
+				resp.Discount = ap.Discount.Display()
+			}
+		}
+	}
+
+	resp.Valid = true
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// RedeemPromotion records that a customer used the code, enforcing the
+// global and per-customer redemption limits.
+func (h *Handler) RedeemPromotion(w http.ResponseWriter, r *http.Request) {
+	var req RedeemPromotionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.CustomerID == "" {
+		writeError(w, http.StatusBadRequest, "customer_id must not be empty")
+		return
+	}
+
+	code := r.PathValue("code")
+	if _, err := h.promotions.FindByCode(code); err != nil {
+		writeError(w, http.StatusNotFound, "promotion not found")
+		return
+	}
+	p, err := h.promotions.Redeem(code, req.CustomerID, h.clock.Now())
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, h.toPromotionResponse(p))
+}
+
+func (h *Handler) ApplyCartPromotion(w http.ResponseWriter, r *http.Request) {
+	var req ApplyPromotionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	now := h.clock.Now()
+	p, err := h.promotions.FindByCode(req.Code)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "promotion not found")
+		return
+	}
+
+	id := r.PathValue("id")
+	if _, err := h.carts.FindByID(id, now); err != nil {
+		writeError(w, http.StatusNotFound, "cart not found")
+		return
+	}
+	// The code must be redeemable by the cart's customer and stack with the
+	// codes already applied; checked under the cart's lock so concurrent
+	// applications cannot combine codes that may not stack.
+	var unusable error
+	cart, err := h.carts.Update(id, now, func(c *domain.Cart) error {
+		c.ApplyPromotionCode(p.Code())
+		if _, unusable = h.cartPromotions(*c, now); unusable != nil {
+			return unusable
+		}
+		c.Touch(now, cartTTL)
+		return nil
+	})
+	if unusable != nil {
+		writeError(w, http.StatusConflict, unusable.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusNotFound, "cart not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, toCartResponse(cart))
+}
+
+func (h *Handler) RemoveCartPromotion(w http.ResponseWriter, r *http.Request) {
+	now := h.clock.Now()
+	id := r.PathValue("id")
+	if _, err := h.carts.FindByID(id, now); err != nil {
+		writeError(w, http.StatusNotFound, "cart not found")
+		return
+	}
+	cart, err := h.carts.Update(id, now, func(c *domain.Cart) error {
+		return c.RemovePromotionCode(r.PathValue("code"))
+	})
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, toCartResponse(cart))
+}
+
+// cartPromotions resolves the cart's applied codes, failing if any is no
+// longer redeemable by the cart's customer or the codes may not be combined.
+func (h *Handler) cartPromotions(cart domain.Cart, now time.Time) ([]domain.Promotion, error) {
+	codes := cart.PromotionCodes()
+	promos := make([]domain.Promotion, 0, len(codes))
+	for _, code := range codes {
+		p, err := h.promotions.CheckRedeemable(code, cart.CustomerID(), now)
+		if errors.Is(err, storage.ErrPromotionNotFound) {
+			return nil, fmt.Errorf("promotion %s no longer exists", code)
+		}
+		if err != nil {
+			return nil, err
+		}
+		promos = append(promos, p)
+	}
+	if err := calc.CheckPromotionStacking(promos); err != nil {
+		return nil, err
+	}
+	return promos, nil
+}
+
+func (req PromotionRequest) toPromotion(code string) (domain.Promotion, error) {
+	if code == "" {
+		return domain.Promotion{}, errors.New("promotion code must not be empty")
+	}
+	genres := make([]domain.Genre, 0, len(req.Genres))
+	for _, g := range req.Genres {
+		genres = append(genres, domain.Genre(g))
+	}
+	return domain.NewPromotion(code, domain.PromotionTerms{
+		Kind:           domain.PromotionKind(req.Kind),
+		Value:          req.Value,
+		BuyQuantity:    req.BuyQuantity,
+		GetQuantity:    req.GetQuantity,
+		Genres:         genres,
+		ValidFrom:      req.ValidFrom,
+		ValidUntil:     req.ValidUntil,
+		MaxRedemptions: req.MaxRedemptions,
+		MaxPerCustomer: req.MaxPerCustomer,
+		Stackable:      req.Stackable,
+	})
+}
+
+func (h *Handler) toPromotionResponse(p domain.Promotion) PromotionResponse {
+	t := p.Terms()
+	total, _ := h.promotions.Redemptions(p.Code(), "")
+	resp := PromotionResponse{
+		Code:           p.Code(),
+		Kind:           string(t.Kind),
+		Value:          t.Value,
+		BuyQuantity:    t.BuyQuantity,
+		GetQuantity:    t.GetQuantity,
+		ValidFrom:      t.ValidFrom,
+		ValidUntil:     t.ValidUntil,
+		MaxRedemptions: t.MaxRedemptions,
+		MaxPerCustomer: t.MaxPerCustomer,
+		Stackable:      t.Stackable,
+		Redemptions:    total,
+	}
+	for _, g := range t.Genres {
+		resp.Genres = append(resp.Genres, string(g))
+	}
+	slices.Sort(resp.Genres)
+	return resp
+}
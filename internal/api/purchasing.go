@@ -0,0 +1,420 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+	"github.com/sergekukharev/agent-test-writer-validator/internal/storage"
+)
+
+type ReorderPolicyRequest struct {
+	ReorderPoint    int    `json:"reorder_point"`
+	ReorderQuantity int    `json:"reorder_quantity"`
+	LeadTimeDays    int    `json:"lead_time_days"`
+	Supplier        string `json:"supplier"`
+}
+
+type ReorderPolicyResponse struct {
+	ISBN            string `json:"isbn"`
+	ReorderPoint    int    `json:"reorder_point"`
+	ReorderQuantity int    `json:"reorder_quantity"`
+	LeadTimeDays    int    `json:"lead_time_days"`
+	Supplier        string `json:"supplier"`
+}
+
+type PurchaseOrderLineRequest struct {
+	Quantity      int    `json:"quantity"`        // zero removes the line
+	UnitCostCents int    `json:"unit_cost_cents"` // in the supplier's currency; zero keeps the line's cost
+	ExpectedAt    string `json:"expected_at"`     // YYYY-MM-DD or RFC 3339, optional
+}
+
+type CreatePurchaseOrderRequest struct {
+	Supplier string                           `json:"supplier"`
+	Location string                           `json:"location"` // defaults to the main warehouse
+	Lines    []CreatePurchaseOrderLineRequest `json:"lines"`
+}
+
+type CreatePurchaseOrderLineRequest struct {
+	ISBN string `json:"isbn"`
+	PurchaseOrderLineRequest
+}
+
+type ReceivePurchaseOrderRequest struct {
+	Items        []StockItemRequest `json:"items"`
+	ChargesCents int                `json:"charges_cents"` // freight, duties and the like, in the supplier's currency
+}
+
+type PurchaseOrderResponse struct {
+	ID         string                         `json:"id"`
+	Supplier   string                         `json:"supplier"`
+	Location   string                         `json:"location"`
+	Status     string                         `json:"status"`
+	Lines      []PurchaseOrderLineResponse    `json:"lines"`
+	Receipts   []PurchaseOrderReceiptResponse `json:"receipts"`
+	CreatedAt  time.Time                      `json:"created_at"`
+	ApprovedBy string                         `json:"approved_by,omitempty"`
+	ApprovedAt time.Time                      `json:"approved_at,omitzero"`
+	SentAt     time.Time                      `json:"sent_at,omitzero"`
+	ClosedAt   time.Time                      `json:"closed_at,omitzero"`
+}
+
+type PurchaseOrderLineResponse struct {
+	ISBN        string    `json:"isbn"`
+	Quantity    int       `json:"quantity"`
+	UnitCost    string    `json:"unit_cost,omitempty"`
+	ExpectedAt  time.Time `json:"expected_at,omitzero"`
+	Received    int       `json:"received"`
+	Outstanding int       `json:"outstanding"`
+}
+
+type PurchaseOrderReceiptResponse struct {
+	ISBN           string    `json:"isbn"`
+	Quantity       int       `json:"quantity"`
+	UnitCost       string    `json:"unit_cost"`
+	LandedUnitCost string    `json:"landed_unit_cost"`
+	ReceivedAt     time.Time `json:"received_at"`
+}
+
+type PurchaseOrderListResponse struct {
+	Orders []PurchaseOrderResponse `json:"orders"`
+	Count  int                     `json:"count"`
+}
+
+// SetReorderPolicy sets when and how much of a catalog book to reorder.
+func (h *Handler) SetReorderPolicy(w http.ResponseWriter, r *http.Request) {
+	book, err := h.repo.FindByISBN(r.PathValue("isbn"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "book not found")
+		return
+	}
+	var req ReorderPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if _, err := h.suppliers.FindByCode(req.Supplier); err != nil {
+		writeError(w, http.StatusNotFound, "supplier not found")
+		return
+	}
+	leadTime := time.Duration(req.LeadTimeDays) * 24 * time.Hour
+	policy, err := domain.NewReorderPolicy(book.ISBN(), req.ReorderPoint, req.ReorderQuantity, leadTime, req.Supplier)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.reorderPolicies.Save(policy)
+	writeJSON(w, http.StatusOK, toReorderPolicyResponse(policy))
+}
+
+func (h *Handler) GetReorderPolicy(w http.ResponseWriter, r *http.Request) {
+	policy, err := h.reorderPolicies.FindByISBN(r.PathValue("isbn"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "reorder policy not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, toReorderPolicyResponse(policy))
+}
+
+func (h *Handler) DeleteReorderPolicy(w http.ResponseWriter, r *http.Request) {
+	if err := h.reorderPolicies.Delete(r.PathValue("isbn")); err != nil {
+		writeError(w, http.StatusNotFound, "reorder policy not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) ListReorderPolicies(w http.ResponseWriter, r *http.Request) {
+	policies := h.reorderPolicies.FindAll()
+	resp := make([]ReorderPolicyResponse, 0, len(policies))
+	for _, p := range policies {
+		resp = append(resp, toReorderPolicyResponse(p))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// SuggestPurchaseOrders runs the reorder job now and returns the drafts it
+// created. Books already on order are not drafted again.
+func (h *Handler) SuggestPurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	drafts := storage.GenerateReorderDrafts(h.inventory, h.reorderPolicies, h.purchaseOrders, h.clock.Now())
+	writeJSON(w, http.StatusOK, toPurchaseOrderListResponse(drafts))
+}
+
+// ListPurchaseOrders lists orders, optionally narrowed to ?status=... and
+// ?supplier=....
+func (h *Handler) ListPurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	supplier := r.URL.Query().Get("supplier")
+	var matched []domain.PurchaseOrder
+	for _, o := range h.purchaseOrders.FindAll() {
+		if status != "" && string(o.Status()) != status {
+			continue
+		}
+		if supplier != "" && o.Supplier() != supplier {
+			continue
+		}
+		matched = append(matched, o)
+	}
+	writeJSON(w, http.StatusOK, toPurchaseOrderListResponse(matched))
+}
+
+func (h *Handler) GetPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	o, err := h.purchaseOrders.FindByID(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "purchase order not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, toPurchaseOrderResponse(o))
+}
+
+// CreatePurchaseOrder starts a draft order with a supplier.
+func (h *Handler) CreatePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	var req CreatePurchaseOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	supplier, err := h.suppliers.FindByCode(req.Supplier)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "supplier not found")
+		return
+	}
+	location, ok := h.resolveLocation(w, req.Location)
+	if !ok {
+		return
+	}
+	o := domain.NewPurchaseOrder(supplier.Code(), h.clock.Now())
+	o.DeliverTo(location)
+	for _, line := range req.Lines {
+		book, err := h.repo.FindByISBN(line.ISBN)
+		if err != nil {
+			writeError(w, http.StatusNotFound, "book not found")
+			return
+		}
+		cost, expectedAt, err := parsePurchaseOrderLine(line.PurchaseOrderLineRequest, supplier)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := o.SetLine(book.ISBN(), line.Quantity, cost, expectedAt); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	h.purchaseOrders.Save(o)
+	writeJSON(w, http.StatusCreated, toPurchaseOrderResponse(o))
+}
+
+// SetPurchaseOrderLine changes the copies of a book on a draft order, adding
+// or, with a quantity of zero, removing the line.
+func (h *Handler) SetPurchaseOrderLine(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	current, err := h.purchaseOrders.FindByID(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "purchase order not found")
+		return
+	}
+	book, err := h.repo.FindByISBN(r.PathValue("isbn"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "book not found")
+		return
+	}
+	supplier, err := h.suppliers.FindByCode(current.Supplier())
+	if err != nil {
+		writeError(w, http.StatusConflict, "supplier not found")
+		return
+	}
+	var req PurchaseOrderLineRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	cost, expectedAt, err := parsePurchaseOrderLine(req, supplier)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	o, err := h.purchaseOrders.Update(id, func(o *domain.PurchaseOrder) error {
+		return o.SetLine(book.ISBN(), req.Quantity, cost, expectedAt)
+	})
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, toPurchaseOrderResponse(o))
+}
+
+// parsePurchaseOrderLine validates a line request, returning its unit cost
+// in the supplier's currency (zero if not given) and expected arrival.
+func parsePurchaseOrderLine(req PurchaseOrderLineRequest, supplier domain.Supplier) (domain.Money, time.Time, error) {
+	if req.Quantity < 0 {
+		return domain.Money{}, time.Time{}, errors.New("quantity must not be negative")
+	}
+	if req.UnitCostCents < 0 {
+		return domain.Money{}, time.Time{}, errors.New("unit_cost_cents must not be negative")
+	}
+	var expectedAt time.Time
+	if req.ExpectedAt != "" {
+		var err error
+		if expectedAt, err = parseAsOf(req.ExpectedAt); err != nil {
+			return domain.Money{}, time.Time{}, errors.New("expected_at must be YYYY-MM-DD or RFC 3339")
+		}
+	}
+	var cost domain.Money
+	if req.UnitCostCents > 0 {
+		cost, _ = domain.NewMoney(req.UnitCostCents, supplier.Currency())
+	}
+	return cost, expectedAt, nil
+}
+
+// ApprovePurchaseOrder signs off a reviewed draft, recording the approver
+// from the X-Actor header.
+func (h *Handler) ApprovePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, err := h.purchaseOrders.FindByID(id); err != nil {
+		writeError(w, http.StatusNotFound, "purchase order not found")
+		return
+	}
+	o, err := h.purchaseOrders.Update(id, func(o *domain.PurchaseOrder) error {
+		return o.Approve(r.Header.Get(actorHeader), h.clock.Now())
+	})
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, toPurchaseOrderResponse(o))
+}
+
+// SendPurchaseOrder places a draft or approved order with its supplier.
+func (h *Handler) SendPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	current, err := h.purchaseOrders.FindByID(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "purchase order not found")
+		return
+	}
+	supplier, err := h.suppliers.FindByCode(current.Supplier())
+	if err != nil {
+		writeError(w, http.StatusConflict, "supplier not found")
+		return
+	}
+	o, err := h.purchaseOrders.Update(id, func(o *domain.PurchaseOrder) error {
+		return o.Send(supplier, h.clock.Now())
+	})
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, toPurchaseOrderResponse(o))
+}
+
+// ReceivePurchaseOrder books a delivery against a sent order and puts the
+// copies into stock at its delivery location. It may be called several
+// times for partial deliveries.
+func (h *Handler) ReceivePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	current, err := h.purchaseOrders.FindByID(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "purchase order not found")
+		return
+	}
+	var req ReceivePurchaseOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	items, ok := stockItems(w, req.Items)
+	if !ok {
+		return
+	}
+	if req.ChargesCents < 0 {
+		writeError(w, http.StatusBadRequest, "charges_cents must not be negative")
+		return
+	}
+	var charges domain.Money
+	if lines := current.Lines(); req.ChargesCents > 0 && len(lines) > 0 {
+		charges, _ = domain.NewMoney(req.ChargesCents, lines[0].UnitCost().Currency())
+	}
+	o, err := storage.ReceivePurchaseOrder(h.purchaseOrders, h.repo, h.inventory, id, items, charges, movementInfo(r, "", ""), h.clock.Now())
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, toPurchaseOrderResponse(o))
+}
+
+// ClosePurchaseOrder ends a sent order; outstanding copies will not arrive.
+func (h *Handler) ClosePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, err := h.purchaseOrders.FindByID(id); err != nil {
+		writeError(w, http.StatusNotFound, "purchase order not found")
+		return
+	}
+	o, err := h.purchaseOrders.Update(id, func(o *domain.PurchaseOrder) error {
+		return o.Close(h.clock.Now())
+	})
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, toPurchaseOrderResponse(o))
+}
+
+func toReorderPolicyResponse(p domain.ReorderPolicy) ReorderPolicyResponse {
+	return ReorderPolicyResponse{
+		ISBN:            p.ISBN().String(),
+		ReorderPoint:    p.ReorderPoint(),
+		ReorderQuantity: p.ReorderQuantity(),
+		LeadTimeDays:    int(p.LeadTime() / (24 * time.Hour)),
+		Supplier:        p.Supplier(),
+	}
+}
+
+func toPurchaseOrderResponse(o domain.PurchaseOrder) PurchaseOrderResponse {
+	resp := PurchaseOrderResponse{
+		ID:         o.ID(),
+		Supplier:   o.Supplier(),
+		Location:   o.Location(),
+		Status:     string(o.Status()),
+		Lines:      []PurchaseOrderLineResponse{},
+		Receipts:   []PurchaseOrderReceiptResponse{},
+		CreatedAt:  o.CreatedAt(),
+		ApprovedBy: o.ApprovedBy(),
+		ApprovedAt: o.ApprovedAt(),
+		SentAt:     o.SentAt(),
+		ClosedAt:   o.ClosedAt(),
+	}
+	for _, l := range o.Lines() {
+		line := PurchaseOrderLineResponse{
+			ISBN:        l.ISBN().String(),
+			Quantity:    l.Quantity(),
+			ExpectedAt:  l.ExpectedAt(),
+			Received:    l.Received(),
+			Outstanding: l.Outstanding(),
+		}
+		if l.UnitCost().Currency() != "" {
+			line.UnitCost = l.UnitCost().Display()
+		}
+		resp.Lines = append(resp.Lines, line)
+	}
+	for _, rc := range o.Receipts() {
+		resp.Receipts = append(resp.Receipts, PurchaseOrderReceiptResponse{
+			ISBN:           rc.ISBN().String(),
+			Quantity:       rc.Quantity(),
+			UnitCost:       rc.UnitCost().Display(),
+			LandedUnitCost: rc.LandedUnitCost().Display(),
+			ReceivedAt:     rc.ReceivedAt(),
+		})
+	}
+	return resp
+}
+
+func toPurchaseOrderListResponse(list []domain.PurchaseOrder) PurchaseOrderListResponse {
+	resp := PurchaseOrderListResponse{Orders: make([]PurchaseOrderResponse, 0, len(list)), Count: len(list)}
+	for _, o := range list {
+		resp.Orders = append(resp.Orders, toPurchaseOrderResponse(o))
+	}
+	return resp
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/calc"
+)
+
+type MarginReportResponse struct {
+	GroupBy      string                `json:"group_by"`
+	Groups       []MarginGroupResponse `json:"groups"`
+	MinMargin    string                `json:"min_margin,omitempty"`
+	BelowMinimum []BelowMarginResponse `json:"below_minimum,omitempty"`
+}
+
+type MarginGroupResponse struct {
+	Key              string `json:"key"`
+	Titles           int    `json:"titles"`
+	Copies           int    `json:"copies"`
+	RetailValueCents int    `json:"retail_value_cents"`
+	CostValueCents   int    `json:"cost_value_cents"`
+	Margin           string `json:"margin"`
+	Markup           string `json:"markup"`
+}
+
+type BelowMarginResponse struct {
+	ISBN     string `json:"isbn"`
+	Title    string `json:"title"`
+	Price    string `json:"price"`
+	UnitCost string `json:"unit_cost"`
+	Margin   string `json:"margin"`
+}
+
+var marginGroupKeys = map[string]calc.GroupKey{
+	"genre":     calc.ByGenreKey,
+	"author":    calc.ByAuthorKey,
+	"publisher": calc.ByPublisherKey,
+}
+
+// MarginReport reports gross margin and markup at list price for all costed
+// stock, grouped by ?group_by=genre|author|publisher (default genre). With
+// ?min_margin=N (basis points) it also lists titles earning less than N.
+func (h *Handler) MarginReport(w http.ResponseWriter, r *http.Request) {
+	groupBy := r.URL.Query().Get("group_by")
+	if groupBy == "" {
+		groupBy = "genre"
+	}
+	key, ok := marginGroupKeys[groupBy]
+	if !ok {
+		writeError(w, http.StatusBadRequest, "group_by must be genre, author or publisher")
+		return
+	}
+
+	minMargin := -1
+	if m := r.URL.Query().Get("min_margin"); m != "" {
+		var err error
+		minMargin, err = strconv.Atoi(m)
+		if err != nil || minMargin < 0 || minMargin >= 10000 {
+			writeError(w, http.StatusBadRequest, "min_margin must be between 0 and 9999 basis points")
+			return
+		}
+	}
+
+	var stock []calc.CostedStock
+	for _, e := range h.inventory.EntriesAt("") {
+		cost, ok := e.AverageUnitCost()
+		if !ok {
+			continue
+		}
+		stock = append(stock, calc.CostedStock{Book: e.Book(), UnitCost: cost, Quantity: e.Total()})
+	}
+
+	resp := MarginReportResponse{GroupBy: groupBy, Groups: make([]MarginGroupResponse, 0)}
+	for _, g := range calc.MarginBy(stock, key) {
+		resp.Groups = append(resp.Groups, MarginGroupResponse{
+			Key:              g.Key,
+			Titles:           g.Titles,
+			Copies:           g.Copies,
+			RetailValueCents: g.RetailValue,
+			CostValueCents:   g.CostValue,
+			Margin:           displayBasisPoints(g.MarginBP),
+			Markup:           displayBasisPoints(g.MarkupBP),
+		})
+	}
+
+	if minMargin >= 0 {
+		resp.MinMargin = displayBasisPoints(minMargin)
+		resp.BelowMinimum = make([]BelowMarginResponse, 0)
+		for _, s := range stock {
+			if !calc.BelowMinimumMargin(s.Book.Price(), s.UnitCost, minMargin) {
+				continue
+			}
+			resp.BelowMinimum = append(resp.BelowMinimum, BelowMarginResponse{
+				ISBN:     s.Book.ISBN().String(),
+				Title:    s.Book.Title(),
+				Price:    s.Book.Price().Display(),
+				UnitCost: s.UnitCost.Display(),
+				Margin:   displayBasisPoints(calc.GrossMargin(s.Book.Price(), s.UnitCost)),
+			})
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,284 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+// defaultReservationTTL is how long stock is held when a request names no TTL.
+const defaultReservationTTL = 15 * time.Minute
+
+type CreateReservationsRequest struct {
+	Holder     string             `json:"holder"`
+	TTLSeconds int                `json:"ttl_seconds"`
+	Items      []StockItemRequest `json:"items"`
+}
+
+type StockItemRequest struct {
+	ISBN      string `json:"isbn"`
+	Location  string `json:"location"`
+	Condition string `json:"condition"` // defaults to new
+	Quantity  int    `json:"quantity"`
+}
+
+type HoldStockRequest struct {
+	Holder     string `json:"holder"`
+	Location   string `json:"location"`
+	Condition  string `json:"condition"` // defaults to new
+	Quantity   int    `json:"quantity"`
+	TTLSeconds int    `json:"ttl_seconds"`
+}
+
+type ExtendReservationRequest struct {
+	TTLSeconds int `json:"ttl_seconds"`
+}
+
+type ReservationResponse struct {
+	ID        string    `json:"id"`
+	ISBN      string    `json:"isbn"`
+	Location  string    `json:"location"`
+	Condition string    `json:"condition"`
+	Serial    string    `json:"serial,omitempty"` // set when one serialized copy is held
+	Quantity  int       `json:"quantity"`
+	Holder    string    `json:"holder"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+}
+
+type ReservationListResponse struct {
+	Reservations []ReservationResponse `json:"reservations"`
+	Count        int                   `json:"count"`
+}
+
+// CreateReservations holds several books for one holder. Either every item
+// is reserved or, on any shortage, none is.
+func (h *Handler) CreateReservations(w http.ResponseWriter, r *http.Request) {
+	var req CreateReservationsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if len(req.Items) == 0 {
+		writeError(w, http.StatusBadRequest, "items must not be empty")
+		return
+	}
+	reqs := make([]domain.StockRequest, 0, len(req.Items))
+	for _, item := range req.Items {
+		isbn, err := domain.NewISBN(item.ISBN)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if item.Quantity <= 0 {
+			writeError(w, http.StatusBadRequest, "quantity must be positive")
+			return
+		}
+		location, ok := h.resolveLocation(w, item.Location)
+		if !ok {
+			return
+		}
+		condition, ok := parseCondition(w, item.Condition)
+		if !ok {
+			return
+		}
+		reqs = append(reqs, domain.StockRequest{ISBN: isbn, Location: location, Condition: condition, Quantity: item.Quantity})
+	}
+	ttl, err := reservationTTL(req.TTLSeconds)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Holder == "" {
+		writeError(w, http.StatusBadRequest, "holder must not be empty")
+		return
+	}
+
+	held, err := h.inventory.HoldMany(reqs, req.Holder, h.clock.Now(), ttl)
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, toReservationListResponse(held))
+}
+
+// HoldStock reserves copies of the book in the path, in the requested
+// condition, for a holder.
+func (h *Handler) HoldStock(w http.ResponseWriter, r *http.Request) {
+	var req HoldStockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	condition, ok := parseCondition(w, req.Condition)
+	if !ok {
+		return
+	}
+	entry, ok := h.findVariant(w, r, condition)
+	if !ok {
+		return
+	}
+	if req.Quantity <= 0 {
+		writeError(w, http.StatusBadRequest, "quantity must be positive")
+		return
+	}
+	if req.Holder == "" {
+		writeError(w, http.StatusBadRequest, "holder must not be empty")
+		return
+	}
+	ttl, err := reservationTTL(req.TTLSeconds)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	location, ok := h.resolveLocation(w, req.Location)
+	if !ok {
+		return
+	}
+	item := domain.StockRequest{ISBN: entry.Book().ISBN(), Location: location, Condition: condition, Quantity: req.Quantity}
+	held, err := h.inventory.HoldMany([]domain.StockRequest{item}, req.Holder, h.clock.Now(), ttl)
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, toReservationResponse(held[0]))
+}
+
+// ListReservations lists outstanding reservations, optionally narrowed to
+// ?holder=..., ?isbn=... and ?location=....
+func (h *Handler) ListReservations(w http.ResponseWriter, r *http.Request) {
+	holder := r.URL.Query().Get("holder")
+	isbn := r.URL.Query().Get("isbn")
+	location := r.URL.Query().Get("location")
+	var matched []domain.Reservation
+	for _, res := range h.inventory.Reservations() {
+		if holder != "" && res.Holder() != holder {
+			continue
+		}
+		if isbn != "" && res.ISBN().String() != isbn {
+			continue
+		}
+		if location != "" && res.Location() != location {
+			continue
+		}
+		matched = append(matched, res)
+	}
+	writeJSON(w, http.StatusOK, toReservationListResponse(matched))
+}
+
+func (h *Handler) GetReservation(w http.ResponseWriter, r *http.Request) {
+	res, err := h.inventory.FindReservation(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "reservation not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, toReservationResponse(res))
+}
+
+// ExtendReservation keeps an active reservation for another ttl_seconds
+// (default 15 minutes) from now.
+func (h *Handler) ExtendReservation(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, err := h.inventory.FindReservation(id); err != nil {
+		writeError(w, http.StatusNotFound, "reservation not found")
+		return
+	}
+	var req ExtendReservationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	ttl, err := reservationTTL(req.TTLSeconds)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	res, err := h.inventory.ExtendReservation(id, h.clock.Now(), ttl)
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, toReservationResponse(res))
+}
+
+// ConfirmReservation commits an active reservation so it no longer expires.
+func (h *Handler) ConfirmReservation(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, err := h.inventory.FindReservation(id); err != nil {
+		writeError(w, http.StatusNotFound, "reservation not found")
+		return
+	}
+	res, err := h.inventory.ConfirmReservation(id, h.clock.Now())
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, toReservationResponse(res))
+}
+
+// FulfilReservation ships a reservation's copies, removing them from stock.
+func (h *Handler) FulfilReservation(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, err := h.inventory.FindReservation(id); err != nil {
+		writeError(w, http.StatusNotFound, "reservation not found")
+		return
+	}
+	res, err := h.inventory.FulfilReservation(id, movementInfo(r, "", ""))
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, toReservationResponse(res))
+}
+
+// ReleaseReservation cancels a reservation and returns its copies to stock.
+func (h *Handler) ReleaseReservation(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.inventory.ReleaseReservation(r.PathValue("id"), movementInfo(r, "", "")); err != nil {
+		writeError(w, http.StatusNotFound, "reservation not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func reservationTTL(seconds int) (time.Duration, error) {
+	switch {
+	case seconds < 0:
+		return 0, errors.New("ttl_seconds must not be negative")
+	case seconds == 0:
+		return defaultReservationTTL, nil
+	default:
+		return time.Duration(seconds) * time.Second, nil
+	}
+}
+
+func toReservationResponse(res domain.Reservation) ReservationResponse {
+	resp := ReservationResponse{
+		ID:        res.ID(),
+		ISBN:      res.ISBN().String(),
+		Location:  res.Location(),
+		Condition: string(res.Condition()),
+		Serial:    res.Serial(),
+		Quantity:  res.Quantity(),
+		Holder:    res.Holder(),
+		Status:    string(res.Status()),
+		CreatedAt: res.CreatedAt(),
+	}
+	if res.Status() == domain.ReservationActive {
+		resp.ExpiresAt = res.ExpiresAt()
+	}
+	return resp
+}
+
+func toReservationListResponse(list []domain.Reservation) ReservationListResponse {
+	resp := ReservationListResponse{Reservations: make([]ReservationResponse, 0, len(list)), Count: len(list)}
+	for _, res := range list {
+		resp.Reservations = append(resp.Reservations, toReservationResponse(res))
+	}
+	return resp
+}
@@ -10,12 +10,35 @@ type ErrorResponse struct {
 }
 
 type BookResponse struct {
-	ISBN      string `json:"isbn"`
-	Title     string `json:"title"`
-	Author    string `json:"author"`
-	Price     string `json:"price"`
-	Genre     string `json:"genre"`
-	IsClassic bool   `json:"is_classic"`
+	ISBN        string `json:"isbn"`
+	Title       string `json:"title"`
+	Author      string `json:"author"`
+	Price       string `json:"price"`
+	Genre       string `json:"genre"`
+	Format      string `json:"format"`
+	Publisher   string `json:"publisher,omitempty"`
+	PublishedAt string `json:"published_at"`
+	IsClassic   bool   `json:"is_classic"`
+	IsRecent    bool   `json:"is_recent"`
+	// Variants lists the book's stock per condition when used copies are held.
+	Variants []VariantResponse `json:"variants,omitempty"`
+}
+
+type PriceResponse struct {
+	ISBN        string              `json:"isbn"`
+	Condition   string              `json:"condition"`
+	Serial      string              `json:"serial,omitempty"`
+	Quantity    int                 `json:"quantity"`
+	UnitPrice   string              `json:"unit_price"`
+	Total       string              `json:"total"`
+	Explanation []PriceStepResponse `json:"explanation,omitempty"`
+}
+
+type PriceStepResponse struct {
+	Rule   string `json:"rule"`
+	Input  string `json:"input"`
+	Delta  string `json:"delta"`
+	Result string `json:"result"`
 }
 
 type ListResponse struct {
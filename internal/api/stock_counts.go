@@ -0,0 +1,309 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+type CreateStockCountRequest struct {
+	Kind     string `json:"kind"` // full or cycle
+	Location string `json:"location"`
+	Genre    string `json:"genre"` // cycle counts only
+}
+
+type RecordCountRequest struct {
+	Condition string `json:"condition"` // defaults to new
+	Counted   int    `json:"counted"`
+}
+
+type ApproveStockCountRequest struct {
+	Reasons map[string]string `json:"reasons"` // reason code per ISBN
+}
+
+type StockCountResponse struct {
+	ID         string                   `json:"id"`
+	Kind       string                   `json:"kind"`
+	Location   string                   `json:"location"`
+	Genre      string                   `json:"genre,omitempty"`
+	Status     string                   `json:"status"`
+	Blind      bool                     `json:"blind"`
+	Lines      []StockCountLineResponse `json:"lines"`
+	Uncounted  int                      `json:"uncounted"`
+	ApprovedBy string                   `json:"approved_by,omitempty"`
+	CreatedAt  time.Time                `json:"created_at"`
+	UpdatedAt  time.Time                `json:"updated_at"`
+}
+
+// StockCountLineResponse leaves out the expected quantity and variance while
+// the count is blind.
+type StockCountLineResponse struct {
+	ISBN      string `json:"isbn"`
+	Condition string `json:"condition"`
+	Counted   *int   `json:"counted"`
+	Expected  *int   `json:"expected,omitempty"`
+	Variance  *int   `json:"variance,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+type StockCountListResponse struct {
+	Counts []StockCountResponse `json:"counts"`
+	Count  int                  `json:"count"`
+}
+
+type VarianceReportResponse struct {
+	ID                 string             `json:"id"`
+	Location           string             `json:"location"`
+	Status             string             `json:"status"`
+	Titles             int                `json:"titles"`
+	TitlesWithVariance int                `json:"titles_with_variance"`
+	Accuracy           string             `json:"accuracy"` // share of titles counted without variance
+	CopiesExpected     int                `json:"copies_expected"`
+	CopiesCounted      int                `json:"copies_counted"`
+	NetVariance        int                `json:"net_variance"`
+	Variances          []VarianceResponse `json:"variances"`
+}
+
+type VarianceResponse struct {
+	ISBN          string `json:"isbn"`
+	Title         string `json:"title"`
+	Condition     string `json:"condition"`
+	Expected      int    `json:"expected"`
+	Counted       int    `json:"counted"`
+	Variance      int    `json:"variance"`
+	VarianceValue string `json:"variance_value,omitempty"` // at average unit cost, when known
+	Reason        string `json:"reason,omitempty"`
+}
+
+// CreateStockCount starts a full count of a location or a cycle count of
+// one genre there, recording the system quantities to count against.
+func (h *Handler) CreateStockCount(w http.ResponseWriter, r *http.Request) {
+	var req CreateStockCountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	kind := domain.CountKind(req.Kind)
+	if kind != domain.CountFull && kind != domain.CountCycle {
+		writeError(w, http.StatusBadRequest, "kind must be full or cycle")
+		return
+	}
+	location, ok := h.resolveLocation(w, req.Location)
+	if !ok {
+		return
+	}
+	c, err := h.inventory.StartCount(kind, location, domain.Genre(req.Genre))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, toStockCountResponse(c))
+}
+
+// ListStockCounts lists stock counts, optionally narrowed to ?status=... and
+// ?location=....
+func (h *Handler) ListStockCounts(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	location := r.URL.Query().Get("location")
+	resp := StockCountListResponse{Counts: []StockCountResponse{}}
+	for _, c := range h.inventory.Counts() {
+		if status != "" && string(c.Status()) != status {
+			continue
+		}
+		if location != "" && c.Location() != location {
+			continue
+		}
+		resp.Counts = append(resp.Counts, toStockCountResponse(c))
+	}
+	resp.Count = len(resp.Counts)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) GetStockCount(w http.ResponseWriter, r *http.Request) {
+	c, err := h.inventory.FindCount(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "stock count not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, toStockCountResponse(c))
+}
+
+// RecordCount enters the copies of a title in one condition found on the
+// shelf. Entering a title again replaces the earlier figure.
+func (h *Handler) RecordCount(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, err := h.inventory.FindCount(id); err != nil {
+		writeError(w, http.StatusNotFound, "stock count not found")
+		return
+	}
+	isbn, err := domain.NewISBN(r.PathValue("isbn"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var req RecordCountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Counted < 0 {
+		writeError(w, http.StatusBadRequest, "counted must not be negative")
+		return
+	}
+	condition, ok := parseCondition(w, req.Condition)
+	if !ok {
+		return
+	}
+	c, err := h.inventory.RecordCount(id, isbn, condition, req.Counted)
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, toStockCountResponse(c))
+}
+
+// SubmitStockCount ends counting once every title is counted and reveals
+// the variances for review.
+func (h *Handler) SubmitStockCount(w http.ResponseWriter, r *http.Request) {
+	h.finishStockCount(w, r, h.inventory.SubmitCount)
+}
+
+// CancelStockCount abandons a count without adjusting any stock.
+func (h *Handler) CancelStockCount(w http.ResponseWriter, r *http.Request) {
+	h.finishStockCount(w, r, h.inventory.CancelCount)
+}
+
+func (h *Handler) finishStockCount(w http.ResponseWriter, r *http.Request, finish func(string) (domain.StockCount, error)) {
+	id := r.PathValue("id")
+	if _, err := h.inventory.FindCount(id); err != nil {
+		writeError(w, http.StatusNotFound, "stock count not found")
+		return
+	}
+	c, err := finish(id)
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, toStockCountResponse(c))
+}
+
+// ApproveStockCount posts a submitted count's variances to the ledger,
+// recording the approver from the X-Actor header. The optional body gives a
+// reason code per ISBN: stock_count (the default), shrinkage, theft,
+// miscount or found.
+func (h *Handler) ApproveStockCount(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, err := h.inventory.FindCount(id); err != nil {
+		writeError(w, http.StatusNotFound, "stock count not found")
+		return
+	}
+	var req ApproveStockCountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	reasons := make(map[domain.ISBN]domain.ReasonCode, len(req.Reasons))
+	for s, reason := range req.Reasons {
+		isbn, err := domain.NewISBN(s)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if !domain.ReasonCode(reason).IsCountReason() {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown count reason %q", reason))
+			return
+		}
+		reasons[isbn] = domain.ReasonCode(reason)
+	}
+	c, err := h.inventory.ApproveCount(id, reasons, movementInfo(r, "", ""))
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, toStockCountResponse(c))
+}
+
+// StockCountVariances reports where a submitted or approved count differs
+// from the system quantities. Variances stay hidden while counting.
+func (h *Handler) StockCountVariances(w http.ResponseWriter, r *http.Request) {
+	c, err := h.inventory.FindCount(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "stock count not found")
+		return
+	}
+	if c.Status() == domain.CountOpen {
+		writeError(w, http.StatusConflict, "variances are hidden until the count is submitted")
+		return
+	}
+	lines := c.Lines()
+	resp := VarianceReportResponse{
+		ID:        c.ID(),
+		Location:  c.Location(),
+		Status:    string(c.Status()),
+		Titles:    len(lines),
+		Variances: []VarianceResponse{},
+	}
+	for _, l := range lines {
+		resp.CopiesExpected += l.Expected()
+		resp.CopiesCounted += l.Counted()
+	}
+	resp.NetVariance = resp.CopiesCounted - resp.CopiesExpected
+	for _, l := range c.Variances() {
+		v := VarianceResponse{
+			ISBN:      l.ISBN().String(),
+			Condition: string(l.Condition()),
+			Expected:  l.Expected(),
+			Counted:   l.Counted(),
+			Variance:  l.Variance(),
+			Reason:    string(l.Reason()),
+		}
+		if e, err := h.inventory.FindVariant(l.ISBN(), c.Location(), l.Condition()); err == nil {
+			v.Title = e.Book().Title()
+			if cost, ok := e.AverageUnitCost(); ok {
+				v.VarianceValue = cost.MultiplyPercent(l.Variance() * 100).Display()
+			}
+		}
+		resp.Variances = append(resp.Variances, v)
+	}
+	resp.TitlesWithVariance = len(resp.Variances)
+	if resp.Titles > 0 {
+		accurate := resp.Titles - resp.TitlesWithVariance
+		resp.Accuracy = fmt.Sprintf("%.2f%%", float64(accurate)*100/float64(resp.Titles))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func toStockCountResponse(c domain.StockCount) StockCountResponse {
+	blind := c.Status() == domain.CountOpen
+	resp := StockCountResponse{
+		ID:         c.ID(),
+		Kind:       string(c.Kind()),
+		Location:   c.Location(),
+		Genre:      string(c.Genre()),
+		Status:     string(c.Status()),
+		Blind:      blind,
+		Lines:      []StockCountLineResponse{},
+		Uncounted:  c.Uncounted(),
+		ApprovedBy: c.ApprovedBy(),
+		CreatedAt:  c.CreatedAt(),
+		UpdatedAt:  c.UpdatedAt(),
+	}
+	for _, l := range c.Lines() {
+		line := StockCountLineResponse{ISBN: l.ISBN().String(), Condition: string(l.Condition()), Reason: string(l.Reason())}
+		if l.IsCounted() {
+			counted := l.Counted()
+			line.Counted = &counted
+		}
+		if !blind {
+			expected, variance := l.Expected(), l.Variance()
+			line.Expected, line.Variance = &expected, &variance
+		}
+		resp.Lines = append(resp.Lines, line)
+	}
+	return resp
+}
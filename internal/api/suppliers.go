@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+type SupplierRequest struct {
+	Code              string `json:"code"`
+	Name              string `json:"name"`
+	Currency          string `json:"currency"`
+	PaymentTermsDays  int    `json:"payment_terms_days"`
+	LeadTimeDays      int    `json:"lead_time_days"`
+	MinimumOrderCents int    `json:"minimum_order_cents"`
+}
+
+type SupplierResponse struct {
+	Code             string `json:"code"`
+	Name             string `json:"name"`
+	Currency         string `json:"currency"`
+	PaymentTermsDays int    `json:"payment_terms_days"`
+	LeadTimeDays     int    `json:"lead_time_days"`
+	MinimumOrder     string `json:"minimum_order"`
+}
+
+func (h *Handler) ListSuppliers(w http.ResponseWriter, r *http.Request) {
+	suppliers := h.suppliers.FindAll()
+	resp := make([]SupplierResponse, 0, len(suppliers))
+	for _, s := range suppliers {
+		resp = append(resp, toSupplierResponse(s))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) GetSupplier(w http.ResponseWriter, r *http.Request) {
+	s, err := h.suppliers.FindByCode(r.PathValue("code"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "supplier not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, toSupplierResponse(s))
+}
+
+func (h *Handler) CreateSupplier(w http.ResponseWriter, r *http.Request) {
+	var req SupplierRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	s, ok := parseSupplier(w, req)
+	if !ok {
+		return
+	}
+	if err := h.suppliers.Create(s); err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, toSupplierResponse(s))
+}
+
+// UpdateSupplier replaces a supplier's terms. Orders already sent keep the
+// terms they were placed under.
+func (h *Handler) UpdateSupplier(w http.ResponseWriter, r *http.Request) {
+	code := r.PathValue("code")
+	if _, err := h.suppliers.FindByCode(code); err != nil {
+		writeError(w, http.StatusNotFound, "supplier not found")
+		return
+	}
+	var req SupplierRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	req.Code = code
+	s, ok := parseSupplier(w, req)
+	if !ok {
+		return
+	}
+	h.suppliers.Save(s)
+	writeJSON(w, http.StatusOK, toSupplierResponse(s))
+}
+
+func parseSupplier(w http.ResponseWriter, req SupplierRequest) (domain.Supplier, bool) {
+	minimum, err := domain.NewMoney(req.MinimumOrderCents, req.Currency)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return domain.Supplier{}, false
+	}
+	leadTime := time.Duration(req.LeadTimeDays) * 24 * time.Hour
+	s, err := domain.NewSupplier(req.Code, req.Name, req.PaymentTermsDays, leadTime, minimum)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return domain.Supplier{}, false
+	}
+	return s, true
+}
+
+func toSupplierResponse(s domain.Supplier) SupplierResponse {
+	return SupplierResponse{
+		Code:             s.Code(),
+		Name:             s.Name(),
+		Currency:         s.Currency(),
+		PaymentTermsDays: s.PaymentTermsDays(),
+		LeadTimeDays:     int(s.LeadTime() / (24 * time.Hour)),
+		MinimumOrder:     s.MinimumOrder().Display(),
+	}
+}
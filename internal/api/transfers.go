@@ -0,0 +1,207 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+type CreateTransferRequest struct {
+	From  string             `json:"from"`
+	To    string             `json:"to"`
+	Items []StockItemRequest `json:"items"`
+}
+
+type ReceiveTransferRequest struct {
+	Items []StockItemRequest `json:"items"`
+}
+
+type TransferResponse struct {
+	ID            string                 `json:"id"`
+	From          string                 `json:"from"`
+	To            string                 `json:"to"`
+	Status        string                 `json:"status"`
+	Lines         []TransferLineResponse `json:"lines"`
+	Discrepancies []TransferLineResponse `json:"discrepancies"`
+	CreatedAt     time.Time              `json:"created_at"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+}
+
+type TransferLineResponse struct {
+	ISBN        string `json:"isbn"`
+	Shipped     int    `json:"shipped"`
+	Received    int    `json:"received"`
+	Lost        int    `json:"lost"`
+	Returned    int    `json:"returned"`
+	Outstanding int    `json:"outstanding"`
+}
+
+type TransferListResponse struct {
+	Transfers []TransferResponse `json:"transfers"`
+	Count     int                `json:"count"`
+}
+
+// CreateTransfer ships copies from one location to another. The source is
+// debited at once and the copies show as in transit at the destination.
+func (h *Handler) CreateTransfer(w http.ResponseWriter, r *http.Request) {
+	var req CreateTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	from, ok := h.resolveLocation(w, req.From)
+	if !ok {
+		return
+	}
+	to, ok := h.resolveLocation(w, req.To)
+	if !ok {
+		return
+	}
+	if from == to {
+		writeError(w, http.StatusBadRequest, "from and to must differ")
+		return
+	}
+	reqs, ok := stockItems(w, req.Items)
+	if !ok {
+		return
+	}
+	tr, err := h.inventory.Transfer(from, to, reqs, movementInfo(r, "", ""))
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, toTransferResponse(tr))
+}
+
+// ListTransfers lists transfers, optionally narrowed to ?status=... and to
+// ?location=..., matching either end.
+func (h *Handler) ListTransfers(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	location := r.URL.Query().Get("location")
+	resp := TransferListResponse{Transfers: []TransferResponse{}}
+	for _, tr := range h.inventory.Transfers() {
+		if status != "" && string(tr.Status()) != status {
+			continue
+		}
+		if location != "" && tr.From() != location && tr.To() != location {
+			continue
+		}
+		resp.Transfers = append(resp.Transfers, toTransferResponse(tr))
+	}
+	resp.Count = len(resp.Transfers)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) GetTransfer(w http.ResponseWriter, r *http.Request) {
+	tr, err := h.inventory.FindTransfer(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "transfer not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, toTransferResponse(tr))
+}
+
+// ReceiveTransfer books copies that arrived at the destination. It may be
+// called several times for partial deliveries.
+func (h *Handler) ReceiveTransfer(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, err := h.inventory.FindTransfer(id); err != nil {
+		writeError(w, http.StatusNotFound, "transfer not found")
+		return
+	}
+	var req ReceiveTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	reqs, ok := stockItems(w, req.Items)
+	if !ok {
+		return
+	}
+	tr, err := h.inventory.ReceiveTransfer(id, reqs, movementInfo(r, "", ""))
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, toTransferResponse(tr))
+}
+
+// CloseTransfer ends a transfer, writing off copies that never arrived.
+func (h *Handler) CloseTransfer(w http.ResponseWriter, r *http.Request) {
+	h.finishTransfer(w, r, h.inventory.CloseTransfer)
+}
+
+// CancelTransfer recalls a transfer, returning copies still in transit to
+// the source.
+func (h *Handler) CancelTransfer(w http.ResponseWriter, r *http.Request) {
+	h.finishTransfer(w, r, h.inventory.CancelTransfer)
+}
+
+func (h *Handler) finishTransfer(w http.ResponseWriter, r *http.Request, finish func(string, domain.MovementInfo) (domain.Transfer, error)) {
+	id := r.PathValue("id")
+	if _, err := h.inventory.FindTransfer(id); err != nil {
+		writeError(w, http.StatusNotFound, "transfer not found")
+		return
+	}
+	tr, err := finish(id, movementInfo(r, "", ""))
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, toTransferResponse(tr))
+}
+
+// stockItems validates the books and quantities on a transfer or delivery.
+func stockItems(w http.ResponseWriter, items []StockItemRequest) ([]domain.StockRequest, bool) {
+	if len(items) == 0 {
+		writeError(w, http.StatusBadRequest, "items must not be empty")
+		return nil, false
+	}
+	reqs := make([]domain.StockRequest, 0, len(items))
+	for _, item := range items {
+		isbn, err := domain.NewISBN(item.ISBN)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return nil, false
+		}
+		if item.Quantity <= 0 {
+			writeError(w, http.StatusBadRequest, "quantity must be positive")
+			return nil, false
+		}
+		reqs = append(reqs, domain.StockRequest{ISBN: isbn, Quantity: item.Quantity})
+	}
+	return reqs, true
+}
+
+func toTransferResponse(tr domain.Transfer) TransferResponse {
+	resp := TransferResponse{
+		ID:            tr.ID(),
+		From:          tr.From(),
+		To:            tr.To(),
+		Status:        string(tr.Status()),
+		Lines:         []TransferLineResponse{},
+		Discrepancies: []TransferLineResponse{},
+		CreatedAt:     tr.CreatedAt(),
+		UpdatedAt:     tr.UpdatedAt(),
+	}
+	for _, l := range tr.Lines() {
+		resp.Lines = append(resp.Lines, toTransferLineResponse(l))
+	}
+	for _, l := range tr.Discrepancies() {
+		resp.Discrepancies = append(resp.Discrepancies, toTransferLineResponse(l))
+	}
+	return resp
+}
+
+func toTransferLineResponse(l domain.TransferLine) TransferLineResponse {
+	return TransferLineResponse{
+		ISBN:        l.ISBN().String(),
+		Shipped:     l.Shipped(),
+		Received:    l.Received(),
+		Lost:        l.Lost(),
+		Returned:    l.Returned(),
+		Outstanding: l.Outstanding(),
+	}
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+type ValuationResponse struct {
+	Method       string                   `json:"method"`
+	AsOf         time.Time                `json:"as_of"`
+	Lines        []ValuationLineResponse  `json:"lines"`
+	Totals       []ValuationTotalResponse `json:"totals"`
+	CostToRetail map[string]int           `json:"cost_to_retail,omitempty"` // basis points per currency
+}
+
+type ValuationLineResponse struct {
+	ISBN       string `json:"isbn"`
+	Title      string `json:"title"`
+	Condition  string `json:"condition"`
+	Copies     int    `json:"copies"`
+	Value      string `json:"value"`
+	ValueCents int    `json:"value_cents"`
+	Sold       int    `json:"sold"`
+	COGS       string `json:"cogs"`
+	COGSCents  int    `json:"cogs_cents"`
+	Lost       int    `json:"lost,omitempty"`
+	WrittenOff string `json:"written_off,omitempty"`
+}
+
+type ValuationTotalResponse struct {
+	Currency        string `json:"currency"`
+	Titles          int    `json:"titles"`
+	Copies          int    `json:"copies"`
+	ValueCents      int    `json:"value_cents"`
+	Sold            int    `json:"sold"`
+	COGSCents       int    `json:"cogs_cents"`
+	Lost            int    `json:"lost"`
+	WrittenOffCents int    `json:"written_off_cents"`
+}
+
+// ValuationReport values stock at cost with ?method=fifo|weighted_average|retail
+// (default: the method sales are costed with), as of ?as_of=<date or
+// RFC 3339 time> (default now). Alongside the value of the copies held it
+// reports the cost of the copies sold and lost in transit up to then. The
+// retail method reads retail prices from the price history.
+func (h *Handler) ValuationReport(w http.ResponseWriter, r *http.Request) {
+	method := h.inventory.CostMethod()
+	if m := r.URL.Query().Get("method"); m != "" {
+		var err error
+		if method, err = domain.ParseCostMethod(m); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	asOf := h.clock.Now()
+	if s := r.URL.Query().Get("as_of"); s != "" {
+		var err error
+		if asOf, err = parseAsOf(s); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	prices := func(isbn domain.ISBN, at time.Time) (domain.Money, bool) {
+		price, err := h.prices.PriceAt(isbn.String(), at)
+		return price, err == nil
+	}
+	v, err := h.inventory.Valuation(method, asOf, prices)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	resp := ValuationResponse{
+		Method:       string(v.Method),
+		AsOf:         v.AsOf,
+		Lines:        make([]ValuationLineResponse, 0, len(v.Lines)),
+		Totals:       make([]ValuationTotalResponse, 0, len(v.Totals)),
+		CostToRetail: v.CostToRetail,
+	}
+	for _, l := range v.Lines {
+		line := ValuationLineResponse{
+			ISBN:       l.Book.ISBN().String(),
+			Title:      l.Book.Title(),
+			Condition:  string(l.Condition),
+			Copies:     l.Copies,
+			Value:      l.Value.Display(),
+			ValueCents: l.Value.Amount(),
+			Sold:       l.Sold,
+			COGS:       l.COGS.Display(),
+			COGSCents:  l.COGS.Amount(),
+			Lost:       l.Lost,
+		}
+		if l.Lost > 0 {
+			line.WrittenOff = l.WrittenOff.Display()
+		}
+		resp.Lines = append(resp.Lines, line)
+	}
+	for _, t := range v.Totals {
+		resp.Totals = append(resp.Totals, ValuationTotalResponse{
+			Currency:        t.Currency,
+			Titles:          t.Titles,
+			Copies:          t.Copies,
+			ValueCents:      t.Value.Amount(),
+			Sold:            t.Sold,
+			COGSCents:       t.COGS.Amount(),
+			Lost:            t.Lost,
+			WrittenOffCents: t.WrittenOff.Amount(),
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+type VariantPriceRequest struct {
+	PriceCents int    `json:"price_cents"`
+	Currency   string `json:"currency"` // defaults to the book's currency
+}
+
+// VariantResponse is a book's stock in one condition, summed across
+// locations.
+type VariantResponse struct {
+	Condition string `json:"condition"`
+	Price     string `json:"price"`
+	Total     int    `json:"total"`
+	Available int    `json:"available"`
+}
+
+type VariantListResponse struct {
+	ISBN     string            `json:"isbn"`
+	Title    string            `json:"title"`
+	Variants []VariantResponse `json:"variants"`
+}
+
+// ListVariants returns the book's stock in each condition held, from the
+// best condition to the worst.
+func (h *Handler) ListVariants(w http.ResponseWriter, r *http.Request) {
+	isbn, err := domain.NewISBN(r.PathValue("isbn"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	variants := h.inventory.Variants(isbn)
+	if len(variants) == 0 {
+		writeError(w, http.StatusNotFound, "stock entry not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, VariantListResponse{
+		ISBN:     isbn.String(),
+		Title:    variants[0].Book().Title(),
+		Variants: toVariantResponses(variants),
+	})
+}
+
+// SetVariantPrice reprices the book's used copies in the condition in the
+// path at every location.
+func (h *Handler) SetVariantPrice(w http.ResponseWriter, r *http.Request) {
+	condition, ok := parseCondition(w, r.PathValue("condition"))
+	if !ok {
+		return
+	}
+	variant, ok := h.findVariant(w, r, condition)
+	if !ok {
+		return
+	}
+	var req VariantPriceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Currency == "" {
+		req.Currency = variant.Book().Price().Currency()
+	}
+	price, err := domain.NewMoney(req.PriceCents, req.Currency)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, err := h.inventory.SetVariantPrice(variant.Book().ISBN(), condition, price); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	variant, _ = h.findVariant(w, r, condition)
+	writeJSON(w, http.StatusOK, toVariantResponse(variant))
+}
+
+// parseCondition reads a stock condition, new if none is given.
+func parseCondition(w http.ResponseWriter, s string) (domain.Condition, bool) {
+	condition, err := domain.ParseCondition(s)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return "", false
+	}
+	return condition, true
+}
+
+// findVariant returns the stock of the book in the path in one condition,
+// summed across locations.
+func (h *Handler) findVariant(w http.ResponseWriter, r *http.Request, condition domain.Condition) (domain.StockEntry, bool) {
+	isbn, err := domain.NewISBN(r.PathValue("isbn"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return domain.StockEntry{}, false
+	}
+	for _, v := range h.inventory.Variants(isbn) {
+		if v.Condition() == condition {
+			return v, true
+		}
+	}
+	writeError(w, http.StatusNotFound, "stock entry not found in that condition")
+	return domain.StockEntry{}, false
+}
+
+// bookVariants lists the conditions a book is stocked in, or nothing if it
+// is only stocked new.
+func (h *Handler) bookVariants(isbn domain.ISBN) []VariantResponse {
+	variants := h.inventory.Variants(isbn)
+	for _, v := range variants {
+		if v.Condition().IsUsed() {
+			return toVariantResponses(variants)
+		}
+	}
+	return nil
+}
+
+func toVariantResponse(v domain.StockEntry) VariantResponse {
+	return VariantResponse{
+		Condition: string(v.Condition()),
+		Price:     v.Price().Display(),
+		Total:     v.Total(),
+		Available: v.Available(),
+	}
+}
+
+func toVariantResponses(variants []domain.StockEntry) []VariantResponse {
+	resp := make([]VariantResponse, 0, len(variants))
+	for _, v := range variants {
+		resp = append(resp, toVariantResponse(v))
+	}
+	return resp
+}
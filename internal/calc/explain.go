@@ -0,0 +1,64 @@
+package calc
+
+import (
+	"fmt"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+// PriceStep records a single adjustment made while computing a price.
+type PriceStep struct {
+	Name   string
+	Input  domain.Money
+	Delta  domain.Money
+	Result domain.Money
+}
+
+// PriceBreakdown is the audit trail of a computed price: the base price,
+// each adjustment in the order it was applied, and the final price.
+type PriceBreakdown struct {
+	Base  domain.Money
+	Steps []PriceStep
+	Final domain.Money
+
+	// Floor is the lowest unit price the FloorRule's minimum margin allows.
+	// FloorRule is empty when no minimum margin applies.
+	Floor     domain.Money
+	FloorRule string
+}
+
+func (b *PriceBreakdown) record(name string, result domain.Money) {
+	b.Steps = append(b.Steps, PriceStep{
+		Name:   name,
+		Input:  b.Final,
+		Delta:  result.Subtract(b.Final),
+		Result: result,
+	})
+	b.Final = result
+}
+
+// ExplainLineTotal extends a unit price breakdown to quantity units, recording
+// the quantity multiplication and any bulk discount. The final price matches
+// LineTotal for the same inputs unless the bulk discount would take the line
+// below the unit's margin floor, in which case the floor rule raises it back.
+func ExplainLineTotal(unit PriceBreakdown, quantity int, tiers []DiscountTier) PriceBreakdown {
+	line := PriceBreakdown{
+		Base:      unit.Base,
+		Steps:     append([]PriceStep(nil), unit.Steps...),
+		Final:     unit.Final,
+		Floor:     unit.Floor,
+		FloorRule: unit.FloorRule,
+	}
+
+	subtotal, _ := domain.NewMoney(unit.Final.Amount()*quantity, unit.Final.Currency())
+	line.record(fmt.Sprintf("Quantity x%d", quantity), subtotal)
+
+	if discount := BulkDiscount(quantity, tiers); discount > 0 {
+		line.record("BulkDiscount", subtotal.MultiplyPercent(100-discount))
+	}
+	if floor := unit.Floor.Amount() * quantity; unit.FloorRule != "" && line.Final.Amount() < floor {
+		total, _ := domain.NewMoney(floor, unit.Final.Currency())
+		line.record(unit.FloorRule, total)
+	}
+	return line
+}
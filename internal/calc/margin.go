@@ -0,0 +1,106 @@
+package calc
+
+import (
+	"sort"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+// GrossMargin returns (price - cost) / price in basis points, e.g. 3500 = 35%.
+// Returns 0 if the price is zero.
+func GrossMargin(price, cost domain.Money) int {
+	if price.Amount() == 0 {
+		return 0
+	}
+	return (price.Amount() - cost.Amount()) * 10000 / price.Amount()
+}
+
+// Markup returns (price - cost) / cost in basis points, e.g. 5000 = 50%.
+// Returns 0 if the cost is zero.
+func Markup(price, cost domain.Money) int {
+	if cost.Amount() == 0 {
+		return 0
+	}
+	return (price.Amount() - cost.Amount()) * 10000 / cost.Amount()
+}
+
+// BelowMinimumMargin returns true if selling at price earns less than
+// minBasisPoints of gross margin.
+func BelowMinimumMargin(price, cost domain.Money, minBasisPoints int) bool {
+	return GrossMargin(price, cost) < minBasisPoints
+}
+
+// MinimumMarginPrice returns the lowest price that earns at least
+// minBasisPoints of gross margin over cost, rounded up to the next cent.
+func MinimumMarginPrice(cost domain.Money, minBasisPoints int) domain.Money {
+	divisor := 10000 - minBasisPoints
+	amount := (cost.Amount()*10000 + divisor - 1) / divisor
+	m, _ := domain.NewMoney(amount, cost.Currency())
+	return m
+}
+
+// CostedStock is a quantity of a book held at a known unit cost.
+type CostedStock struct {
+	Book     domain.Book
+	UnitCost domain.Money
+	Quantity int
+}
+
+// MarginGroup aggregates retail value and cost for a group of titles.
+type MarginGroup struct {
+	Key         string
+	Titles      int
+	Copies      int
+	RetailValue int // cents, at list price
+	CostValue   int // cents
+	MarginBP    int
+	MarkupBP    int
+}
+
+// GroupKey extracts the grouping key for a margin report.
+type GroupKey func(domain.Book) string
+
+// ByGenreKey groups by genre.
+func ByGenreKey(b domain.Book) string { return string(b.Genre()) }
+
+// ByAuthorKey groups by the author's full name.
+func ByAuthorKey(b domain.Book) string { return b.Author().FullName() }
+
+// ByPublisherKey groups by publisher; books without one are grouped under "unknown".
+func ByPublisherKey(b domain.Book) string {
+	if b.Publisher() == "" {
+		return "unknown"
+	}
+	return b.Publisher()
+}
+
+// MarginBy groups stock by key and computes each group's margin and markup
+// at list price. Groups are sorted by key.
+func MarginBy(stock []CostedStock, key GroupKey) []MarginGroup {
+	groups := make(map[string]*MarginGroup)
+	for _, s := range stock {
+		k := key(s.Book)
+		g, ok := groups[k]
+		if !ok {
+			g = &MarginGroup{Key: k}
+			groups[k] = g
+		}
+		g.Titles++
+		g.Copies += s.Quantity
+		g.RetailValue += s.Book.Price().Amount() * s.Quantity
+		g.CostValue += s.UnitCost.Amount() * s.Quantity
+	}
+
+	result := make([]MarginGroup, 0, len(groups))
+	for _, g := range groups {
+		if g.RetailValue > 0 {
+			g.MarginBP = (g.RetailValue - g.CostValue) * 10000 / g.RetailValue
+		}
+		if g.CostValue > 0 {
+			g.MarkupBP = (g.RetailValue - g.CostValue) * 10000 / g.CostValue
+		}
+		result = append(result, *g)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result
+}
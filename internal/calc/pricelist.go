@@ -0,0 +1,66 @@
+package calc
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+// PriceList is a negotiated set of net prices and bulk tiers, assigned to
+// customers such as schools, libraries and resellers.
+type PriceList struct {
+	Name      string
+	Overrides map[string]domain.Money // net unit prices keyed by ISBN
+	Tiers     []DiscountTier          // nil means StandardTiers
+}
+
+// Validate checks that the list is named and its overrides and tiers are sane.
+func (pl PriceList) Validate() error {
+	if pl.Name == "" {
+		return errors.New("price list name must not be empty")
+	}
+	for isbn, price := range pl.Overrides {
+		if price.Amount() < 0 {
+			return fmt.Errorf("net price for %s must not be negative", isbn)
+		}
+	}
+	for _, t := range pl.Tiers {
+		if t.MinQuantity <= 0 || t.Percent < 0 || t.Percent > 100 {
+			return fmt.Errorf("invalid tier: min quantity %d, percent %d", t.MinQuantity, t.Percent)
+		}
+	}
+	return nil
+}
+
+// Clone returns a deep copy, so stored lists cannot be modified through shared maps or slices.
+func (pl PriceList) Clone() PriceList {
+	return PriceList{
+		Name:      pl.Name,
+		Overrides: maps.Clone(pl.Overrides),
+		Tiers:     slices.Clone(pl.Tiers),
+	}
+}
+
+// NetPrice returns the negotiated unit price for the book, if the list has one
+// in the book's currency. A nil list has no overrides.
+func (pl *PriceList) NetPrice(book domain.Book) (domain.Money, bool) {
+	if pl == nil {
+		return domain.Money{}, false
+	}
+	price, ok := pl.Overrides[book.ISBN().String()]
+	if !ok || price.Currency() != book.Price().Currency() {
+		return domain.Money{}, false
+	}
+	return price, true
+}
+
+// TiersOr returns the list's bulk tiers, or fallback if the list is nil or has none.
+func (pl *PriceList) TiersOr(fallback []DiscountTier) []DiscountTier {
+	if pl == nil || pl.Tiers == nil {
+		return fallback
+	}
+	return pl.Tiers
+}
@@ -1,6 +1,10 @@
 package calc
 
-import "github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+import (
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
 
 // DiscountTier defines a discount based on quantity purchased.
 type DiscountTier struct {
@@ -29,9 +33,27 @@ func BulkDiscount(quantity int, tiers []DiscountTier) int {
 }
 
 // OrderTotal calculates the total price for ordering n copies of a book,
+// applying the best matching bulk discount. A customer's price list, if not
+// nil, takes precedence: its net price for the book replaces the book's
+// price, and its tiers, if it has any, replace tiers.
+func OrderTotal(book domain.Book, quantity int, tiers []DiscountTier, list *PriceList) domain.Money {
+	unit := book.Price()
+	if net, ok := list.NetPrice(book); ok {
+		unit = net
+	}
+	return LineTotal(unit, quantity, list.TiersOr(tiers))
+}
+
+// VariantOrderTotal calculates the total for ordering n copies from one
+// stock variant, such as used copies in good condition, at the variant's
+// own price and applying the best matching bulk discount.
+func VariantOrderTotal(variant domain.StockEntry, quantity int, tiers []DiscountTier) domain.Money {
+	return OrderTotal(variant.Offer(), quantity, tiers, nil)
+}
+
+// LineTotal calculates the total for quantity units at unitPrice,
 // applying the best matching bulk discount.
-func OrderTotal(book domain.Book, quantity int, tiers []DiscountTier) domain.Money {
-	unitPrice := book.Price()
+func LineTotal(unitPrice domain.Money, quantity int, tiers []DiscountTier) domain.Money {
 	discount := BulkDiscount(quantity, tiers)
 	effectivePercent := 100 - discount
 
@@ -42,18 +64,26 @@ func OrderTotal(book domain.Book, quantity int, tiers []DiscountTier) domain.Mon
 	return lineTotal
 }
 
-// ClassicSurcharge adds a 25% surcharge if the book is a classic (published > 50 years ago).
-// Classics are considered collector items.
-func ClassicSurcharge(book domain.Book) domain.Money {
-	if !book.IsClassic() {
+// ClassicSurcharge adds a 25% surcharge if the book is a classic (published > 50 years before now).
+// Classics are considered collector items. The same adjustment is available as
+// a configurable rule in DefaultPricingRules.
+func ClassicSurcharge(book domain.Book, now time.Time) domain.Money {
+	if !book.IsClassic(now) {
 		return book.Price()
 	}
 	return book.Price().MultiplyPercent(125)
 }
 
-// NewReleasePremium adds a 10% premium for books published within the last year.
-func NewReleasePremium(book domain.Book) domain.Money {
-	if !book.IsRecent() {
+// CopyClassicSurcharge applies ClassicSurcharge to one serialized copy at its
+// own price. The copy's printing date decides, so a first edition can carry
+// the surcharge while later editions of the same book do not.
+func CopyClassicSurcharge(c domain.SerialCopy, now time.Time) domain.Money {
+	return ClassicSurcharge(c.Offer(), now)
+}
+
+// NewReleasePremium adds a 10% premium for books published within the year before now.
+func NewReleasePremium(book domain.Book, now time.Time) domain.Money {
+	if !book.IsRecent(now) {
 		return book.Price()
 	}
 	return book.Price().MultiplyPercent(110)
@@ -1,6 +1,11 @@
 package calc
 
-import "testing"
+import (
+	"testing"
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
 
 func TestBulkDiscount_NoDiscount(t *testing.T) {
 	discount := BulkDiscount(5, StandardTiers)
@@ -22,3 +27,79 @@ func TestBulkDiscount_HighestTier(t *testing.T) {
 		t.Errorf("expected 20%% discount for 100 items, got %d%%", discount)
 	}
 }
+
+func TestVariantOrderTotal_UsesTheVariantPrice(t *testing.T) {
+	book := testBook(t, 1000, testNow, domain.GenreFiction)
+	price, _ := domain.NewMoney(400, "EUR")
+	used, _ := domain.NewUsedStockEntry(book, domain.ConditionGood, price, 20)
+	if total := VariantOrderTotal(used, 10, StandardTiers); total.Amount() != 3800 {
+		t.Errorf("expected 3800, got %d", total.Amount())
+	}
+	newCopies, _ := domain.NewStockEntry(book, 20)
+	if total := VariantOrderTotal(newCopies, 10, StandardTiers); total.Amount() != 9500 {
+		t.Errorf("expected 9500, got %d", total.Amount())
+	}
+}
+
+func TestOrderTotal_NoPriceListUsesGivenTiers(t *testing.T) {
+	book := testBook(t, 1000, testNow, domain.GenreFiction)
+	total := OrderTotal(book, 10, StandardTiers, nil)
+	if total.Amount() != 9500 {
+		t.Errorf("expected 9500, got %d", total.Amount())
+	}
+}
+
+func TestOrderTotal_PriceListNetPriceAndTiers(t *testing.T) {
+	book := testBook(t, 1000, testNow, domain.GenreFiction)
+	net, _ := domain.NewMoney(800, "EUR")
+	list := &PriceList{
+		Name:      "schools",
+		Overrides: map[string]domain.Money{book.ISBN().String(): net},
+		Tiers:     []DiscountTier{{MinQuantity: 5, Percent: 10}},
+	}
+
+	total := OrderTotal(book, 5, StandardTiers, list)
+	if total.Amount() != 3600 {
+		t.Errorf("expected 3600, got %d", total.Amount())
+	}
+}
+
+func TestClassicSurcharge_DependsOnGivenTime(t *testing.T) {
+	published := time.Date(1975, 3, 1, 0, 0, 0, 0, time.UTC)
+	book := testBook(t, 1000, published, domain.GenreFiction)
+
+	if got := ClassicSurcharge(book, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)); got.Amount() != 1000 {
+		t.Errorf("expected unchanged 1000 before 50 years, got %d", got.Amount())
+	}
+	if got := ClassicSurcharge(book, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)); got.Amount() != 1250 {
+		t.Errorf("expected 1250 after 50 years, got %d", got.Amount())
+	}
+}
+
+func TestCopyClassicSurcharge_UsesTheCopysPrinting(t *testing.T) {
+	book := testBook(t, 1000, testNow.AddDate(-10, 0, 0), domain.GenreFiction)
+	price, _ := domain.NewMoney(20000, "EUR")
+	reprint, _ := domain.NewSerialCopy("R-1", domain.ConditionGood, price)
+	inv := domain.NewInventory(nil)
+	reprint, _ = inv.AddCopy(book, "", reprint, domain.MovementInfo{})
+	if got := CopyClassicSurcharge(reprint, testNow); got.Amount() != 20000 {
+		t.Errorf("expected 20000 for a recent printing, got %d", got.Amount())
+	}
+	first, _ := domain.ParsePublicationDate("1950")
+	firstEdition, _ := domain.NewSerialCopy("F-1", domain.ConditionGood, price)
+	firstEdition, _ = inv.AddCopy(book, "", firstEdition.WithPrinting(first), domain.MovementInfo{})
+	if got := CopyClassicSurcharge(firstEdition, testNow); got.Amount() != 25000 {
+		t.Errorf("expected 25000 for a 1950 first edition, got %d", got.Amount())
+	}
+}
+
+func TestNewReleasePremium_DependsOnGivenTime(t *testing.T) {
+	book := testBook(t, 1000, testNow.AddDate(0, -6, 0), domain.GenreFiction)
+
+	if got := NewReleasePremium(book, testNow); got.Amount() != 1100 {
+		t.Errorf("expected 1100 for a recent book, got %d", got.Amount())
+	}
+	if got := NewReleasePremium(book, testNow.AddDate(1, 0, 0)); got.Amount() != 1000 {
+		t.Errorf("expected unchanged 1000 a year later, got %d", got.Amount())
+	}
+}
@@ -0,0 +1,64 @@
+package calc
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+// AppliedPromotion is a promotion code and the discount it granted on a quote.
+type AppliedPromotion struct {
+	Code     string
+	Discount domain.Money
+}
+
+// PromotionDiscount returns how much the promotion takes off the given lines.
+// Only lines whose genre the promotion covers are eligible, and the discount
+// never exceeds what earlier discounts have left of their combined total.
+func PromotionDiscount(p domain.Promotion, lines []QuoteLine, currency string) domain.Money {
+	terms := p.Terms()
+	var eligible, left int
+	var units []int // unit prices of every eligible copy
+	for _, l := range lines {
+		if !p.AppliesTo(l.Book.Genre()) {
+			continue
+		}
+		eligible += l.Total.Amount()
+		left += l.remaining()
+		for range l.Quantity {
+			units = append(units, l.UnitPrice.Amount())
+		}
+	}
+
+	var amount int
+	switch terms.Kind {
+	case domain.PromotionPercent:
+		amount = eligible * terms.Value / 100
+	case domain.PromotionFixed:
+		amount = terms.Value
+	case domain.PromotionBuyXGetY:
+		free := len(units) / (terms.BuyQuantity + terms.GetQuantity) * terms.GetQuantity
+		sort.Ints(units)
+		for _, u := range units[:free] {
+			amount += u
+		}
+	}
+
+	discount, _ := domain.NewMoney(min(amount, left), currency)
+	return discount
+}
+
+// CheckPromotionStacking returns an error if a non-stackable promotion is
+// combined with any other code.
+func CheckPromotionStacking(promos []domain.Promotion) error {
+	if len(promos) < 2 {
+		return nil
+	}
+	for _, p := range promos {
+		if !p.Terms().Stackable {
+			return fmt.Errorf("promotion %s cannot be combined with other codes", p.Code())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,252 @@
+package calc
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+// CartDiscount is a cart-level percentage discount for orders whose
+// subtotal (after bulk discounts) reaches MinSubtotal cents.
+type CartDiscount struct {
+	Name        string
+	MinSubtotal int
+	Percent     int
+}
+
+// ShippingPolicy charges a flat fee unless the discounted subtotal reaches
+// FreeOver cents. A zero FreeOver means shipping is never free.
+type ShippingPolicy struct {
+	FlatFee  int
+	FreeOver int
+}
+
+// QuoteOptions configure how a cart is quoted.
+type QuoteOptions struct {
+	Tiers     []DiscountTier
+	Discounts []CartDiscount
+	Shipping  ShippingPolicy
+
+	// Tax rates are applied per line to the discounted line amount; shipping
+	// is not taxed. A nil table means no tax is charged.
+	Tax                 TaxTable
+	TaxMode             TaxMode
+	DefaultJurisdiction Jurisdiction // used when a quote names no jurisdiction
+}
+
+// DefaultQuoteOptions are used for carts when nothing else is configured.
+var DefaultQuoteOptions = QuoteOptions{
+	Tiers: StandardTiers,
+	Discounts: []CartDiscount{
+		{Name: "Orders over 100", MinSubtotal: 10000, Percent: 5},
+	},
+	Shipping:            ShippingPolicy{FlatFee: 499, FreeOver: 4000},
+	Tax:                 DefaultTaxTable,
+	TaxMode:             TaxInclusive,
+	DefaultJurisdiction: Jurisdiction{Country: "DE"},
+}
+
+// QuoteItem is a book to be quoted with its already rule-adjusted unit price.
+type QuoteItem struct {
+	Book         domain.Book
+	Quantity     int
+	UnitPrice    domain.Money
+	MinUnitPrice domain.Money // the margin floor discounts must respect, see PriceBreakdown.Floor; zero for none
+}
+
+// QuoteRequest is everything about a cart that QuoteCart needs to price it.
+type QuoteRequest struct {
+	Currency     string
+	Items        []QuoteItem
+	Promotions   []domain.Promotion
+	Jurisdiction Jurisdiction
+	At           time.Time // selects the tax rates in effect
+}
+
+// QuoteLine is the priced result for a single QuoteItem.
+type QuoteLine struct {
+	Book                domain.Book
+	Quantity            int
+	UnitPrice           domain.Money
+	BulkDiscountPercent int
+	Total               domain.Money // after bulk discount, before cart-level discounts
+	Floor               domain.Money // MinUnitPrice times Quantity; no discount takes the line below it
+	Discount            domain.Money // this line's share of promotion and cart discounts
+	Taxable             domain.Money // Total minus Discount
+	TaxBasisPoints      int
+	Tax                 domain.Money
+}
+
+// Quote is a fully itemized price for a cart.
+type Quote struct {
+	Lines        []QuoteLine
+	Subtotal     domain.Money
+	Promotions   []AppliedPromotion
+	DiscountName string
+	Discount     domain.Money
+	TaxMode      TaxMode
+	TaxSummary   []TaxSummaryLine
+	Tax          domain.Money
+	Shipping     domain.Money
+	Total        domain.Money
+}
+
+// QuoteCart prices each item with its bulk tier, then applies promotion codes,
+// the best matching cart discount, tax and shipping. The automatic cart discount
+// is skipped when a non-stackable code is used. All items must be priced in the
+// request's currency. Discounts stop at each item's margin floor.
+//
+// Discounts are spread over the lines they apply to in proportion to line
+// totals, so tax can be computed per line at that line's rate.
+func QuoteCart(req QuoteRequest, opts QuoteOptions) (Quote, error) {
+	currency := req.Currency
+	zero, err := domain.NewMoney(0, currency)
+	if err != nil {
+		return Quote{}, err
+	}
+	if err := CheckPromotionStacking(req.Promotions); err != nil {
+		return Quote{}, err
+	}
+
+	q := Quote{Subtotal: zero, Discount: zero, Tax: zero, Shipping: zero, TaxMode: opts.TaxMode}
+	for _, it := range req.Items {
+		if it.UnitPrice.Currency() != currency {
+			return Quote{}, fmt.Errorf("book %s is priced in %s, cart is in %s",
+				it.Book.ISBN(), it.UnitPrice.Currency(), currency)
+		}
+		floor, _ := domain.NewMoney(it.MinUnitPrice.Amount()*it.Quantity, currency)
+		line := QuoteLine{
+			Book:                it.Book,
+			Quantity:            it.Quantity,
+			UnitPrice:           it.UnitPrice,
+			BulkDiscountPercent: BulkDiscount(it.Quantity, opts.Tiers),
+			Total:               LineTotal(it.UnitPrice, it.Quantity, opts.Tiers),
+			Floor:               floor,
+			Discount:            zero,
+			Tax:                 zero,
+		}
+		if line.Total.Amount() < floor.Amount() {
+			line.Total = floor
+		}
+		q.Lines = append(q.Lines, line)
+		q.Subtotal = q.Subtotal.Add(line.Total)
+	}
+
+	discounted := q.Subtotal
+	stackable := true
+	for _, p := range req.Promotions {
+		d := PromotionDiscount(p, q.Lines, currency)
+		if d.Amount() > discounted.Amount() {
+			d = discounted
+		}
+		allocateDiscount(q.Lines, d, func(l QuoteLine) bool { return p.AppliesTo(l.Book.Genre()) })
+		q.Promotions = append(q.Promotions, AppliedPromotion{Code: p.Code(), Discount: d})
+		discounted = discounted.Subtract(d)
+		stackable = stackable && p.Terms().Stackable
+	}
+
+	if d, ok := bestCartDiscount(discounted.Amount(), opts.Discounts); ok && stackable {
+		q.DiscountName = d.Name
+		q.Discount = discounted.Subtract(discounted.MultiplyPercent(100 - d.Percent))
+		if left := totalRemaining(q.Lines); q.Discount.Amount() > left {
+			q.Discount, _ = domain.NewMoney(left, currency)
+		}
+		allocateDiscount(q.Lines, q.Discount, func(QuoteLine) bool { return true })
+		discounted = discounted.Subtract(q.Discount)
+	}
+
+	jurisdiction := req.Jurisdiction
+	if jurisdiction.Country == "" {
+		jurisdiction = opts.DefaultJurisdiction
+	}
+	for i := range q.Lines {
+		l := &q.Lines[i]
+		l.Taxable = l.Total.Subtract(l.Discount)
+		if opts.Tax == nil {
+			continue
+		}
+		rate, err := opts.Tax.Rate(jurisdiction, l.Book.Format(), req.At)
+		if err != nil {
+			return Quote{}, err
+		}
+		l.TaxBasisPoints = rate.BasisPoints
+		l.Tax = TaxAmount(l.Taxable, rate.BasisPoints, opts.TaxMode)
+		q.Tax = q.Tax.Add(l.Tax)
+	}
+	if opts.Tax != nil {
+		q.TaxSummary = summarizeTax(q.Lines)
+	}
+
+	if len(q.Lines) > 0 && (opts.Shipping.FreeOver == 0 || discounted.Amount() < opts.Shipping.FreeOver) {
+		q.Shipping, _ = domain.NewMoney(opts.Shipping.FlatFee, currency)
+	}
+	q.Total = discounted.Add(q.Shipping)
+	if opts.TaxMode != TaxInclusive {
+		q.Total = q.Total.Add(q.Tax)
+	}
+	return q, nil
+}
+
+// remaining returns what discounts may still take off the line: its total
+// less the discounts allocated to it so far and its margin floor.
+func (l QuoteLine) remaining() int {
+	return l.Total.Amount() - l.Discount.Amount() - l.Floor.Amount()
+}
+
+func totalRemaining(lines []QuoteLine) int {
+	var left int
+	for _, l := range lines {
+		left += l.remaining()
+	}
+	return left
+}
+
+// allocateDiscount spreads amount over the eligible lines in proportion to
+// what is left of their totals, so no line is discounted below its floor as
+// long as amount does not exceed what is left. Rounding leftovers go to the lines
+// with the most left, largest first.
+func allocateDiscount(lines []QuoteLine, amount domain.Money, eligible func(QuoteLine) bool) {
+	var base int
+	var order []int // eligible lines, most left first
+	for i, l := range lines {
+		if eligible(l) {
+			base += l.remaining()
+			order = append(order, i)
+		}
+	}
+	if base == 0 || amount.IsZero() {
+		return
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return lines[order[a]].remaining() > lines[order[b]].remaining()
+	})
+
+	shares := make(map[int]int, len(order))
+	leftover := amount.Amount()
+	for _, i := range order {
+		shares[i] = amount.Amount() * lines[i].remaining() / base
+		leftover -= shares[i]
+	}
+	for _, i := range order {
+		extra := min(leftover, lines[i].remaining()-shares[i])
+		shares[i] += extra
+		leftover -= extra
+	}
+	for i, share := range shares {
+		lines[i].Discount, _ = domain.NewMoney(lines[i].Discount.Amount()+share, amount.Currency())
+	}
+}
+
+func bestCartDiscount(subtotal int, discounts []CartDiscount) (CartDiscount, bool) {
+	var best CartDiscount
+	found := false
+	for _, d := range discounts {
+		if subtotal >= d.MinSubtotal && d.Percent > best.Percent {
+			best = d
+			found = true
+		}
+	}
+	return best, found
+}
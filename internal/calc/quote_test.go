@@ -0,0 +1,237 @@
+package calc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+func TestQuoteCart_DiscountTaxAndShipping(t *testing.T) {
+	book := testBook(t, 1000, testNow, domain.GenreFiction)
+	opts := QuoteOptions{
+		Tiers:     StandardTiers,
+		Discounts: []CartDiscount{{Name: "big order", MinSubtotal: 10000, Percent: 10}},
+		Shipping:  ShippingPolicy{FlatFee: 499, FreeOver: 50000},
+		Tax:       TaxTable{{Jurisdiction: Jurisdiction{Country: "DE"}, Format: domain.FormatPrint, BasisPoints: 700}},
+		TaxMode:   TaxExclusive,
+	}
+
+	q, err := QuoteCart(QuoteRequest{
+		Currency:     "EUR",
+		Items:        []QuoteItem{{Book: book, Quantity: 12, UnitPrice: book.Price()}},
+		Jurisdiction: Jurisdiction{Country: "DE"},
+		At:           testNow,
+	}, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 12 x 10.00 with 5% bulk = 114.00; 10% cart discount = 11.40; tax 7% of 102.60 = 7.18
+	checks := map[string][2]int{
+		"subtotal": {q.Subtotal.Amount(), 11400},
+		"discount": {q.Discount.Amount(), 1140},
+		"tax":      {q.Tax.Amount(), 718},
+		"shipping": {q.Shipping.Amount(), 499},
+		"total":    {q.Total.Amount(), 11477},
+	}
+	for name, c := range checks {
+		if c[0] != c[1] {
+			t.Errorf("%s: expected %d, got %d", name, c[1], c[0])
+		}
+	}
+}
+
+func TestQuoteCart_EmptyCartHasNoShipping(t *testing.T) {
+	q, err := QuoteCart(QuoteRequest{Currency: "EUR", At: testNow}, DefaultQuoteOptions)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !q.Total.IsZero() {
+		t.Errorf("expected zero total, got %s", q.Total.Display())
+	}
+}
+
+func TestQuoteCart_RejectsCurrencyMismatch(t *testing.T) {
+	book := testBook(t, 1000, testNow, domain.GenreFiction)
+	_, err := QuoteCart(QuoteRequest{
+		Currency: "USD",
+		Items:    []QuoteItem{{Book: book, Quantity: 1, UnitPrice: book.Price()}},
+		At:       testNow,
+	}, DefaultQuoteOptions)
+	if err == nil {
+		t.Fatal("expected error for currency mismatch")
+	}
+}
+
+func TestQuoteCart_BuyXGetYRestrictedToGenre(t *testing.T) {
+	fiction := testBook(t, 1000, testNow, domain.GenreFiction)
+	science := testBook(t, 3000, testNow, domain.GenreScience)
+	promo, err := domain.NewPromotion("bookweek", domain.PromotionTerms{
+		Kind:        domain.PromotionBuyXGetY,
+		BuyQuantity: 2,
+		GetQuantity: 1,
+		Genres:      []domain.Genre{domain.GenreFiction},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	items := []QuoteItem{
+		{Book: fiction, Quantity: 3, UnitPrice: fiction.Price()},
+		{Book: science, Quantity: 1, UnitPrice: science.Price()},
+	}
+	q, err := QuoteCart(QuoteRequest{Currency: "EUR", Items: items, Promotions: []domain.Promotion{promo}}, QuoteOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(q.Promotions) != 1 || q.Promotions[0].Discount.Amount() != 1000 {
+		t.Errorf("expected one free fiction copy, got %+v", q.Promotions)
+	}
+	if q.Total.Amount() != 5000 {
+		t.Errorf("expected total 5000, got %d", q.Total.Amount())
+	}
+}
+
+func TestQuoteCart_StackedCodesShareWhatIsLeft(t *testing.T) {
+	fiction := testBook(t, 1000, testNow, domain.GenreFiction)
+	science := testBook(t, 3000, testNow, domain.GenreScience)
+	var promos []domain.Promotion
+	for _, code := range []string{"FIC8", "MOREFIC8"} {
+		p, err := domain.NewPromotion(code, domain.PromotionTerms{
+			Kind:      domain.PromotionFixed,
+			Value:     800,
+			Genres:    []domain.Genre{domain.GenreFiction},
+			Stackable: true,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		promos = append(promos, p)
+	}
+	opts := QuoteOptions{
+		Tax:                 TaxTable{{Jurisdiction: Jurisdiction{Country: "DE"}, Format: domain.FormatPrint, BasisPoints: 700}},
+		TaxMode:             TaxExclusive,
+		DefaultJurisdiction: Jurisdiction{Country: "DE"},
+	}
+
+	q, err := QuoteCart(QuoteRequest{
+		Currency: "EUR",
+		Items: []QuoteItem{
+			{Book: fiction, Quantity: 1, UnitPrice: fiction.Price()},
+			{Book: science, Quantity: 1, UnitPrice: science.Price()},
+		},
+		Promotions: promos,
+		At:         testNow,
+	}, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The second code only gets the 2.00 the first left on the fiction line.
+	if q.Promotions[0].Discount.Amount() != 800 || q.Promotions[1].Discount.Amount() != 200 {
+		t.Errorf("got discounts %+v, want 800 then 200", q.Promotions)
+	}
+	if l := q.Lines[0]; l.Taxable.Amount() != 0 || l.Tax.Amount() != 0 {
+		t.Errorf("got fiction taxable %d, tax %d; want both zero", l.Taxable.Amount(), l.Tax.Amount())
+	}
+	if q.Tax.Amount() != 210 || q.Total.Amount() != 3210 {
+		t.Errorf("got tax %d, total %d; want 210 and 3210", q.Tax.Amount(), q.Total.Amount())
+	}
+}
+
+func TestQuoteCart_NonStackableSkipsCartDiscount(t *testing.T) {
+	book := testBook(t, 1000, testNow, domain.GenreFiction)
+	promo, _ := domain.NewPromotion("SAVE10", domain.PromotionTerms{Kind: domain.PromotionPercent, Value: 10})
+	opts := QuoteOptions{Discounts: []CartDiscount{{Name: "always", Percent: 50}}}
+
+	q, err := QuoteCart(QuoteRequest{
+		Currency:   "EUR",
+		Items:      []QuoteItem{{Book: book, Quantity: 1, UnitPrice: book.Price()}},
+		Promotions: []domain.Promotion{promo},
+	}, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !q.Discount.IsZero() || q.Total.Amount() != 900 {
+		t.Errorf("expected only the code discount, got discount %d total %d", q.Discount.Amount(), q.Total.Amount())
+	}
+}
+
+func TestQuoteCart_DiscountsStopAtMarginFloor(t *testing.T) {
+	book := testBook(t, 1000, testNow, domain.GenreFiction)
+	floor, _ := domain.NewMoney(750, "EUR")
+	promo, _ := domain.NewPromotion("HALF", domain.PromotionTerms{Kind: domain.PromotionPercent, Value: 50, Stackable: true})
+	opts := QuoteOptions{Discounts: []CartDiscount{{Name: "always", Percent: 10}}}
+
+	q, err := QuoteCart(QuoteRequest{
+		Currency:   "EUR",
+		Items:      []QuoteItem{{Book: book, Quantity: 2, UnitPrice: book.Price(), MinUnitPrice: floor}},
+		Promotions: []domain.Promotion{promo},
+	}, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q.Promotions[0].Discount.Amount() != 500 || !q.Discount.IsZero() || q.Total.Amount() != 1500 {
+		t.Errorf("got code discount %d, cart discount %d, total %d; want 500, 0, 1500",
+			q.Promotions[0].Discount.Amount(), q.Discount.Amount(), q.Total.Amount())
+	}
+}
+
+func TestQuoteCart_InclusiveTaxPerFormat(t *testing.T) {
+	paper := testBook(t, 1070, testNow, domain.GenreFiction)
+	ebook, _ := testBook(t, 1190, testNow, domain.GenreFiction).WithFormat(domain.FormatDigital)
+	opts := QuoteOptions{
+		Tax: TaxTable{
+			{Jurisdiction: Jurisdiction{Country: "DE"}, Format: domain.FormatPrint, BasisPoints: 700},
+			{Jurisdiction: Jurisdiction{Country: "DE"}, Format: domain.FormatDigital, BasisPoints: 1900},
+		},
+		TaxMode:             TaxInclusive,
+		DefaultJurisdiction: Jurisdiction{Country: "DE"},
+	}
+
+	q, err := QuoteCart(QuoteRequest{
+		Currency: "EUR",
+		Items: []QuoteItem{
+			{Book: paper, Quantity: 1, UnitPrice: paper.Price()},
+			{Book: ebook, Quantity: 1, UnitPrice: ebook.Price()},
+		},
+		At: testNow,
+	}, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q.Lines[0].Tax.Amount() != 70 || q.Lines[1].Tax.Amount() != 190 {
+		t.Errorf("expected line taxes 70 and 190, got %d and %d", q.Lines[0].Tax.Amount(), q.Lines[1].Tax.Amount())
+	}
+	if q.Total.Amount() != 2260 {
+		t.Errorf("expected inclusive total 2260, got %d", q.Total.Amount())
+	}
+	if len(q.TaxSummary) != 2 {
+		t.Errorf("expected two summary lines, got %d", len(q.TaxSummary))
+	}
+}
+
+func TestTaxTable_RateHonoursEffectiveDateAndRegion(t *testing.T) {
+	table := TaxTable{
+		{Jurisdiction: Jurisdiction{Country: "DE"}, Format: domain.FormatDigital, BasisPoints: 1900},
+		{Jurisdiction: Jurisdiction{Country: "DE"}, Format: domain.FormatDigital, BasisPoints: 700, EffectiveFrom: time.Date(2019, 12, 18, 0, 0, 0, 0, time.UTC)},
+		{Jurisdiction: Jurisdiction{Country: "US"}, Format: domain.FormatPrint, BasisPoints: 0},
+		{Jurisdiction: Jurisdiction{Country: "US", Region: "CA"}, Format: domain.FormatPrint, BasisPoints: 725},
+	}
+
+	before, _ := table.Rate(Jurisdiction{Country: "DE"}, domain.FormatDigital, time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	after, _ := table.Rate(Jurisdiction{Country: "DE"}, domain.FormatDigital, testNow)
+	if before.BasisPoints != 1900 || after.BasisPoints != 700 {
+		t.Errorf("expected 1900 then 700, got %d then %d", before.BasisPoints, after.BasisPoints)
+	}
+
+	ca, _ := table.Rate(Jurisdiction{Country: "US", Region: "CA"}, domain.FormatPrint, testNow)
+	or, _ := table.Rate(Jurisdiction{Country: "US", Region: "OR"}, domain.FormatPrint, testNow)
+	if ca.BasisPoints != 725 || or.BasisPoints != 0 {
+		t.Errorf("expected 725 for CA and 0 for OR, got %d and %d", ca.BasisPoints, or.BasisPoints)
+	}
+
+	if _, err := table.Rate(Jurisdiction{Country: "FR"}, domain.FormatPrint, testNow); err == nil {
+		t.Error("expected error for unknown jurisdiction")
+	}
+}
@@ -0,0 +1,280 @@
+package calc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+// RuleAction identifies how a pricing rule adjusts the price.
+type RuleAction string
+
+const (
+	ActionPercent RuleAction = "percent" // adjust by Value percent, e.g. 25 = +25%, -10 = -10%
+	ActionFixed   RuleAction = "fixed"   // adjust by Value cents
+	ActionFloor   RuleAction = "floor"   // raise the price to at least Value cents
+	ActionCap     RuleAction = "cap"     // lower the price to at most Value cents
+
+	// ActionMinMargin raises the price until it earns at least Value basis
+	// points of gross margin over the unit cost. Skipped when the cost is unknown.
+	ActionMinMargin RuleAction = "min_margin"
+)
+
+// StackingPolicy controls how a matching rule combines with other rules.
+type StackingPolicy string
+
+const (
+	StackCombine   StackingPolicy = "combine"   // applies on top of earlier rules (default)
+	StackStop      StackingPolicy = "stop"      // applies, then no further rules are evaluated
+	StackExclusive StackingPolicy = "exclusive" // applies only if no earlier rule did, then stops
+)
+
+// RuleCondition restricts when a rule applies. Zero-valued fields are ignored;
+// every field that is set must match.
+type RuleCondition struct {
+	Genres       []domain.Genre `json:"genres,omitempty"`
+	Authors      []string       `json:"authors,omitempty"`       // author last names, case-insensitive
+	MinAgeYears  int            `json:"min_age_years,omitempty"` // published at least this many years ago
+	MaxAgeYears  int            `json:"max_age_years,omitempty"` // published less than this many years ago
+	Classic      bool           `json:"classic,omitempty"`       // a classic under the context's age policy
+	Recent       bool           `json:"recent,omitempty"`        // a recent release under the context's age policy
+	StockBelow   int            `json:"stock_below,omitempty"`   // fewer than this many copies available
+	StockAtLeast int            `json:"stock_at_least,omitempty"`
+	From         time.Time      `json:"from,omitzero"`  // inclusive
+	Until        time.Time      `json:"until,omitzero"` // exclusive
+}
+
+// PricingRule is a single declarative price adjustment.
+type PricingRule struct {
+	Name     string         `json:"name"`
+	Priority int            `json:"priority"` // lower priorities are evaluated first
+	Action   RuleAction     `json:"action"`
+	Value    int            `json:"value"`
+	Stacking StackingPolicy `json:"stacking,omitempty"`
+	Disabled bool           `json:"disabled,omitempty"`
+	When     RuleCondition  `json:"when"`
+}
+
+// PricingContext carries everything a rule may inspect when pricing a book.
+type PricingContext struct {
+	Book       domain.Book
+	Now        time.Time
+	Stock      int // available copies, only consulted when StockKnown is set
+	StockKnown bool
+	UnitCost   domain.Money // average acquisition cost, only consulted when CostKnown is set
+	CostKnown  bool
+	PriceList  *PriceList        // the requesting customer's price list, if any
+	Ages       *domain.AgePolicy // classic and recent thresholds, nil means domain.DefaultAgePolicy
+}
+
+// DefaultPricingRules reproduce the classic surcharge and new release premium.
+var DefaultPricingRules = []PricingRule{
+	{Name: "ClassicSurcharge", Priority: 10, Action: ActionPercent, Value: 25, When: RuleCondition{Classic: true}},
+	{Name: "NewReleasePremium", Priority: 20, Action: ActionPercent, Value: 10, When: RuleCondition{Recent: true}},
+}
+
+// PricingEngine evaluates pricing rules in priority order.
+type PricingEngine struct {
+	rules []PricingRule
+}
+
+// NewPricingEngine validates the rules and orders them by priority.
+// Rules with equal priority keep their configured order.
+func NewPricingEngine(rules []PricingRule) (*PricingEngine, error) {
+	seen := make(map[string]bool, len(rules))
+	sorted := make([]PricingRule, 0, len(rules))
+	for _, r := range rules {
+		if err := validateRule(r); err != nil {
+			return nil, err
+		}
+		if seen[r.Name] {
+			return nil, fmt.Errorf("duplicate pricing rule: %s", r.Name)
+		}
+		seen[r.Name] = true
+		if r.Stacking == "" {
+			r.Stacking = StackCombine
+		}
+		sorted = append(sorted, r)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority < sorted[j].Priority
+	})
+	return &PricingEngine{rules: sorted}, nil
+}
+
+// LoadPricingRules decodes a JSON array of pricing rules.
+func LoadPricingRules(r io.Reader) ([]PricingRule, error) {
+	var rules []PricingRule
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&rules); err != nil {
+		return nil, fmt.Errorf("decode pricing rules: %w", err)
+	}
+	return rules, nil
+}
+
+// Rules returns the engine's rules in evaluation order.
+func (e *PricingEngine) Rules() []PricingRule {
+	return slices.Clone(e.rules)
+}
+
+// UnitPrice applies all matching rules to the book's price.
+func (e *PricingEngine) UnitPrice(ctx PricingContext) domain.Money {
+	return e.Explain(ctx).Final
+}
+
+// Explain applies all matching rules to the book's price, recording a step
+// for every rule that applied, including those that left the price unchanged.
+// A net price from the customer's price list replaces the rules. Minimum
+// margin rules also guard the result: whatever the stacking or price list,
+// the final price is raised to the highest floor they set, which is
+// reported as the breakdown's Floor so that later discounts can honour it.
+func (e *PricingEngine) Explain(ctx PricingContext) PriceBreakdown {
+	b := PriceBreakdown{Base: ctx.Book.Price(), Final: ctx.Book.Price()}
+	if net, ok := ctx.PriceList.NetPrice(ctx.Book); ok {
+		b.record("PriceList "+ctx.PriceList.Name, net)
+	} else {
+		for _, r := range e.rules {
+			if r.Disabled || !r.When.matches(ctx) {
+				continue
+			}
+			if r.Action == ActionMinMargin && !ctx.CostKnown {
+				continue
+			}
+			if r.Stacking == StackExclusive && len(b.Steps) > 0 {
+				continue
+			}
+			b.record(r.Name, r.apply(b.Final, ctx))
+			if r.Stacking == StackStop || r.Stacking == StackExclusive {
+				break
+			}
+		}
+	}
+	if b.FloorRule, b.Floor = e.marginFloor(ctx); b.FloorRule != "" && b.Final.Amount() < b.Floor.Amount() {
+		b.record(b.FloorRule, b.Floor)
+	}
+	return b
+}
+
+// marginFloor returns the highest price floor set by the matching minimum
+// margin rules and the rule that sets it, or no rule if none applies.
+func (e *PricingEngine) marginFloor(ctx PricingContext) (string, domain.Money) {
+	var name string
+	var floor domain.Money
+	if !ctx.CostKnown {
+		return name, floor
+	}
+	for _, r := range e.rules {
+		if r.Disabled || r.Action != ActionMinMargin || !r.When.matches(ctx) {
+			continue
+		}
+		if p := MinimumMarginPrice(ctx.UnitCost, r.Value); name == "" || p.Amount() > floor.Amount() {
+			name = r.Name
+			floor, _ = domain.NewMoney(p.Amount(), ctx.Book.Price().Currency())
+		}
+	}
+	return name, floor
+}
+
+func (r PricingRule) apply(price domain.Money, ctx PricingContext) domain.Money {
+	amount := price.Amount()
+	switch r.Action {
+	case ActionMinMargin:
+		if BelowMinimumMargin(price, ctx.UnitCost, r.Value) {
+			amount = MinimumMarginPrice(ctx.UnitCost, r.Value).Amount()
+		}
+	case ActionPercent:
+		amount = price.MultiplyPercent(100 + r.Value).Amount()
+	case ActionFixed:
+		amount += r.Value
+	case ActionFloor:
+		amount = max(amount, r.Value)
+	case ActionCap:
+		amount = min(amount, r.Value)
+	}
+	result, _ := domain.NewMoney(max(amount, 0), price.Currency())
+	return result
+}
+
+func (c RuleCondition) matches(ctx PricingContext) bool {
+	if len(c.Genres) > 0 && !slices.Contains(c.Genres, ctx.Book.Genre()) {
+		return false
+	}
+	if len(c.Authors) > 0 && !containsFold(c.Authors, ctx.Book.Author().LastName()) {
+		return false
+	}
+	published := ctx.Book.Publication()
+	if c.MinAgeYears > 0 && published.YearsSince(ctx.Now) < c.MinAgeYears {
+		return false
+	}
+	if c.MaxAgeYears > 0 && !published.Time().AddDate(c.MaxAgeYears, 0, 0).After(ctx.Now) {
+		return false
+	}
+	if c.Classic && !ctx.Ages.IsClassic(ctx.Book, ctx.Now) {
+		return false
+	}
+	if c.Recent && !ctx.Ages.IsRecent(ctx.Book, ctx.Now) {
+		return false
+	}
+	if c.StockBelow > 0 && (!ctx.StockKnown || ctx.Stock >= c.StockBelow) {
+		return false
+	}
+	if c.StockAtLeast > 0 && (!ctx.StockKnown || ctx.Stock < c.StockAtLeast) {
+		return false
+	}
+	if !c.From.IsZero() && ctx.Now.Before(c.From) {
+		return false
+	}
+	if !c.Until.IsZero() && !ctx.Now.Before(c.Until) {
+		return false
+	}
+	return true
+}
+
+func validateRule(r PricingRule) error {
+	if r.Name == "" {
+		return errors.New("pricing rule name must not be empty")
+	}
+	switch r.Action {
+	case ActionPercent:
+		if r.Value <= -100 {
+			return fmt.Errorf("rule %s: percent adjustment must be greater than -100", r.Name)
+		}
+	case ActionFixed:
+	case ActionFloor, ActionCap:
+		if r.Value < 0 {
+			return fmt.Errorf("rule %s: %s value must not be negative", r.Name, r.Action)
+		}
+	case ActionMinMargin:
+		if r.Value < 0 || r.Value >= 10000 {
+			return fmt.Errorf("rule %s: minimum margin must be between 0 and 9999 basis points", r.Name)
+		}
+	default:
+		return fmt.Errorf("rule %s: unknown action %q", r.Name, r.Action)
+	}
+	switch r.Stacking {
+	case "", StackCombine, StackStop, StackExclusive:
+	default:
+		return fmt.Errorf("rule %s: unknown stacking policy %q", r.Name, r.Stacking)
+	}
+	if !r.When.From.IsZero() && !r.When.Until.IsZero() && !r.When.From.Before(r.When.Until) {
+		return fmt.Errorf("rule %s: date window must end after it starts", r.Name)
+	}
+	return nil
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,210 @@
+package calc
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+func testBook(t *testing.T, priceCents int, publishedAt time.Time, genre domain.Genre) domain.Book {
+	t.Helper()
+	isbn, _ := domain.NewISBN("9780306406157")
+	author, _ := domain.NewAuthor("Jane", "Austen")
+	price, _ := domain.NewMoney(priceCents, "EUR")
+	book, err := domain.NewBook(isbn, "Emma", author, price, publishedAt, genre)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return book
+}
+
+var testNow = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func TestPricingEngine_DefaultRulesClassic(t *testing.T) {
+	engine, err := NewPricingEngine(DefaultPricingRules)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	book := testBook(t, 1000, time.Date(1815, 12, 23, 0, 0, 0, 0, time.UTC), domain.GenreFiction)
+
+	price := engine.UnitPrice(PricingContext{Book: book, Now: testNow})
+	if price.Amount() != 1250 {
+		t.Errorf("expected 1250, got %d", price.Amount())
+	}
+}
+
+func TestPricingEngine_ClassicFollowsAgePolicy(t *testing.T) {
+	engine, err := NewPricingEngine(DefaultPricingRules)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	book := testBook(t, 1000, time.Date(1995, 3, 1, 0, 0, 0, 0, time.UTC), domain.GenreChildren)
+
+	if price := engine.UnitPrice(PricingContext{Book: book, Now: testNow}); price.Amount() != 1250 {
+		t.Errorf("expected 1250 for a 30 year old children's book, got %d", price.Amount())
+	}
+	strict := &domain.AgePolicy{Default: domain.AgeThresholds{ClassicYears: 50, RecentYears: 1}}
+	if price := engine.UnitPrice(PricingContext{Book: book, Now: testNow, Ages: strict}); price.Amount() != 1000 {
+		t.Errorf("expected 1000 without a children's threshold, got %d", price.Amount())
+	}
+}
+
+func TestPricingEngine_StackingOrder(t *testing.T) {
+	engine, err := NewPricingEngine([]PricingRule{
+		{Name: "cap", Priority: 30, Action: ActionCap, Value: 1100},
+		{Name: "surcharge", Priority: 10, Action: ActionPercent, Value: 50},
+		{Name: "fee", Priority: 20, Action: ActionFixed, Value: 100},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	book := testBook(t, 1000, testNow, domain.GenreScience)
+
+	price := engine.UnitPrice(PricingContext{Book: book, Now: testNow})
+	if price.Amount() != 1100 {
+		t.Errorf("expected capped price 1100, got %d", price.Amount())
+	}
+}
+
+func TestPricingEngine_ExclusiveSkippedAfterEarlierRule(t *testing.T) {
+	engine, err := NewPricingEngine([]PricingRule{
+		{Name: "science", Priority: 1, Action: ActionPercent, Value: -10, When: RuleCondition{Genres: []domain.Genre{domain.GenreScience}}},
+		{Name: "clearance", Priority: 2, Action: ActionPercent, Value: -50, Stacking: StackExclusive},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	science := testBook(t, 1000, testNow, domain.GenreScience)
+	if got := engine.UnitPrice(PricingContext{Book: science, Now: testNow}).Amount(); got != 900 {
+		t.Errorf("expected 900 for science book, got %d", got)
+	}
+	fiction := testBook(t, 1000, testNow, domain.GenreFiction)
+	if got := engine.UnitPrice(PricingContext{Book: fiction, Now: testNow}).Amount(); got != 500 {
+		t.Errorf("expected 500 for fiction book, got %d", got)
+	}
+}
+
+func TestPricingEngine_StockAndDateConditions(t *testing.T) {
+	engine, err := NewPricingEngine([]PricingRule{{
+		Name:   "last-copies",
+		Action: ActionPercent,
+		Value:  20,
+		When: RuleCondition{
+			StockBelow: 3,
+			From:       time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			Until:      time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	book := testBook(t, 1000, testNow, domain.GenreFiction)
+
+	if got := engine.UnitPrice(PricingContext{Book: book, Now: testNow}).Amount(); got != 1000 {
+		t.Errorf("expected rule to be skipped without stock level, got %d", got)
+	}
+	if got := engine.UnitPrice(PricingContext{Book: book, Now: testNow, Stock: 2, StockKnown: true}).Amount(); got != 1200 {
+		t.Errorf("expected 1200 with low stock, got %d", got)
+	}
+	later := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	if got := engine.UnitPrice(PricingContext{Book: book, Now: later, Stock: 2, StockKnown: true}).Amount(); got != 1000 {
+		t.Errorf("expected rule to be skipped outside its window, got %d", got)
+	}
+}
+
+func TestNewPricingEngine_RejectsUnknownAction(t *testing.T) {
+	_, err := NewPricingEngine([]PricingRule{{Name: "bad", Action: "double"}})
+	if err == nil {
+		t.Fatal("expected error for unknown action")
+	}
+}
+
+func TestLoadPricingRules(t *testing.T) {
+	rules, err := LoadPricingRules(strings.NewReader(`[
+		{"name": "children", "priority": 5, "action": "percent", "value": -15, "when": {"genres": ["children"]}}
+	]`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rules) != 1 || rules[0].When.Genres[0] != domain.GenreChildren {
+		t.Errorf("unexpected rules: %+v", rules)
+	}
+}
+
+func TestExplainLineTotal_MatchesLineTotal(t *testing.T) {
+	engine, err := NewPricingEngine(DefaultPricingRules)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	book := testBook(t, 999, time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC), domain.GenreFiction)
+
+	unit := engine.Explain(PricingContext{Book: book, Now: testNow})
+	line := ExplainLineTotal(unit, 30, StandardTiers)
+
+	want := LineTotal(unit.Final, 30, StandardTiers)
+	if line.Final != want {
+		t.Errorf("expected final %s, got %s", want.Display(), line.Final.Display())
+	}
+	names := make([]string, 0, len(line.Steps))
+	for _, s := range line.Steps {
+		names = append(names, s.Name)
+	}
+	if strings.Join(names, ",") != "ClassicSurcharge,Quantity x30,BulkDiscount" {
+		t.Errorf("unexpected steps: %v", names)
+	}
+}
+
+func TestPricingEngine_MinMarginRaisesPrice(t *testing.T) {
+	engine, err := NewPricingEngine([]PricingRule{
+		{Name: "clearance", Priority: 1, Action: ActionPercent, Value: -50},
+		{Name: "margin-guard", Priority: 99, Action: ActionMinMargin, Value: 2000},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	book := testBook(t, 1000, testNow, domain.GenreFiction)
+	cost, _ := domain.NewMoney(600, "EUR")
+
+	guarded := engine.UnitPrice(PricingContext{Book: book, Now: testNow, UnitCost: cost, CostKnown: true})
+	if guarded.Amount() != 750 {
+		t.Errorf("expected price raised to 750, got %d", guarded.Amount())
+	}
+	if BelowMinimumMargin(guarded, cost, 2000) {
+		t.Errorf("expected %d to earn at least 20%% margin, got %d bp", guarded.Amount(), GrossMargin(guarded, cost))
+	}
+
+	unknown := engine.UnitPrice(PricingContext{Book: book, Now: testNow})
+	if unknown.Amount() != 500 {
+		t.Errorf("expected guard to be skipped without cost, got %d", unknown.Amount())
+	}
+}
+
+func TestPricingEngine_MinMarginGuardsNetPricesAndBulkDiscounts(t *testing.T) {
+	engine, err := NewPricingEngine([]PricingRule{
+		{Name: "margin-guard", Priority: 99, Action: ActionMinMargin, Value: 2000},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	book := testBook(t, 1000, testNow, domain.GenreFiction)
+	cost, _ := domain.NewMoney(600, "EUR")
+	net, _ := domain.NewMoney(500, "EUR")
+	list := &PriceList{Name: "schools", Overrides: map[string]domain.Money{book.ISBN().String(): net}}
+
+	unit := engine.Explain(PricingContext{Book: book, Now: testNow, UnitCost: cost, CostKnown: true, PriceList: list})
+	if unit.Final.Amount() != 750 || unit.Floor.Amount() != 750 || unit.FloorRule != "margin-guard" {
+		t.Errorf("expected the net price raised to the 750 floor, got %d (floor %d by %q)", unit.Final.Amount(), unit.Floor.Amount(), unit.FloorRule)
+	}
+
+	unit = engine.Explain(PricingContext{Book: book, Now: testNow, UnitCost: cost, CostKnown: true})
+	line := ExplainLineTotal(unit, 10, []DiscountTier{{MinQuantity: 10, Percent: 40}})
+	if line.Final.Amount() != 7500 {
+		t.Errorf("expected the bulk discount to stop at 7500, got %d", line.Final.Amount())
+	}
+	if last := line.Steps[len(line.Steps)-1]; last.Name != "margin-guard" {
+		t.Errorf("expected the guard to apply last, got %s", last.Name)
+	}
+}
@@ -0,0 +1,160 @@
+package calc
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+// TaxMode says whether prices already include tax.
+type TaxMode string
+
+const (
+	TaxExclusive TaxMode = "exclusive" // tax is added on top of prices
+	TaxInclusive TaxMode = "inclusive" // prices already contain tax
+)
+
+// Jurisdiction is where a sale is taxed.
+type Jurisdiction struct {
+	Country string // ISO 3166-1 alpha-2, e.g. "DE"
+	Region  string // optional subdivision; "" means country-wide
+}
+
+func (j Jurisdiction) String() string {
+	if j.Region == "" {
+		return j.Country
+	}
+	return j.Country + "-" + j.Region
+}
+
+// TaxRate is the rate for a jurisdiction and book format from a given date.
+type TaxRate struct {
+	Jurisdiction  Jurisdiction
+	Format        domain.Format
+	BasisPoints   int // 700 = 7%
+	EffectiveFrom time.Time
+}
+
+// TaxTable holds every known rate, including superseded ones.
+type TaxTable []TaxRate
+
+// DefaultTaxTable holds reduced book rates for a few EU countries. Since
+// 2020 the EU allows ebooks to be taxed at the same reduced rate as print.
+var DefaultTaxTable = TaxTable{
+	{Jurisdiction: Jurisdiction{Country: "DE"}, Format: domain.FormatPrint, BasisPoints: 700},
+	{Jurisdiction: Jurisdiction{Country: "DE"}, Format: domain.FormatDigital, BasisPoints: 1900},
+	{Jurisdiction: Jurisdiction{Country: "DE"}, Format: domain.FormatDigital, BasisPoints: 700, EffectiveFrom: time.Date(2019, 12, 18, 0, 0, 0, 0, time.UTC)},
+	{Jurisdiction: Jurisdiction{Country: "FR"}, Format: domain.FormatPrint, BasisPoints: 550},
+	{Jurisdiction: Jurisdiction{Country: "FR"}, Format: domain.FormatDigital, BasisPoints: 550},
+	{Jurisdiction: Jurisdiction{Country: "NL"}, Format: domain.FormatPrint, BasisPoints: 900},
+	{Jurisdiction: Jurisdiction{Country: "NL"}, Format: domain.FormatDigital, BasisPoints: 900},
+	{Jurisdiction: Jurisdiction{Country: "IE"}, Format: domain.FormatPrint, BasisPoints: 0},
+	{Jurisdiction: Jurisdiction{Country: "IE"}, Format: domain.FormatDigital, BasisPoints: 900},
+}
+
+// Validate checks that every rate is non-negative and names a country and format.
+func (t TaxTable) Validate() error {
+	for _, r := range t {
+		if r.Jurisdiction.Country == "" {
+			return errors.New("tax rate country must not be empty")
+		}
+		if r.Format != domain.FormatPrint && r.Format != domain.FormatDigital {
+			return fmt.Errorf("unknown format: %s", r.Format)
+		}
+		if r.BasisPoints < 0 {
+			return fmt.Errorf("tax rate for %s must not be negative", r.Jurisdiction)
+		}
+	}
+	return nil
+}
+
+// Rate returns the rate in effect at the given time. A rate for the exact
+// region takes precedence over a country-wide one; among those, the most
+// recent effective date wins.
+func (t TaxTable) Rate(j Jurisdiction, format domain.Format, at time.Time) (TaxRate, error) {
+	var best TaxRate
+	found := false
+	for _, r := range t {
+		if r.Jurisdiction.Country != j.Country || r.Format != format || r.EffectiveFrom.After(at) {
+			continue
+		}
+		if r.Jurisdiction.Region != "" && r.Jurisdiction.Region != j.Region {
+			continue
+		}
+		if !found || moreSpecific(r, best) {
+			best = r
+			found = true
+		}
+	}
+	if !found {
+		return TaxRate{}, fmt.Errorf("no %s tax rate for %s", format, j)
+	}
+	return best, nil
+}
+
+func moreSpecific(a, b TaxRate) bool {
+	if (a.Jurisdiction.Region != "") != (b.Jurisdiction.Region != "") {
+		return a.Jurisdiction.Region != ""
+	}
+	return a.EffectiveFrom.After(b.EffectiveFrom)
+}
+
+// TaxAmount returns the tax contained in (inclusive) or owed on top of
+// (exclusive) amount at the given rate, rounded half up to the nearest cent.
+func TaxAmount(amount domain.Money, basisPoints int, mode TaxMode) domain.Money {
+	var tax int
+	if mode == TaxInclusive {
+		tax = amount.Amount() - roundDiv(amount.Amount()*10000, 10000+basisPoints)
+	} else {
+		tax = roundDiv(amount.Amount()*basisPoints, 10000)
+	}
+	m, _ := domain.NewMoney(tax, amount.Currency())
+	return m
+}
+
+// TaxSummaryLine totals the tax charged at a single rate and format.
+type TaxSummaryLine struct {
+	Format      domain.Format
+	BasisPoints int
+	Taxable     domain.Money
+	Tax         domain.Money
+}
+
+// summarizeTax groups line taxes by format and rate, ordered by format then rate.
+func summarizeTax(lines []QuoteLine) []TaxSummaryLine {
+	type key struct {
+		format domain.Format
+		bp     int
+	}
+	groups := make(map[key]*TaxSummaryLine)
+	var result []TaxSummaryLine
+	for _, l := range lines {
+		k := key{l.Book.Format(), l.TaxBasisPoints}
+		g, ok := groups[k]
+		if !ok {
+			zero, _ := domain.NewMoney(0, l.Taxable.Currency())
+			g = &TaxSummaryLine{Format: k.format, BasisPoints: k.bp, Taxable: zero, Tax: zero}
+			groups[k] = g
+		}
+		g.Taxable = g.Taxable.Add(l.Taxable)
+		g.Tax = g.Tax.Add(l.Tax)
+	}
+	for _, g := range groups {
+		result = append(result, *g)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Format != result[j].Format {
+			return result[i].Format < result[j].Format
+		}
+		return result[i].BasisPoints < result[j].BasisPoints
+	})
+	return result
+}
+
+// roundDiv divides non-negative a by b, rounding half up.
+func roundDiv(a, b int) int {
+	return (2*a + b) / (2 * b)
+}
@@ -0,0 +1,285 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// BackorderKind distinguishes waiting for stock from waiting for release.
+type BackorderKind string
+
+const (
+	// KindBackorder waits for copies of a released book to come in.
+	KindBackorder BackorderKind = "backorder"
+	// KindPreorder waits for an unreleased book; it is only allocated once
+	// the book's publication date has passed.
+	KindPreorder BackorderKind = "preorder"
+)
+
+// BackorderStatus tracks a backorder through its life.
+type BackorderStatus string
+
+const (
+	// BackorderWaiting has copies still to allocate.
+	BackorderWaiting BackorderStatus = "waiting"
+	// BackorderAllocated has had every copy allocated.
+	BackorderAllocated BackorderStatus = "allocated"
+	// BackorderCancelled was withdrawn; copies already allocated stay reserved.
+	BackorderCancelled BackorderStatus = "cancelled"
+)
+
+// Backorder is a customer's place in the queue for copies of a book at a
+// location. Incoming copies are allocated to waiting backorders first come,
+// first served; each allocation becomes a confirmed reservation for the
+// customer.
+type Backorder struct {
+	id           string
+	isbn         ISBN
+	location     string
+	customer     string
+	kind         BackorderKind
+	quantity     int
+	allocated    int
+	reservations []string
+	status       BackorderStatus
+	createdAt    time.Time
+	releaseAt    time.Time // publication date of a pre-ordered book
+}
+
+func (b Backorder) ID() string              { return b.id }
+func (b Backorder) ISBN() ISBN              { return b.isbn }
+func (b Backorder) Location() string        { return b.location }
+func (b Backorder) Customer() string        { return b.customer }
+func (b Backorder) Kind() BackorderKind     { return b.kind }
+func (b Backorder) Quantity() int           { return b.quantity }
+func (b Backorder) Allocated() int          { return b.allocated }
+func (b Backorder) Status() BackorderStatus { return b.status }
+func (b Backorder) CreatedAt() time.Time    { return b.createdAt }
+func (b Backorder) ReleaseAt() time.Time    { return b.releaseAt }
+
+// Reservations returns the IDs of the reservations made by allocation.
+func (b Backorder) Reservations() []string {
+	return append([]string(nil), b.reservations...)
+}
+
+// Outstanding returns the copies still to allocate.
+func (b Backorder) Outstanding() int {
+	if b.status == BackorderCancelled {
+		return 0
+	}
+	return b.quantity - b.allocated
+}
+
+// Allocation announces copies allocated to a backorder.
+type Allocation struct {
+	Backorder   Backorder
+	Reservation Reservation
+	At          time.Time
+}
+
+// AllocationMessage describes an allocation for the customer.
+func AllocationMessage(a Allocation) string {
+	what := "back-ordered"
+	if a.Backorder.kind == KindPreorder {
+		what = "pre-ordered"
+	}
+	copies := "copies are"
+	if a.Reservation.quantity == 1 {
+		copies = "copy is"
+	}
+	msg := fmt.Sprintf("%d %s %s of %s reserved for you (reservation %s)", a.Reservation.quantity, what, copies, a.Backorder.isbn, a.Reservation.id)
+	if n := a.Backorder.Outstanding(); n > 0 {
+		msg += fmt.Sprintf("; %d more to follow", n)
+	}
+	return msg
+}
+
+// OnAllocation registers fn to be told about every allocation, e.g. to let
+// the customer know. It is called after the inventory's lock is released,
+// so it may call back into the inventory.
+func (inv *Inventory) OnAllocation(fn func(Allocation)) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	inv.notify = fn
+}
+
+// PlaceBackorder queues a customer for new copies of a book at a location. A
+// book that is not yet released is pre-ordered. Copies already available are
+// allocated at once, so the returned backorder may be partly or wholly
+// allocated. The book gets an empty entry at the location if it has none,
+// opened on the ledger like any other.
+func (inv *Inventory) PlaceBackorder(book Book, location string, quantity int, customer string, now time.Time) (Backorder, error) {
+	customer = strings.TrimSpace(customer)
+	if customer == "" {
+		return Backorder{}, errors.New("backorder customer must not be empty")
+	}
+	if quantity <= 0 {
+		return Backorder{}, errors.New("backorder quantity must be positive")
+	}
+	inv.mu.Lock()
+	defer inv.unlock()
+	location = locationOr(location)
+	if _, ok := inv.locations[location]; !ok {
+		return Backorder{}, fmt.Errorf("location %s not found", location)
+	}
+	key := stockKey(book.ISBN(), location)
+	if _, ok := inv.entries[key]; !ok {
+		if _, err := inv.openLocked(StockEntry{book: book, location: location}, MovementInfo{Actor: customer}); err != nil {
+			return Backorder{}, err
+		}
+	}
+
+	bo := Backorder{
+		id:        NewID("bo"),
+		isbn:      book.ISBN(),
+		location:  location,
+		customer:  customer,
+		kind:      KindBackorder,
+		quantity:  quantity,
+		status:    BackorderWaiting,
+		createdAt: now,
+	}
+	if !book.IsReleased(now) {
+		bo.kind = KindPreorder
+		bo.releaseAt = book.published.t
+	}
+	inv.backorders[bo.id] = bo
+	inv.queue = append(inv.queue, bo.id)
+	inv.allocateLocked(key)
+	return inv.backorders[bo.id].clone(), nil
+}
+
+// FindBackorder returns the backorder with the given ID.
+func (inv *Inventory) FindBackorder(id string) (Backorder, error) {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	bo, ok := inv.backorders[id]
+	if !ok {
+		return Backorder{}, fmt.Errorf("backorder %s not found", id)
+	}
+	return bo.clone(), nil
+}
+
+// Backorders returns every backorder, oldest first.
+func (inv *Inventory) Backorders() []Backorder {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	result := make([]Backorder, 0, len(inv.backorders))
+	for _, bo := range inv.backorders {
+		result = append(result, bo.clone())
+	}
+	slices.SortFunc(result, func(a, b Backorder) int {
+		if c := a.createdAt.Compare(b.createdAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.id, b.id)
+	})
+	return result
+}
+
+// CancelBackorder takes a waiting backorder out of the queue. Copies already
+// allocated stay reserved for the customer until their reservations are
+// released or fulfilled.
+func (inv *Inventory) CancelBackorder(id string) (Backorder, error) {
+	inv.mu.Lock()
+	defer inv.unlock()
+	bo, ok := inv.backorders[id]
+	if !ok {
+		return Backorder{}, fmt.Errorf("backorder %s not found", id)
+	}
+	if bo.status != BackorderWaiting {
+		return Backorder{}, fmt.Errorf("backorder %s is %s", id, bo.status)
+	}
+	bo.status = BackorderCancelled
+	inv.backorders[id] = bo
+	inv.queue = slices.DeleteFunc(inv.queue, func(q string) bool { return q == id })
+	return bo.clone(), nil
+}
+
+// AllocateBackorders allocates available stock to every waiting backorder
+// that can take it, e.g. pre-orders whose book has just been released.
+// Stock arriving through the inventory is allocated without calling this.
+func (inv *Inventory) AllocateBackorders() int {
+	inv.mu.Lock()
+	defer inv.unlock()
+	before := len(inv.outbox)
+	keys := make(map[string]bool)
+	for _, id := range inv.queue {
+		bo := inv.backorders[id]
+		keys[stockKey(bo.isbn, bo.location)] = true
+	}
+	for key := range keys {
+		inv.allocateLocked(key)
+	}
+	return len(inv.outbox) - before
+}
+
+// allocateLocked hands the available copies at one entry to its waiting
+// backorders, oldest first, as confirmed reservations. Pre-orders wait until
+// their release date. The caller must hold the write lock.
+func (inv *Inventory) allocateLocked(key string) {
+	now := inv.clock.Now()
+	for _, id := range slices.Clone(inv.queue) {
+		bo := inv.backorders[id]
+		if stockKey(bo.isbn, bo.location) != key || now.Before(bo.releaseAt) {
+			continue
+		}
+		available := inv.entries[key].unserialized()
+		if available == 0 {
+			return
+		}
+		n := min(available, bo.Outstanding())
+		res := Reservation{
+			id:        NewID("res"),
+			isbn:      bo.isbn,
+			location:  bo.location,
+			quantity:  n,
+			holder:    bo.customer,
+			status:    ReservationConfirmed,
+			createdAt: now,
+		}
+		info := MovementInfo{Reason: ReasonBackorder, Actor: bo.customer, Reference: res.id}
+		m := newMovement(bo.isbn, bo.location, MovementReserve, n, info, now)
+		m.held = res.id
+		if _, err := inv.commitLocked([]Movement{m}); err != nil {
+			return
+		}
+		inv.reservations[res.id] = res
+		bo.allocated += n
+		bo.reservations = append(slices.Clone(bo.reservations), res.id)
+		if bo.Outstanding() == 0 {
+			bo.status = BackorderAllocated
+			inv.queue = slices.DeleteFunc(inv.queue, func(q string) bool { return q == id })
+		}
+		inv.backorders[id] = bo
+		inv.outbox = append(inv.outbox, Allocation{Backorder: bo.clone(), Reservation: res, At: now})
+	}
+}
+
+// unlock releases the write lock and then announces queued stock changes
+// and allocations, so that listeners may call back into the inventory.
+func (inv *Inventory) unlock() {
+	changes, watch := inv.changes, inv.watch
+	notices, notify := inv.outbox, inv.notify
+	inv.changes, inv.outbox = nil, nil
+	inv.mu.Unlock()
+	if watch != nil {
+		for _, c := range changes {
+			watch(c)
+		}
+	}
+	if notify == nil {
+		return
+	}
+	for _, a := range notices {
+		notify(a)
+	}
+}
+
+func (b Backorder) clone() Backorder {
+	b.reservations = b.Reservations()
+	return b
+}
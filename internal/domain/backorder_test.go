@@ -0,0 +1,148 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInventory_RestockAllocatesBackordersFIFO(t *testing.T) {
+	clock := NewFakeClock(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	inv := NewInventory(clock)
+	var notices []Allocation
+	inv.OnAllocation(func(a Allocation) { notices = append(notices, a) })
+	book := testStockBook(t, "9780306406157", 1000, "EUR")
+	entry, _ := NewStockEntry(book, 0)
+	if err := inv.Add(entry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	first, _ := inv.PlaceBackorder(book, "", 2, "alice", clock.Now())
+	clock.Advance(time.Minute)
+	second, _ := inv.PlaceBackorder(book, "", 2, "bob", clock.Now())
+	if first.Kind() != KindBackorder || first.Allocated() != 0 {
+		t.Fatalf("got kind %s with %d allocated, want a waiting backorder", first.Kind(), first.Allocated())
+	}
+
+	if _, err := inv.Restock(book.ISBN(), "", 3, MovementInfo{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	first, _ = inv.FindBackorder(first.ID())
+	second, _ = inv.FindBackorder(second.ID())
+	if first.Status() != BackorderAllocated || second.Allocated() != 1 || second.Status() != BackorderWaiting {
+		t.Errorf("got first %s, second %d allocated (%s); want first allocated, second 1 waiting", first.Status(), second.Allocated(), second.Status())
+	}
+	if len(notices) != 2 || notices[0].Backorder.Customer() != "alice" || notices[1].Reservation.Quantity() != 1 {
+		t.Errorf("unexpected notices: %+v", notices)
+	}
+	res, err := inv.FindReservation(first.Reservations()[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Holder() != "alice" || res.Status() != ReservationConfirmed {
+		t.Errorf("got reservation for %s (%s), want alice confirmed", res.Holder(), res.Status())
+	}
+	if e, _ := inv.Find(book.ISBN()); e.Available() != 0 {
+		t.Errorf("got %d available, want every copy allocated", e.Available())
+	}
+	if !inv.Reconcile().Balanced() {
+		t.Errorf("ledger does not balance: %+v", inv.Reconcile().Discrepancies)
+	}
+}
+
+func TestInventory_ReleasedCopiesGoToBackorders(t *testing.T) {
+	clock := NewFakeClock(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	inv := NewInventory(clock)
+	book := testStockBook(t, "9780306406157", 1000, "EUR")
+	entry, _ := NewStockEntry(book, 1)
+	if err := inv.Add(entry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	held, err := inv.Hold(book.ISBN(), "", 1, "cart-1", clock.Now(), time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	bo, _ := inv.PlaceBackorder(book, "", 1, "alice", clock.Now())
+
+	clock.Advance(2 * time.Minute)
+	inv.ExpireReservations(clock.Now())
+	if _, err := inv.FindReservation(held.ID()); err == nil {
+		t.Error("expected the cart's reservation to expire")
+	}
+	if bo, _ = inv.FindBackorder(bo.ID()); bo.Status() != BackorderAllocated {
+		t.Errorf("got status %s, want the expired copy allocated", bo.Status())
+	}
+}
+
+func TestInventory_PreorderWaitsForRelease(t *testing.T) {
+	clock := NewFakeClock(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	inv := NewInventory(clock)
+	isbn, _ := NewISBN("9780306406157")
+	author, _ := NewAuthor("Jane", "Doe")
+	price, _ := NewMoney(1000, "EUR")
+	release := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	book, _ := NewBook(isbn, "Forthcoming", author, price, release, GenreFiction)
+
+	bo, err := inv.PlaceBackorder(book, "", 1, "alice", clock.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bo.Kind() != KindPreorder || !bo.ReleaseAt().Equal(release) {
+		t.Errorf("got kind %s releasing %v, want a pre-order for %v", bo.Kind(), bo.ReleaseAt(), release)
+	}
+	inv.Restock(isbn, "", 5, MovementInfo{})
+	if bo, _ = inv.FindBackorder(bo.ID()); bo.Allocated() != 0 {
+		t.Error("pre-order was allocated before release")
+	}
+	if _, err := inv.Hold(isbn, "", 1, "cart-1", clock.Now(), time.Minute); err == nil {
+		t.Error("expected an unreleased book not to be sold")
+	}
+
+	clock.Set(release)
+	if n := inv.AllocateBackorders(); n != 1 {
+		t.Errorf("got %d allocations on release day, want 1", n)
+	}
+	if bo, _ = inv.FindBackorder(bo.ID()); bo.Status() != BackorderAllocated {
+		t.Errorf("got status %s, want allocated", bo.Status())
+	}
+}
+
+func TestInventory_CancelBackorder(t *testing.T) {
+	inv := NewInventory(nil)
+	book := testStockBook(t, "9780306406157", 1000, "EUR")
+	bo, _ := inv.PlaceBackorder(book, "", 1, "alice", time.Now())
+	if _, err := inv.CancelBackorder(bo.ID()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	inv.Restock(book.ISBN(), "", 1, MovementInfo{})
+	if e, _ := inv.Find(book.ISBN()); e.Available() != 1 {
+		t.Errorf("got %d available, want the copy left unallocated", e.Available())
+	}
+	if _, err := inv.CancelBackorder(bo.ID()); err == nil {
+		t.Error("expected error cancelling twice")
+	}
+	if _, err := inv.PlaceBackorder(book, "", 0, "alice", time.Now()); err == nil {
+		t.Error("expected error for zero quantity")
+	}
+}
+
+func TestInventory_BackorderOpensUnstockedBookOnTheLedger(t *testing.T) {
+	clock := NewFakeClock(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	inv := NewInventory(clock)
+	var changes []StockChange
+	inv.OnStockChange(func(c StockChange) { changes = append(changes, c) })
+	book := testStockBook(t, "9780306406157", 1000, "EUR")
+
+	if _, err := inv.PlaceBackorder(book, "", 2, "alice", clock.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ms := inv.Movements(book.ISBN())
+	if len(ms) != 1 || ms[0].Type() != MovementOpening || ms[0].Quantity() != 0 {
+		t.Fatalf("got movements %+v, want a single empty opening", ms)
+	}
+	if len(changes) != 1 {
+		t.Errorf("got %d stock changes, want 1", len(changes))
+	}
+	if !inv.Reconcile().Balanced() {
+		t.Errorf("ledger does not balance: %+v", inv.Reconcile().Discrepancies)
+	}
+}
@@ -3,16 +3,19 @@ package domain
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 )
 
 type Book struct {
-	isbn        ISBN
-	title       string
-	author      Author
-	price       Money
-	publishedAt time.Time
-	genre       Genre
+	isbn      ISBN
+	title     string
+	author    Author
+	price     Money
+	published PublicationDate
+	genre     Genre
+	format    Format
+	publisher string
 }
 
 type Genre string
@@ -25,6 +28,14 @@ const (
 	GenreChildren   Genre = "children"
 )
 
+// Format is the physical or digital form a book is sold in.
+type Format string
+
+const (
+	FormatPrint   Format = "print"
+	FormatDigital Format = "digital"
+)
+
 func NewBook(isbn ISBN, title string, author Author, price Money, publishedAt time.Time, genre Genre) (Book, error) {
 	if title == "" {
 		return Book{}, errors.New("title must not be empty")
@@ -36,30 +47,79 @@ func NewBook(isbn ISBN, title string, author Author, price Money, publishedAt ti
 		return Book{}, errors.New("price must not be negative")
 	}
 	return Book{
-		isbn:        isbn,
-		title:       title,
-		author:      author,
-		price:       price,
-		publishedAt: publishedAt,
-		genre:       genre,
+		isbn:      isbn,
+		title:     title,
+		author:    author,
+		price:     price,
+		published: PublicationDate{t: publishedAt, precision: PrecisionDay},
+		genre:     genre,
+		format:    FormatPrint,
 	}, nil
 }
 
-func (b Book) ISBN() ISBN       { return b.isbn }
-func (b Book) Title() string    { return b.title }
-func (b Book) Author() Author   { return b.author }
-func (b Book) Price() Money     { return b.price }
-func (b Book) Genre() Genre     { return b.genre }
-func (b Book) PublishedAt() time.Time { return b.publishedAt }
+func (b Book) ISBN() ISBN             { return b.isbn }
+func (b Book) Title() string          { return b.title }
+func (b Book) Author() Author         { return b.author }
+func (b Book) Price() Money           { return b.price }
+func (b Book) Genre() Genre           { return b.genre }
+func (b Book) PublishedAt() time.Time { return b.published.t }
+func (b Book) Format() Format         { return b.format }
+func (b Book) Publisher() string      { return b.publisher }
+
+// WithFormat returns a copy of the book sold in the given format.
+// Books are print unless stated otherwise.
+func (b Book) WithFormat(f Format) (Book, error) {
+	if f != FormatPrint && f != FormatDigital {
+		return Book{}, fmt.Errorf("unknown format: %s", f)
+	}
+	b.format = f
+	return b, nil
+}
+
+// WithPrice returns a copy of the book at a new price in the same currency.
+func (b Book) WithPrice(price Money) (Book, error) {
+	if price.Amount() < 0 {
+		return Book{}, errors.New("price must not be negative")
+	}
+	if price.Currency() != b.price.Currency() {
+		return Book{}, fmt.Errorf("price must be in %s", b.price.Currency())
+	}
+	b.price = price
+	return b, nil
+}
+
+// WithPublisher returns a copy of the book attributed to the given publisher.
+func (b Book) WithPublisher(name string) Book {
+	b.publisher = strings.TrimSpace(name)
+	return b
+}
+
+// Publication returns the publication date together with its precision.
+func (b Book) Publication() PublicationDate { return b.published }
+
+// IsReleased reports whether the book has been published by now. Unreleased
+// books can be pre-ordered but not sold.
+func (b Book) IsReleased(now time.Time) bool {
+	return !now.Before(b.published.t)
+}
+
+// WithPublication returns a copy of the book with a possibly partial
+// publication date, e.g. only the year for older editions.
+func (b Book) WithPublication(d PublicationDate) Book {
+	b.published = d
+	return b
+}
 
-// IsClassic returns true if the book was published more than 50 years ago.
-func (b Book) IsClassic() bool {
-	return time.Since(b.publishedAt) > 50*365*24*time.Hour
+// IsClassic reports whether the book is a classic under DefaultAgePolicy:
+// at least 50 calendar years old, or 25 for children's books.
+func (b Book) IsClassic(now time.Time) bool {
+	return DefaultAgePolicy.IsClassic(b, now)
 }
 
-// IsRecent returns true if the book was published within the last year.
-func (b Book) IsRecent() bool {
-	return time.Since(b.publishedAt) < 365*24*time.Hour
+// IsRecent reports whether the book was published within the calendar year
+// before now under DefaultAgePolicy.
+func (b Book) IsRecent(now time.Time) bool {
+	return DefaultAgePolicy.IsRecent(b, now)
 }
 
 func isValidGenre(g Genre) bool {
@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// CartLine is a quantity of a single book in a cart.
+type CartLine struct {
+	isbn     ISBN
+	quantity int
+}
+
+func (l CartLine) ISBN() ISBN    { return l.isbn }
+func (l CartLine) Quantity() int { return l.quantity }
+
+// Cart holds the books a customer intends to buy. Carts expire after a
+// period of inactivity.
+type Cart struct {
+	id         string
+	customerID string // "" for anonymous carts
+	currency   string
+	country    string // destination for tax purposes; "" means the shop's home country
+	region     string
+	lines      []CartLine
+	promotions []string // promotion codes, in the order they were applied
+	createdAt  time.Time
+	expiresAt  time.Time
+}
+
+func NewCart(id, currency string, createdAt time.Time, ttl time.Duration) (Cart, error) {
+	if id == "" {
+		return Cart{}, errors.New("cart id must not be empty")
+	}
+	if len(currency) != 3 {
+		return Cart{}, fmt.Errorf("currency must be a 3-letter ISO code, got %q", currency)
+	}
+	if ttl <= 0 {
+		return Cart{}, errors.New("cart ttl must be positive")
+	}
+	return Cart{
+		id:        id,
+		currency:  currency,
+		createdAt: createdAt,
+		expiresAt: createdAt.Add(ttl),
+	}, nil
+}
+
+func (c Cart) ID() string           { return c.id }
+func (c Cart) Currency() string     { return c.currency }
+func (c Cart) CustomerID() string   { return c.customerID }
+func (c Cart) CreatedAt() time.Time { return c.createdAt }
+func (c Cart) ExpiresAt() time.Time { return c.expiresAt }
+
+// Lines returns a copy of the cart's lines in the order they were added.
+func (c Cart) Lines() []CartLine {
+	return append([]CartLine(nil), c.lines...)
+}
+
+// PromotionCodes returns the promotion codes applied to the cart.
+func (c Cart) PromotionCodes() []string {
+	return append([]string(nil), c.promotions...)
+}
+
+// Clone returns a deep copy, so that changing it leaves carts already handed
+// out untouched.
+func (c Cart) Clone() Cart {
+	c.lines = c.Lines()
+	c.promotions = c.PromotionCodes()
+	return c
+}
+
+// IsExpired returns true if the cart has expired at the given time.
+func (c Cart) IsExpired(now time.Time) bool {
+	return !now.Before(c.expiresAt)
+}
+
+// AddLine adds quantity copies of a book, merging with an existing line for the same ISBN.
+func (c *Cart) AddLine(isbn ISBN, quantity int) error {
+	if quantity <= 0 {
+		return errors.New("line quantity must be positive")
+	}
+	for i, l := range c.lines {
+		if l.isbn == isbn {
+			c.lines[i].quantity += quantity
+			return nil
+		}
+	}
+	c.lines = append(c.lines, CartLine{isbn: isbn, quantity: quantity})
+	return nil
+}
+
+// Destination returns the country and region the cart ships to.
+func (c Cart) Destination() (country, region string) { return c.country, c.region }
+
+// ShipTo sets the destination used to determine tax rates.
+func (c *Cart) ShipTo(country, region string) error {
+	if country != "" && len(country) != 2 {
+		return fmt.Errorf("country must be a 2-letter ISO code, got %q", country)
+	}
+	if country == "" && region != "" {
+		return errors.New("region requires a country")
+	}
+	c.country = strings.ToUpper(country)
+	c.region = strings.ToUpper(region)
+	return nil
+}
+
+// AssignCustomer ties the cart to a customer account, whose price list is used for quotes.
+func (c *Cart) AssignCustomer(customerID string) {
+	c.customerID = customerID
+}
+
+// ApplyPromotionCode adds a promotion code to the cart. Applying the same code twice is a no-op.
+func (c *Cart) ApplyPromotionCode(code string) {
+	code = NormalizePromotionCode(code)
+	if !slices.Contains(c.promotions, code) {
+		c.promotions = append(c.promotions, code)
+	}
+}
+
+// RemovePromotionCode removes a previously applied promotion code.
+func (c *Cart) RemovePromotionCode(code string) error {
+	code = NormalizePromotionCode(code)
+	i := slices.Index(c.promotions, code)
+	if i < 0 {
+		return fmt.Errorf("promotion %s is not applied to cart %s", code, c.id)
+	}
+	c.promotions = slices.Delete(c.promotions, i, i+1)
+	return nil
+}
+
+// Touch extends the cart's expiry to ttl after now.
+func (c *Cart) Touch(now time.Time, ttl time.Duration) {
+	c.expiresAt = now.Add(ttl)
+}
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClock_SetAndAdvance(t *testing.T) {
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	if !clock.Now().Equal(start) {
+		t.Fatalf("expected %v, got %v", start, clock.Now())
+	}
+
+	clock.Advance(time.Hour)
+	if want := start.Add(time.Hour); !clock.Now().Equal(want) {
+		t.Errorf("expected %v after advance, got %v", want, clock.Now())
+	}
+
+	later := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock.Set(later)
+	if !clock.Now().Equal(later) {
+		t.Errorf("expected %v after set, got %v", later, clock.Now())
+	}
+}
+
+func TestBook_IsClassicFollowsClock(t *testing.T) {
+	isbn, _ := NewISBN("9780306406157")
+	author, _ := NewAuthor("Jane", "Doe")
+	price, _ := NewMoney(1000, "EUR")
+	book, err := NewBook(isbn, "Old Book", author, price, time.Date(1975, 3, 1, 0, 0, 0, 0, time.UTC), GenreFiction)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clock := NewFakeClock(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	if book.IsClassic(clock.Now()) {
+		t.Errorf("expected not a classic in 2024")
+	}
+	clock.Advance(2 * 365 * 24 * time.Hour)
+	if !book.IsClassic(clock.Now()) {
+		t.Errorf("expected a classic in 2026")
+	}
+}
@@ -0,0 +1,203 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Condition grades the copies of a book. New copies are sold at the book's
+// price; every used grade is a separate stock variant with its own price.
+type Condition string
+
+const (
+	ConditionNew        Condition = "new"
+	ConditionLikeNew    Condition = "like_new"
+	ConditionVeryGood   Condition = "very_good"
+	ConditionGood       Condition = "good"
+	ConditionAcceptable Condition = "acceptable"
+)
+
+// conditions lists the grades from best to worst.
+var conditions = []Condition{ConditionNew, ConditionLikeNew, ConditionVeryGood, ConditionGood, ConditionAcceptable}
+
+// ParseCondition returns the condition with the given name. An empty name
+// means new.
+func ParseCondition(s string) (Condition, error) {
+	c := Condition(strings.ToLower(strings.TrimSpace(s)))
+	if c == "" {
+		return ConditionNew, nil
+	}
+	if !slices.Contains(conditions, c) {
+		return "", fmt.Errorf("unknown condition %q: want new, like_new, very_good, good or acceptable", s)
+	}
+	return c, nil
+}
+
+// IsUsed reports whether the condition is a used grade.
+func (c Condition) IsUsed() bool {
+	return c != "" && c != ConditionNew
+}
+
+func (c Condition) orNew() Condition {
+	if c == "" {
+		return ConditionNew
+	}
+	return c
+}
+
+// variantKey identifies the stock of a book in one condition at one
+// location. New copies keep the plain stockKey.
+func variantKey(isbn ISBN, location string, condition Condition) string {
+	if !condition.IsUsed() {
+		return stockKey(isbn, location)
+	}
+	return stockKey(isbn, location) + "#" + string(condition)
+}
+
+// NewUsedStockEntry creates an entry for used copies of a book in the given
+// condition, sold at their own price rather than the book's.
+func NewUsedStockEntry(book Book, condition Condition, price Money, total int) (StockEntry, error) {
+	if !condition.IsUsed() {
+		return StockEntry{}, errors.New("used stock needs a used condition")
+	}
+	if err := checkVariantPrice(book, price); err != nil {
+		return StockEntry{}, err
+	}
+	entry, err := NewStockEntry(book, total)
+	if err != nil {
+		return StockEntry{}, err
+	}
+	entry.condition, entry.price = condition, price
+	return entry, nil
+}
+
+func checkVariantPrice(book Book, price Money) error {
+	if price.Currency() != book.Price().Currency() {
+		return fmt.Errorf("price is in %s, book is priced in %s", price.Currency(), book.Price().Currency())
+	}
+	if price.Amount() < 0 {
+		return errors.New("price must not be negative")
+	}
+	return nil
+}
+
+// Condition returns the grade of the entry's copies.
+func (s StockEntry) Condition() Condition { return s.condition.orNew() }
+
+// Price returns what one of the entry's copies sells for: the book's price
+// for new copies, the variant's own price for used ones.
+func (s StockEntry) Price() Money {
+	if s.condition.IsUsed() {
+		return s.price
+	}
+	return s.book.Price()
+}
+
+// Offer returns the book as sold from this entry, priced at the entry's
+// price, so that order totals and surcharges apply to the chosen variant.
+func (s StockEntry) Offer() Book {
+	b := s.book
+	b.price = s.Price()
+	return b
+}
+
+func (s StockEntry) key() string {
+	return variantKey(s.book.ISBN(), s.location, s.condition)
+}
+
+// Variants returns the book's stock in each condition held, summed across
+// locations and ordered from the best condition to the worst.
+func (inv *Inventory) Variants(isbn ISBN) []StockEntry {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	byCondition := make(map[Condition][]StockEntry)
+	for _, e := range inv.entries {
+		if e.book.ISBN() == isbn {
+			byCondition[e.Condition()] = append(byCondition[e.Condition()], e)
+		}
+	}
+	var result []StockEntry
+	for _, c := range conditions {
+		if entries, ok := byCondition[c]; ok {
+			sum := aggregate(entries)
+			sum.condition, sum.price = entries[0].condition, entries[0].price
+			result = append(result, sum)
+		}
+	}
+	return result
+}
+
+// FindVariant returns a copy of the book's stock entry in one condition at
+// one location.
+func (inv *Inventory) FindVariant(isbn ISBN, location string, condition Condition) (StockEntry, error) {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	location = locationOr(location)
+	e, ok := inv.entries[variantKey(isbn, location, condition)]
+	if !ok {
+		return StockEntry{}, fmt.Errorf("book %s not found in inventory in %s condition at %s", isbn, condition.orNew(), location)
+	}
+	return e.clone(), nil
+}
+
+// RestockVariant adds n copies of the book in the given condition at a
+// location.
+func (inv *Inventory) RestockVariant(isbn ISBN, location string, condition Condition, n int, info MovementInfo) (StockEntry, error) {
+	inv.mu.Lock()
+	defer inv.unlock()
+	location = locationOr(location)
+	m := newMovement(isbn, location, MovementRestock, n, info, inv.clock.Now())
+	if condition.IsUsed() {
+		m.condition = condition
+	}
+	if _, err := inv.commitLocked([]Movement{m}); err != nil {
+		return StockEntry{}, err
+	}
+	return inv.entries[m.key()].clone(), nil
+}
+
+// SetVariantPrice reprices the book's used copies in one condition at every
+// location. New copies follow the catalogue price instead.
+func (inv *Inventory) SetVariantPrice(isbn ISBN, condition Condition, price Money) ([]StockEntry, error) {
+	if !condition.IsUsed() {
+		return nil, errors.New("new copies are priced by the catalogue")
+	}
+	inv.mu.Lock()
+	defer inv.unlock()
+	var keys []string
+	for key, e := range inv.entries {
+		if e.book.ISBN() != isbn || e.condition != condition {
+			continue
+		}
+		if err := checkVariantPrice(e.book, price); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("book %s not found in inventory in %s condition", isbn, condition)
+	}
+	slices.Sort(keys)
+	result := make([]StockEntry, 0, len(keys))
+	for _, key := range keys {
+		e := inv.entries[key]
+		e.price = price
+		inv.entries[key] = e
+		result = append(result, e.clone())
+	}
+	return result, nil
+}
+
+// variantPriceLocked returns the price the book's copies in a used
+// condition already sell for, if any are stocked. The caller must hold the
+// lock.
+func (inv *Inventory) variantPriceLocked(isbn ISBN, condition Condition) (Money, bool) {
+	for _, e := range inv.entries {
+		if e.book.ISBN() == isbn && e.condition == condition {
+			return e.price, true
+		}
+	}
+	return Money{}, false
+}
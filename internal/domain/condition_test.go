@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func testUsedInventory(t *testing.T) (*Inventory, Book) {
+	t.Helper()
+	inv := NewInventory(nil)
+	book := testStockBook(t, "9780306406157", 1000, "EUR")
+	entry, _ := NewStockEntry(book, 10)
+	if err := inv.Add(entry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	price, _ := NewMoney(400, "EUR")
+	used, err := NewUsedStockEntry(book, ConditionGood, price, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := inv.Create(used, MovementInfo{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return inv, book
+}
+
+func TestInventory_VariantsHaveTheirOwnStockAndPrice(t *testing.T) {
+	inv, book := testUsedInventory(t)
+	variants := inv.Variants(book.ISBN())
+	if len(variants) != 2 || variants[0].Condition() != ConditionNew || variants[1].Condition() != ConditionGood {
+		t.Fatalf("got %+v, want new then good", variants)
+	}
+	if variants[0].Price().Amount() != 1000 || variants[1].Price().Amount() != 400 || variants[1].Offer().Price().Amount() != 400 {
+		t.Errorf("got prices %s and %s, want 10.00 and 4.00", variants[0].Price().Display(), variants[1].Price().Display())
+	}
+
+	res, err := inv.HoldMany([]StockRequest{{ISBN: book.ISBN(), Condition: ConditionGood, Quantity: 3}}, "alice", time.Now(), time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := inv.Hold(book.ISBN(), "", 1, "bob", time.Now(), time.Hour); err != nil {
+		t.Errorf("new copies should stay available: %v", err)
+	}
+	if _, err := inv.HoldMany([]StockRequest{{ISBN: book.ISBN(), Condition: ConditionGood, Quantity: 1}}, "bob", time.Now(), time.Hour); err == nil {
+		t.Error("expected error when the good copies are all held")
+	}
+	if _, err := inv.FulfilReservation(res[0].ID(), MovementInfo{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, _ := inv.Find(book.ISBN()); e.Total() != 10 || e.Reserved() != 1 {
+		t.Errorf("got %d total, %d reserved new copies; want 10 and 1", e.Total(), e.Reserved())
+	}
+	if e, _ := inv.FindVariant(book.ISBN(), "", ConditionGood); e.Total() != 0 {
+		t.Errorf("got %d good copies, want 0", e.Total())
+	}
+	if ms := inv.Movements(book.ISBN()); ms[len(ms)-1].Condition() != ConditionGood {
+		t.Errorf("got sale of %s copies, want good", ms[len(ms)-1].Condition())
+	}
+	if !inv.Reconcile().Balanced() {
+		t.Errorf("ledger does not balance: %+v", inv.Reconcile().Discrepancies)
+	}
+}
+
+func TestInventory_VariantPricing(t *testing.T) {
+	inv, book := testUsedInventory(t)
+	shop, _ := NewLocation("shop", "Shop", LocationStore)
+	inv.AddLocation(shop)
+	other, _ := NewMoney(500, "EUR")
+	used, _ := NewUsedStockEntry(book, ConditionGood, other, 1)
+	if err := inv.Create(used.WithLocation("shop", ""), MovementInfo{}); err == nil {
+		t.Error("expected error stocking good copies at a second price")
+	}
+	if _, err := inv.SetVariantPrice(book.ISBN(), ConditionNew, other); err == nil {
+		t.Error("expected error repricing new copies")
+	}
+	if _, err := inv.SetVariantPrice(book.ISBN(), ConditionGood, other); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := inv.ValueByCurrency("")["EUR"]; got.Amount() != 10*1000+3*500 {
+		t.Errorf("got total value %d, want %d", got.Amount(), 10*1000+3*500)
+	}
+	if _, err := inv.RestockVariant(book.ISBN(), "", ConditionAcceptable, 1, MovementInfo{}); err == nil {
+		t.Error("expected error restocking a condition that is not stocked")
+	}
+	if _, err := NewUsedStockEntry(book, ConditionNew, other, 1); err == nil {
+		t.Error("expected error for used stock in new condition")
+	}
+	if _, err := ParseCondition("mint"); err == nil {
+		t.Error("expected error for an unknown condition")
+	}
+}
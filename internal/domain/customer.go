@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CustomerKind groups customers that typically negotiate their own prices.
+type CustomerKind string
+
+const (
+	CustomerRetail   CustomerKind = "retail"
+	CustomerSchool   CustomerKind = "school"
+	CustomerLibrary  CustomerKind = "library"
+	CustomerReseller CustomerKind = "reseller"
+)
+
+// Customer is an account that may be assigned a named price list.
+type Customer struct {
+	id        string
+	name      string
+	kind      CustomerKind
+	priceList string
+}
+
+func NewCustomer(id, name string, kind CustomerKind, priceList string) (Customer, error) {
+	name = strings.TrimSpace(name)
+	if id == "" {
+		return Customer{}, errors.New("customer id must not be empty")
+	}
+	if name == "" {
+		return Customer{}, errors.New("customer name must not be empty")
+	}
+	switch kind {
+	case CustomerRetail, CustomerSchool, CustomerLibrary, CustomerReseller:
+	default:
+		return Customer{}, fmt.Errorf("unknown customer kind: %s", kind)
+	}
+	return Customer{id: id, name: name, kind: kind, priceList: priceList}, nil
+}
+
+func (c Customer) ID() string         { return c.id }
+func (c Customer) Name() string       { return c.name }
+func (c Customer) Kind() CustomerKind { return c.kind }
+
+// PriceList returns the name of the customer's price list, or "" for standard pricing.
+func (c Customer) PriceList() string { return c.priceList }
+
+// AssignPriceList switches the customer to the named price list; "" restores standard pricing.
+func (c *Customer) AssignPriceList(name string) {
+	c.priceList = name
+}
@@ -0,0 +1,18 @@
+package domain
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+// NewID returns a random identifier with the given prefix, e.g. "cart_9f86d081884c7d65".
+func NewID(prefix string) string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		// Without randomness IDs could collide; there is nothing safe to
+		// fall back to.
+		panic(fmt.Sprintf("generate %s id: %v", prefix, err))
+	}
+	return prefix + "_" + hex.EncodeToString(b)
+}
@@ -3,37 +3,87 @@ package domain
 import (
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 )
 
-// StockEntry tracks the available and reserved copies for a single book.
+// StockLot is a batch of copies received from a supplier at a known unit cost.
+type StockLot struct {
+	quantity   int
+	unitCost   Money
+	supplier   string
+	receivedAt time.Time
+}
+
+func NewStockLot(quantity int, unitCost Money, supplier string, receivedAt time.Time) (StockLot, error) {
+	if quantity <= 0 {
+		return StockLot{}, errors.New("lot quantity must be positive")
+	}
+	if unitCost.Amount() < 0 {
+		return StockLot{}, errors.New("unit cost must not be negative")
+	}
+	return StockLot{quantity: quantity, unitCost: unitCost, supplier: supplier, receivedAt: receivedAt}, nil
+}
+
+func (l StockLot) Quantity() int         { return l.quantity }
+func (l StockLot) UnitCost() Money       { return l.unitCost }
+func (l StockLot) Supplier() string      { return l.supplier }
+func (l StockLot) ReceivedAt() time.Time { return l.receivedAt }
+
+// StockEntry tracks the available and reserved copies of a single book in
+// one condition at one location, optionally shelved in a named bin. Damaged
+// copies are held apart from total and are never sellable, and copies in
+// transit from another location only count towards total once they arrive.
 type StockEntry struct {
-	book     Book
-	total    int
-	reserved int
+	book      Book
+	location  string
+	bin       string
+	condition Condition // empty for new copies
+	price     Money     // set for used copies only
+	total     int
+	reserved  int
+	damaged   int
+	inTransit int
+	lots      []StockLot
+	copies    []SerialCopy // serialized copies, sold ones included
 }
 
 func NewStockEntry(book Book, total int) (StockEntry, error) {
 	if total < 0 {
 		return StockEntry{}, errors.New("total stock must not be negative")
 	}
-	return StockEntry{book: book, total: total}, nil
+	return StockEntry{book: book, location: DefaultLocation, total: total}, nil
+}
+
+// WithLocation returns a copy of the entry held at the given location and bin.
+func (s StockEntry) WithLocation(location, bin string) StockEntry {
+	s.location = locationOr(location)
+	s.bin = strings.TrimSpace(bin)
+	return s
 }
 
-func (s StockEntry) Book() Book    { return s.book }
-func (s StockEntry) Total() int    { return s.total }
-func (s StockEntry) Reserved() int { return s.reserved }
+func (s StockEntry) Book() Book       { return s.book }
+func (s StockEntry) Location() string { return s.location }
+func (s StockEntry) Bin() string      { return s.bin }
+func (s StockEntry) Total() int       { return s.total }
+func (s StockEntry) Reserved() int    { return s.reserved }
+func (s StockEntry) Damaged() int     { return s.damaged }
+func (s StockEntry) InTransit() int   { return s.inTransit }
 
 func (s StockEntry) Available() int {
 	return s.total - s.reserved
 }
 
 // Reserve attempts to reserve n copies. Returns an error if insufficient stock.
+// Serialized copies are left alone; they are reserved by serial.
 func (s *StockEntry) Reserve(n int) error {
 	if n <= 0 {
 		return errors.New("reservation quantity must be positive")
 	}
-	if s.Available() < n {
-		return fmt.Errorf("insufficient stock: %d available, %d requested", s.Available(), n)
+	if s.unserialized() < n {
+		return fmt.Errorf("insufficient stock: %d available, %d requested", s.unserialized(), n)
 	}
 	s.reserved += n
 	return nil
@@ -44,8 +94,8 @@ func (s *StockEntry) Release(n int) error {
 	if n <= 0 {
 		return errors.New("release quantity must be positive")
 	}
-	if n > s.reserved {
-		return fmt.Errorf("cannot release %d: only %d reserved", n, s.reserved)
+	if held := s.reserved - s.countCopies(CopyReserved); n > held {
+		return fmt.Errorf("cannot release %d: only %d reserved", n, held)
 	}
 	s.reserved -= n
 	return nil
@@ -60,36 +110,455 @@ func (s *StockEntry) Restock(n int) error {
 	return nil
 }
 
+// Fulfil removes n reserved copies from stock once they have been sold and shipped.
+func (s *StockEntry) Fulfil(n int) error {
+	if n <= 0 {
+		return errors.New("fulfilment quantity must be positive")
+	}
+	if held := s.reserved - s.countCopies(CopyReserved); n > held {
+		return fmt.Errorf("cannot fulfil %d: only %d reserved", n, held)
+	}
+	s.reserved -= n
+	s.total -= n
+	return nil
+}
+
+// Return takes n returned copies back into sellable stock.
+func (s *StockEntry) Return(n int) error {
+	if n <= 0 {
+		return errors.New("return quantity must be positive")
+	}
+	s.total += n
+	return nil
+}
+
+// ReturnDamaged takes n returned copies back as damaged, unsellable stock.
+func (s *StockEntry) ReturnDamaged(n int) error {
+	if n <= 0 {
+		return errors.New("return quantity must be positive")
+	}
+	s.damaged += n
+	return nil
+}
+
+// checkInvariants reports a state no sequence of valid changes can reach.
+func (s StockEntry) checkInvariants() error {
+	switch {
+	case s.total < 0 || s.reserved < 0 || s.damaged < 0 || s.inTransit < 0:
+		return fmt.Errorf("negative stock: %d total, %d reserved, %d damaged, %d in transit", s.total, s.reserved, s.damaged, s.inTransit)
+	case s.reserved > s.total:
+		return fmt.Errorf("reserved %d exceeds total %d", s.reserved, s.total)
+	}
+	return nil
+}
+
+// ReceiveLot adds a costed batch of copies to total stock.
+func (s *StockEntry) ReceiveLot(lot StockLot) error {
+	if lot.unitCost.Currency() != s.book.Price().Currency() {
+		return fmt.Errorf("lot cost is in %s, book is priced in %s", lot.unitCost.Currency(), s.book.Price().Currency())
+	}
+	s.total += lot.quantity
+	s.lots = append(s.lots, lot)
+	return nil
+}
+
+// Lots returns the costed batches received for this entry, oldest first.
+func (s StockEntry) Lots() []StockLot {
+	return append([]StockLot(nil), s.lots...)
+}
+
+// AverageUnitCost returns the quantity-weighted unit cost across all lots.
+// Returns false if no costed lots have been received.
+func (s StockEntry) AverageUnitCost() (Money, bool) {
+	var qty, cost int
+	for _, l := range s.lots {
+		qty += l.quantity
+		cost += l.quantity * l.unitCost.Amount()
+	}
+	if qty == 0 {
+		return Money{}, false
+	}
+	return Money{amount: cost / qty, currency: s.book.Price().Currency()}, true
+}
+
 // IsLowStock returns true if available copies are below the threshold.
 func (s StockEntry) IsLowStock(threshold int) bool {
 	return s.Available() < threshold
 }
 
-// Inventory manages stock for multiple books.
+// clone returns a copy that shares no mutable state with s.
+func (s StockEntry) clone() StockEntry {
+	s.lots = s.Lots()
+	s.copies = s.Copies()
+	return s
+}
+
+// StockRequest asks for a number of copies of one book at a location,
+// DefaultLocation if none is named, in a condition, new if none is named.
+type StockRequest struct {
+	ISBN      ISBN
+	Location  string
+	Condition Condition
+	Quantity  int
+}
+
+// Inventory manages stock for multiple books across locations. Every change
+// is recorded as a movement in an append-only ledger, and the entries hold
+// the balances the ledger adds up to. It is safe for concurrent use: changes
+// happen under its lock and only copies are handed out.
 type Inventory struct {
-	entries map[string]*StockEntry // keyed by ISBN string
+	mu           sync.RWMutex
+	clock        Clock
+	locations    map[string]Location
+	entries      map[string]StockEntry  // keyed by variantKey
+	reservations map[string]Reservation // keyed by reservation ID
+	transfers    map[string]Transfer    // keyed by transfer ID
+	backorders   map[string]Backorder   // keyed by backorder ID
+	counts       map[string]StockCount  // keyed by count ID
+	queue        []string               // IDs of waiting backorders, oldest first
+	ledger       []Movement
+	pools        map[variant]*costPool // the running cost of each variant's copies, kept with the ledger
+	costMethod   CostMethod            // how sales are costed
+	notify       func(Allocation)
+	outbox       []Allocation // allocations to announce once the lock is released
+	watch        func(StockChange)
+	changes      []StockChange // changes to announce once the lock is released
+}
+
+// NewInventory creates an empty inventory that timestamps movements with the
+// given clock, or the system clock if nil. It starts with DefaultLocation
+// registered as a warehouse and costs sales first in, first out.
+func NewInventory(clock Clock) *Inventory {
+	if clock == nil {
+		clock = SystemClock{}
+	}
+	main, _ := NewLocation(DefaultLocation, "Main warehouse", LocationWarehouse)
+	return &Inventory{
+		clock:        clock,
+		locations:    map[string]Location{DefaultLocation: main},
+		entries:      make(map[string]StockEntry),
+		reservations: make(map[string]Reservation),
+		transfers:    make(map[string]Transfer),
+		backorders:   make(map[string]Backorder),
+		counts:       make(map[string]StockCount),
+		pools:        make(map[variant]*costPool),
+		costMethod:   CostFIFO,
+	}
+}
+
+// Add stores the entry's stock as opening movements. Like Create, it refuses
+// a book already stocked in the entry's condition at its location: the
+// ledger is append-only, so existing stock is changed through movements.
+func (inv *Inventory) Add(entry StockEntry) error {
+	return inv.Create(entry, MovementInfo{})
+}
+
+// Create starts tracking the entry's stock unless the book is already
+// stocked in the entry's condition at its location. Used copies stocked at
+// several locations must share one price.
+func (inv *Inventory) Create(entry StockEntry, info MovementInfo) error {
+	inv.mu.Lock()
+	defer inv.unlock()
+	entry.location = locationOr(entry.location)
+	if _, ok := inv.entries[entry.key()]; ok {
+		if entry.condition.IsUsed() {
+			return fmt.Errorf("book %s already in inventory in %s condition at %s", entry.book.ISBN(), entry.condition, entry.location)
+		}
+		return fmt.Errorf("book %s already in inventory at %s", entry.book.ISBN(), entry.location)
+	}
+	if price, ok := inv.variantPriceLocked(entry.book.ISBN(), entry.condition); ok && entry.condition.IsUsed() && price != entry.price {
+		return fmt.Errorf("book %s in %s condition already sells for %s", entry.book.ISBN(), entry.condition, price.Display())
+	}
+	_, err := inv.openLocked(entry, info)
+	return err
 }
 
-func NewInventory() *Inventory {
-	return &Inventory{entries: make(map[string]*StockEntry)}
+// openLocked records an entry's copies, reservations and lots as movements
+// against a fresh entry. The caller must hold the write lock.
+func (inv *Inventory) openLocked(entry StockEntry, info MovementInfo) ([]Movement, error) {
+	if _, ok := inv.locations[entry.location]; !ok {
+		return nil, fmt.Errorf("location %s not found", entry.location)
+	}
+	now := inv.clock.Now()
+	isbn := entry.book.ISBN()
+	uncosted := entry.total
+	for _, l := range entry.lots {
+		uncosted -= l.quantity
+	}
+	ms := []Movement{newMovement(isbn, entry.location, MovementOpening, uncosted, info, now)}
+	for _, l := range entry.lots {
+		m := newMovement(isbn, entry.location, MovementReceipt, l.quantity, info, l.receivedAt)
+		m.lot = l
+		ms = append(ms, m)
+	}
+	if entry.reserved > 0 {
+		ms = append(ms, newMovement(isbn, entry.location, MovementReserve, entry.reserved, info, now))
+	}
+	for i := range ms {
+		ms[i].condition = entry.condition
+	}
+	return inv.commitLocked(ms, StockEntry{book: entry.book, location: entry.location, bin: entry.bin, condition: entry.condition, price: entry.price})
 }
 
-func (inv *Inventory) Add(entry StockEntry) {
-	inv.entries[entry.book.ISBN().String()] = &entry
+// Find returns the book's new stock summed across all locations.
+func (inv *Inventory) Find(isbn ISBN) (StockEntry, error) {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	entries := inv.byBookLocked(isbn)
+	if len(entries) == 0 {
+		return StockEntry{}, fmt.Errorf("book %s not found in inventory", isbn)
+	}
+	return aggregate(entries), nil
 }
 
-func (inv *Inventory) Find(isbn ISBN) (*StockEntry, error) {
-	e, ok := inv.entries[isbn.String()]
+// FindAt returns a copy of the book's new stock entry at one location.
+func (inv *Inventory) FindAt(isbn ISBN, location string) (StockEntry, error) {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	location = locationOr(location)
+	e, ok := inv.entries[stockKey(isbn, location)]
 	if !ok {
-		return nil, fmt.Errorf("book %s not found in inventory", isbn)
+		return StockEntry{}, fmt.Errorf("book %s not found in inventory at %s", isbn, location)
 	}
-	return e, nil
+	return e.clone(), nil
+}
+
+// StockByLocation returns the book's new stock entries at every location
+// holding it, ordered by location code.
+func (inv *Inventory) StockByLocation(isbn ISBN) []StockEntry {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	return inv.byBookLocked(isbn)
 }
 
-// LowStockBooks returns all books with available stock below the threshold.
+func (inv *Inventory) byBookLocked(isbn ISBN) []StockEntry {
+	var result []StockEntry
+	for _, e := range inv.entries {
+		if e.book.ISBN() == isbn && !e.condition.IsUsed() {
+			result = append(result, e.clone())
+		}
+	}
+	slices.SortFunc(result, func(a, b StockEntry) int { return strings.Compare(a.location, b.location) })
+	return result
+}
+
+// AssignBin shelves the book's stock at a location in the named bin.
+func (inv *Inventory) AssignBin(isbn ISBN, location, bin string) (StockEntry, error) {
+	inv.mu.Lock()
+	defer inv.unlock()
+	key := stockKey(isbn, locationOr(location))
+	e, ok := inv.entries[key]
+	if !ok {
+		return StockEntry{}, fmt.Errorf("book %s not found in inventory at %s", isbn, locationOr(location))
+	}
+	e.bin = strings.TrimSpace(bin)
+	inv.entries[key] = e
+	return e.clone(), nil
+}
+
+// UpdateBook replaces the book on all of its entries, e.g. after a price
+// change, so new stock is valued and sold at the book's current price. Used
+// copies and serialized copies keep their own prices. Returns the number of
+// entries updated.
+func (inv *Inventory) UpdateBook(book Book) int {
+	inv.mu.Lock()
+	defer inv.unlock()
+	var updated int
+	for key, e := range inv.entries {
+		if e.book.ISBN() != book.ISBN() {
+			continue
+		}
+		e.book = book
+		inv.entries[key] = e
+		updated++
+	}
+	return updated
+}
+
+// Reserve reserves n copies of the book at a location without a reservation
+// record; use Hold to set copies aside for someone.
+func (inv *Inventory) Reserve(isbn ISBN, location string, n int, info MovementInfo) (StockEntry, error) {
+	return inv.record(isbn, location, MovementReserve, n, info)
+}
+
+// Release returns n reserved copies of the book at a location to available stock.
+func (inv *Inventory) Release(isbn ISBN, location string, n int, info MovementInfo) (StockEntry, error) {
+	return inv.record(isbn, location, MovementRelease, n, info)
+}
+
+// Restock adds n copies of the book at a location.
+func (inv *Inventory) Restock(isbn ISBN, location string, n int, info MovementInfo) (StockEntry, error) {
+	return inv.record(isbn, location, MovementRestock, n, info)
+}
+
+// ReturnCondition says where returned copies go.
+type ReturnCondition string
+
+const (
+	ReturnSellable ReturnCondition = "sellable"
+	ReturnDamaged  ReturnCondition = "damaged"
+)
+
+// Return takes n returned copies of the book back into sellable or damaged
+// stock at a location.
+func (inv *Inventory) Return(isbn ISBN, location string, n int, condition ReturnCondition, info MovementInfo) (StockEntry, error) {
+	switch condition {
+	case ReturnSellable:
+		return inv.record(isbn, location, MovementReturn, n, info)
+	case ReturnDamaged:
+		return inv.record(isbn, location, MovementDamaged, n, info)
+	default:
+		return StockEntry{}, fmt.Errorf("unknown return condition: %s", condition)
+	}
+}
+
+func (inv *Inventory) record(isbn ISBN, location string, typ MovementType, n int, info MovementInfo) (StockEntry, error) {
+	inv.mu.Lock()
+	defer inv.unlock()
+	location = locationOr(location)
+	if _, err := inv.commitLocked([]Movement{newMovement(isbn, location, typ, n, info, inv.clock.Now())}); err != nil {
+		return StockEntry{}, err
+	}
+	return inv.entries[stockKey(isbn, location)].clone(), nil
+}
+
+// ReceiveLot adds a costed lot to the book's entry at a location, creating
+// an empty entry first if the book is not stocked there yet.
+func (inv *Inventory) ReceiveLot(book Book, location string, lot StockLot, info MovementInfo) (StockEntry, error) {
+	entries, err := inv.ReceiveMany([]StockReceipt{{Book: book, Location: location, Lot: lot}}, info)
+	if err != nil {
+		return StockEntry{}, err
+	}
+	return entries[0], nil
+}
+
+// StockReceipt is a delivery of new copies of one book to a location,
+// DefaultLocation if none is named: a costed lot if Lot is set, otherwise
+// Quantity uncosted copies.
+type StockReceipt struct {
+	Book     Book
+	Location string
+	Quantity int
+	Lot      StockLot
+}
+
+// ReceiveMany stocks every receipt or none of them, creating empty entries
+// for books not yet stocked at a receipt's location. It returns the
+// receiving entries in the order they first appear.
+func (inv *Inventory) ReceiveMany(receipts []StockReceipt, info MovementInfo) ([]StockEntry, error) {
+	inv.mu.Lock()
+	defer inv.unlock()
+	now := inv.clock.Now()
+	ms := make([]Movement, 0, len(receipts))
+	var created []StockEntry
+	for _, rc := range receipts {
+		location := locationOr(rc.Location)
+		if _, ok := inv.locations[location]; !ok {
+			return nil, fmt.Errorf("location %s not found", location)
+		}
+		m := newMovement(rc.Book.ISBN(), location, MovementRestock, rc.Quantity, info, now)
+		if rc.Lot.quantity > 0 {
+			m = newMovement(rc.Book.ISBN(), location, MovementReceipt, rc.Lot.quantity, info, rc.Lot.receivedAt)
+			m.lot = rc.Lot
+		}
+		ms = append(ms, m)
+		if _, ok := inv.entries[m.key()]; !ok {
+			created = append(created, StockEntry{book: rc.Book, location: location})
+		}
+	}
+	if _, err := inv.commitLocked(ms, created...); err != nil {
+		return nil, err
+	}
+	result := make([]StockEntry, 0, len(ms))
+	seen := make(map[string]bool, len(ms))
+	for _, m := range ms {
+		key := m.key()
+		if !seen[key] {
+			seen[key] = true
+			result = append(result, inv.entries[key].clone())
+		}
+	}
+	return result, nil
+}
+
+// ReserveMany reserves every request or none of them. Requests for the same
+// book, location and condition add up. On any shortage or unknown book nothing is reserved.
+func (inv *Inventory) ReserveMany(reqs []StockRequest, info MovementInfo) ([]StockEntry, error) {
+	inv.mu.Lock()
+	defer inv.unlock()
+	now := inv.clock.Now()
+	ms := make([]Movement, 0, len(reqs))
+	for _, r := range reqs {
+		m := newMovement(r.ISBN, locationOr(r.Location), MovementReserve, r.Quantity, info, now)
+		m.condition = r.Condition
+		ms = append(ms, m)
+	}
+	if _, err := inv.commitLocked(ms); err != nil {
+		return nil, err
+	}
+	result := make([]StockEntry, 0, len(reqs))
+	seen := make(map[string]bool, len(reqs))
+	for _, m := range ms {
+		key := m.key()
+		if !seen[key] {
+			seen[key] = true
+			result = append(result, inv.entries[key].clone())
+		}
+	}
+	return result, nil
+}
+
+// Entries returns a snapshot of every book's stock in every condition at
+// every location.
+func (inv *Inventory) Entries() []StockEntry {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	result := make([]StockEntry, 0, len(inv.entries))
+	for _, e := range inv.entries {
+		result = append(result, e.clone())
+	}
+	return result
+}
+
+// EntriesAt returns a snapshot of the new stock held at one location, or of
+// every book's new stock summed across locations if location is empty.
+// Used copies are left out; see Variants.
+func (inv *Inventory) EntriesAt(location string) []StockEntry {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	if location == "" {
+		byBook := make(map[ISBN][]StockEntry)
+		for _, e := range inv.entries {
+			if !e.condition.IsUsed() {
+				byBook[e.book.ISBN()] = append(byBook[e.book.ISBN()], e)
+			}
+		}
+		result := make([]StockEntry, 0, len(byBook))
+		for _, entries := range byBook {
+			result = append(result, aggregate(entries))
+		}
+		return result
+	}
+	var result []StockEntry
+	for _, e := range inv.entries {
+		if e.location == strings.ToLower(location) && !e.condition.IsUsed() {
+			result = append(result, e.clone())
+		}
+	}
+	return result
+}
+
+// LowStockBooks returns all books with available stock, summed across
+// locations, below the threshold.
 func (inv *Inventory) LowStockBooks(threshold int) []Book {
+	return inv.LowStockBooksAt("", threshold)
+}
+
+// LowStockBooksAt returns the books with available stock below the threshold
+// at one location, or summed across locations if location is empty.
+func (inv *Inventory) LowStockBooksAt(location string, threshold int) []Book {
 	var result []Book
-	for _, e := range inv.entries {
+	for _, e := range inv.EntriesAt(location) {
 		if e.IsLowStock(threshold) {
 			result = append(result, e.book)
 		}
@@ -97,11 +566,28 @@ func (inv *Inventory) LowStockBooks(threshold int) []Book {
 	return result
 }
 
-// TotalValue calculates the total value of all stock (total copies * price).
-func (inv *Inventory) TotalValue() int {
-	var total int
-	for _, e := range inv.entries {
-		total += e.book.Price().Amount() * e.total
+// ValueByCurrency totals stock value at list price separately for each
+// currency, since books may be priced in different currencies. Used copies
+// count at their variant's price and serialized copies at their own. An
+// empty location covers all locations.
+func (inv *Inventory) ValueByCurrency(location string) map[string]Money {
+	result := make(map[string]Money)
+	for _, e := range inv.pricedEntries(location) {
+		currency := e.Price().Currency()
+		v := result[currency]
+		result[currency] = Money{amount: v.amount + e.listValue(), currency: currency}
+	}
+	return result
+}
+
+// pricedEntries returns the entries in every condition at one location, or
+// at all locations if location is empty.
+func (inv *Inventory) pricedEntries(location string) []StockEntry {
+	var result []StockEntry
+	for _, e := range inv.Entries() {
+		if location == "" || e.location == strings.ToLower(location) {
+			result = append(result, e)
+		}
 	}
-	return total
+	return result
 }
@@ -0,0 +1,228 @@
+package domain
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testStockBook(t *testing.T, isbn string, priceCents int, currency string) Book {
+	t.Helper()
+	i, err := NewISBN(isbn)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	author, _ := NewAuthor("Jane", "Doe")
+	price, _ := NewMoney(priceCents, currency)
+	book, err := NewBook(i, "Stocked", author, price, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), GenreFiction)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return book
+}
+
+func TestInventory_ValueByCurrency(t *testing.T) {
+	inv := NewInventory(nil)
+	for _, s := range []struct {
+		isbn     string
+		cents    int
+		currency string
+		total    int
+	}{
+		{"9780306406157", 1000, "EUR", 3},
+		{"9780140449136", 500, "EUR", 2},
+		{"9780131103627", 2000, "USD", 1},
+	} {
+		entry, _ := NewStockEntry(testStockBook(t, s.isbn, s.cents, s.currency), s.total)
+		if err := inv.Add(entry); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	values := inv.ValueByCurrency("")
+	if got := values["EUR"].Amount(); got != 4000 {
+		t.Errorf("expected 4000 EUR, got %d", got)
+	}
+	if got := values["USD"].Amount(); got != 2000 {
+		t.Errorf("expected 2000 USD, got %d", got)
+	}
+}
+
+func TestInventory_FindReturnsCopy(t *testing.T) {
+	inv := NewInventory(nil)
+	book := testStockBook(t, "9780306406157", 1000, "EUR")
+	entry, _ := NewStockEntry(book, 5)
+	if err := inv.Add(entry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	found, _ := inv.Find(book.ISBN())
+	if err := found.Reserve(3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stored, _ := inv.Find(book.ISBN())
+	if stored.Reserved() != 0 {
+		t.Errorf("expected changes to a found entry not to reach the inventory, got %d reserved", stored.Reserved())
+	}
+}
+
+func TestInventory_ReserveManyAllOrNothing(t *testing.T) {
+	inv := NewInventory(nil)
+	a := testStockBook(t, "9780306406157", 1000, "EUR")
+	b := testStockBook(t, "9780140449136", 1000, "EUR")
+	ea, _ := NewStockEntry(a, 5)
+	eb, _ := NewStockEntry(b, 1)
+	if err := inv.Add(ea); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := inv.Add(eb); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err := inv.ReserveMany([]StockRequest{{ISBN: a.ISBN(), Quantity: 2}, {ISBN: b.ISBN(), Quantity: 2}}, MovementInfo{})
+	if err == nil {
+		t.Fatal("expected shortage error")
+	}
+	if e, _ := inv.Find(a.ISBN()); e.Reserved() != 0 {
+		t.Errorf("expected rollback of %s, got %d reserved", a.ISBN(), e.Reserved())
+	}
+
+	entries, err := inv.ReserveMany([]StockRequest{{ISBN: a.ISBN(), Quantity: 2}, {ISBN: b.ISBN(), Quantity: 1}, {ISBN: a.ISBN(), Quantity: 3}}, MovementInfo{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Reserved() != 5 || entries[1].Reserved() != 1 {
+		t.Errorf("unexpected entries after reservation: %+v", entries)
+	}
+}
+
+func TestInventory_ReserveManyUnknownBook(t *testing.T) {
+	inv := NewInventory(nil)
+	a := testStockBook(t, "9780306406157", 1000, "EUR")
+	ea, _ := NewStockEntry(a, 5)
+	if err := inv.Add(ea); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	missing := testStockBook(t, "9780140449136", 1000, "EUR")
+
+	if _, err := inv.ReserveMany([]StockRequest{{ISBN: a.ISBN(), Quantity: 1}, {ISBN: missing.ISBN(), Quantity: 1}}, MovementInfo{}); err == nil {
+		t.Fatal("expected error for unknown book")
+	}
+	if e, _ := inv.Find(a.ISBN()); e.Reserved() != 0 {
+		t.Errorf("expected nothing reserved, got %d", e.Reserved())
+	}
+}
+
+func TestInventory_ReceiveManyAllOrNothing(t *testing.T) {
+	inv := NewInventory(nil)
+	a := testStockBook(t, "9780306406157", 1000, "EUR")
+	b := testStockBook(t, "9780140449136", 1000, "EUR")
+	cost, _ := NewMoney(400, "EUR")
+	lot, _ := NewStockLot(3, cost, "acme", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+
+	_, err := inv.ReceiveMany([]StockReceipt{{Book: a, Lot: lot}, {Book: b, Location: "nowhere", Quantity: 2}}, MovementInfo{})
+	if err == nil {
+		t.Fatal("expected error for unknown location")
+	}
+	if _, err := inv.Find(a.ISBN()); err == nil {
+		t.Errorf("expected %s not to be stocked after a refused delivery", a.ISBN())
+	}
+	if n := len(inv.Movements(a.ISBN())); n != 0 {
+		t.Errorf("expected no movements, got %d", n)
+	}
+
+	entries, err := inv.ReceiveMany([]StockReceipt{{Book: a, Lot: lot}, {Book: b, Quantity: 2}, {Book: a, Quantity: 1}}, MovementInfo{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Total() != 4 || len(entries[0].Lots()) != 1 || entries[1].Total() != 2 {
+		t.Errorf("unexpected entries after delivery: %+v", entries)
+	}
+}
+
+func TestInventory_ConcurrentReservationsNeverOversell(t *testing.T) {
+	inv := NewInventory(nil)
+	book := testStockBook(t, "9780306406157", 1000, "EUR")
+	entry, _ := NewStockEntry(book, 100)
+	if err := inv.Add(entry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	var succeeded atomic.Int64
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 10 {
+				if _, err := inv.Reserve(book.ISBN(), DefaultLocation, 1, MovementInfo{}); err == nil {
+					succeeded.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	e, _ := inv.Find(book.ISBN())
+	if succeeded.Load() != 100 || e.Reserved() != 100 || e.Available() != 0 {
+		t.Errorf("expected exactly 100 reservations, got %d succeeded, %d reserved", succeeded.Load(), e.Reserved())
+	}
+}
+
+func TestInventory_ConcurrentMixedOperations(t *testing.T) {
+	inv := NewInventory(nil)
+	a := testStockBook(t, "9780306406157", 1000, "EUR")
+	b := testStockBook(t, "9780140449136", 500, "EUR")
+	ea, _ := NewStockEntry(a, 20)
+	eb, _ := NewStockEntry(b, 20)
+	if err := inv.Add(ea); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := inv.Add(eb); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cost, _ := NewMoney(400, "EUR")
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(4)
+		go func() {
+			defer wg.Done()
+			if _, err := inv.ReserveMany([]StockRequest{{ISBN: a.ISBN(), Quantity: 1}, {ISBN: b.ISBN(), Quantity: 1}}, MovementInfo{}); err == nil {
+				inv.Release(a.ISBN(), DefaultLocation, 1, MovementInfo{})
+				inv.Release(b.ISBN(), DefaultLocation, 1, MovementInfo{})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			inv.Restock(a.ISBN(), DefaultLocation, 1, MovementInfo{})
+		}()
+		go func() {
+			defer wg.Done()
+			lot, _ := NewStockLot(1, cost, "Acme", time.Date(2025, 1, 1, i, 0, 0, 0, time.UTC))
+			inv.ReceiveLot(b, DefaultLocation, lot, MovementInfo{})
+		}()
+		go func() {
+			defer wg.Done()
+			for _, e := range inv.Entries() {
+				_ = e.Lots()
+			}
+			inv.LowStockBooks(5)
+			inv.ValueByCurrency("")
+		}()
+	}
+	wg.Wait()
+
+	ga, _ := inv.Find(a.ISBN())
+	gb, _ := inv.Find(b.ISBN())
+	if ga.Total() != 40 || gb.Total() != 40 {
+		t.Errorf("expected 40 copies each, got %d and %d", ga.Total(), gb.Total())
+	}
+	if ga.Reserved() != 0 || gb.Reserved() != 0 {
+		t.Errorf("expected all reservations released, got %d and %d", ga.Reserved(), gb.Reserved())
+	}
+	if len(gb.Lots()) != 20 {
+		t.Errorf("expected 20 lots, got %d", len(gb.Lots()))
+	}
+}
@@ -0,0 +1,394 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// MovementType says how a stock movement changes a stock entry.
+type MovementType string
+
+const (
+	MovementOpening MovementType = "opening" // stock on hand when tracking starts
+	MovementRestock MovementType = "restock" // uncosted copies added
+	MovementReceipt MovementType = "receipt" // a costed lot received from a supplier
+	MovementReserve MovementType = "reserve" // copies set aside
+	MovementRelease MovementType = "release" // set-aside copies made available again
+	MovementFulfil  MovementType = "fulfil"  // reserved copies sold and shipped
+	MovementReturn  MovementType = "return"  // copies returned to sellable stock
+	MovementDamaged MovementType = "damaged" // copies returned as damaged
+
+	MovementTransferOut    MovementType = "transfer_out"    // copies shipped to another location
+	MovementInTransit      MovementType = "in_transit"      // copies on their way from another location
+	MovementTransferIn     MovementType = "transfer_in"     // in-transit copies that arrived
+	MovementTransitLoss    MovementType = "transit_loss"    // in-transit copies that never arrived
+	MovementTransferCancel MovementType = "transfer_cancel" // in-transit copies recalled by the sender
+	MovementTransferReturn MovementType = "transfer_return" // recalled copies back at the sender
+
+	MovementCountGain MovementType = "count_gain" // copies found by a stock count
+	MovementCountLoss MovementType = "count_loss" // copies a stock count found missing
+)
+
+// ReasonCode explains why a movement happened.
+type ReasonCode string
+
+const (
+	ReasonInitialStock     ReasonCode = "initial_stock"
+	ReasonRestock          ReasonCode = "restock"
+	ReasonSupplierDelivery ReasonCode = "supplier_delivery"
+	ReasonHold             ReasonCode = "hold"
+	ReasonHoldReleased     ReasonCode = "hold_released"
+	ReasonHoldExpired      ReasonCode = "hold_expired"
+	ReasonSale             ReasonCode = "sale"
+	ReasonCustomerReturn   ReasonCode = "customer_return"
+	ReasonTransfer         ReasonCode = "transfer"
+	ReasonTransitLoss      ReasonCode = "transit_loss"
+	ReasonTransferCancel   ReasonCode = "transfer_cancelled"
+	ReasonBackorder        ReasonCode = "backorder_allocation"
+)
+
+// defaultReasons apply when a movement is recorded without a reason code.
+var defaultReasons = map[MovementType]ReasonCode{
+	MovementOpening: ReasonInitialStock,
+	MovementRestock: ReasonRestock,
+	MovementReceipt: ReasonSupplierDelivery,
+	MovementReserve: ReasonHold,
+	MovementRelease: ReasonHoldReleased,
+	MovementFulfil:  ReasonSale,
+	MovementReturn:  ReasonCustomerReturn,
+	MovementDamaged: ReasonCustomerReturn,
+
+	MovementTransferOut:    ReasonTransfer,
+	MovementInTransit:      ReasonTransfer,
+	MovementTransferIn:     ReasonTransfer,
+	MovementTransitLoss:    ReasonTransitLoss,
+	MovementTransferCancel: ReasonTransferCancel,
+	MovementTransferReturn: ReasonTransferCancel,
+
+	MovementCountGain: ReasonStockCount,
+	MovementCountLoss: ReasonStockCount,
+}
+
+// MovementInfo describes who caused a movement and why. Reference points at
+// the document behind it, such as a reservation, order or delivery note.
+type MovementInfo struct {
+	Reason    ReasonCode
+	Actor     string
+	Reference string
+}
+
+// Movement is one immutable entry in the stock ledger.
+type Movement struct {
+	seq       int
+	isbn      ISBN
+	location  string
+	condition Condition // empty for new copies
+	typ       MovementType
+	quantity  int
+	lot       StockLot   // set for receipts only
+	cost      Money      // set for sales and losses
+	copy      SerialCopy // set when one serialized copy moves; in full when it is added
+	held      string     // the ID of the reservation that reserved, released or sold the copies, if any
+	info      MovementInfo
+	at        time.Time
+}
+
+func newMovement(isbn ISBN, location string, typ MovementType, quantity int, info MovementInfo, at time.Time) Movement {
+	if info.Reason == "" {
+		info.Reason = defaultReasons[typ]
+	}
+	return Movement{isbn: isbn, location: location, typ: typ, quantity: quantity, info: info, at: at}
+}
+
+func (m Movement) Seq() int             { return m.seq }
+func (m Movement) Location() string     { return m.location }
+func (m Movement) ISBN() ISBN           { return m.isbn }
+func (m Movement) Condition() Condition { return m.condition.orNew() }
+func (m Movement) Type() MovementType   { return m.typ }
+func (m Movement) Quantity() int        { return m.quantity }
+func (m Movement) Reason() ReasonCode   { return m.info.Reason }
+func (m Movement) Actor() string        { return m.info.Actor }
+func (m Movement) Reference() string    { return m.info.Reference }
+func (m Movement) At() time.Time        { return m.at }
+
+// where names the movement's location and, for used copies, their condition.
+func (m Movement) where() string {
+	if m.condition.IsUsed() {
+		return fmt.Sprintf("%s (%s)", m.location, m.condition)
+	}
+	return m.location
+}
+
+// key returns the variantKey of the entry the movement changes.
+func (m Movement) key() string {
+	return variantKey(m.isbn, m.location, m.condition)
+}
+
+// Serial returns the serial of the copy the movement moved, if it moved a
+// serialized copy.
+func (m Movement) Serial() string { return m.copy.serial }
+
+// Lot returns the lot a receipt brought in.
+func (m Movement) Lot() (StockLot, bool) {
+	return m.lot, m.typ == MovementReceipt
+}
+
+// Cost returns what the copies a sale or loss took out of stock had cost,
+// under the inventory's cost method at the time.
+func (m Movement) Cost() (Money, bool) {
+	return m.cost, m.takesOut()
+}
+
+// apply folds the movement into the entry, refusing changes that would
+// break the entry's invariants: no negative stock and never more reserved
+// than on hand.
+func (s *StockEntry) apply(m Movement) error {
+	if m.copy.serial != "" {
+		return s.applyCopy(m)
+	}
+	var err error
+	switch m.typ {
+	case MovementOpening:
+		if m.quantity < 0 {
+			return fmt.Errorf("opening stock must not be negative")
+		}
+		s.total += m.quantity
+	case MovementRestock:
+		err = s.Restock(m.quantity)
+	case MovementReceipt:
+		err = s.ReceiveLot(m.lot)
+	case MovementReserve:
+		err = s.Reserve(m.quantity)
+	case MovementRelease:
+		err = s.Release(m.quantity)
+	case MovementFulfil:
+		err = s.Fulfil(m.quantity)
+	case MovementReturn:
+		err = s.Return(m.quantity)
+	case MovementDamaged:
+		err = s.ReturnDamaged(m.quantity)
+	case MovementTransferOut:
+		err = s.dispatch(m.quantity)
+	case MovementInTransit:
+		err = s.expect(m.quantity)
+	case MovementTransferIn:
+		err = s.arrive(m.quantity)
+	case MovementTransitLoss, MovementTransferCancel:
+		err = s.writeOffTransit(m.quantity)
+	case MovementTransferReturn:
+		err = s.Restock(m.quantity)
+	case MovementCountGain:
+		err = s.adjustCount(m.quantity)
+	case MovementCountLoss:
+		err = s.adjustCount(-m.quantity)
+	default:
+		return fmt.Errorf("unknown movement type: %s", m.typ)
+	}
+	if err != nil {
+		return err
+	}
+	return s.checkInvariants()
+}
+
+// StockDiscrepancy is a balance that does not match what the ledger implies.
+type StockDiscrepancy struct {
+	ISBN      ISBN
+	Location  string
+	Condition Condition
+	Measure   string // total, reserved, damaged, in_transit, lots, copies, reservations or transfers
+	Ledger    int    // what replaying the ledger gives
+	Recorded  int    // what the inventory currently holds
+}
+
+// Reconciliation is the outcome of replaying the ledger.
+type Reconciliation struct {
+	Entries       int
+	Movements     int
+	Discrepancies []StockDiscrepancy
+}
+
+// Balanced reports whether every balance matched the ledger.
+func (r Reconciliation) Balanced() bool { return len(r.Discrepancies) == 0 }
+
+// Movements returns the ledger entries for a book at every location, oldest first.
+func (inv *Inventory) Movements(isbn ISBN) []Movement {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	var result []Movement
+	for _, m := range inv.ledger {
+		if m.isbn == isbn {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// Reconcile replays the whole ledger from empty entries and compares the
+// result with the current balances and outstanding reservations. Copies
+// reserved without a reservation record, such as by Reserve or as opening
+// stock, are checked against the balances only.
+func (inv *Inventory) Reconcile() Reconciliation {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+
+	replayed := make(map[string]StockEntry, len(inv.entries))
+	for key, e := range inv.entries {
+		replayed[key] = StockEntry{book: e.book, location: e.location, condition: e.condition}
+	}
+	report := Reconciliation{Entries: len(inv.entries), Movements: len(inv.ledger)}
+	copies := make(map[string]int) // serialized copies held
+	booked := make(map[string]int) // copies reserved for reservation records
+	for _, m := range inv.ledger {
+		key := m.key()
+		e := replayed[key]
+		if m.copy.serial != "" {
+			switch m.typ {
+			case MovementRestock:
+				copies[key]++
+			case MovementFulfil:
+				copies[key]--
+			}
+		}
+		// The ledger was validated when written; replay without the checks so
+		// that a corrupted balance is reported rather than hidden.
+		switch m.typ {
+		case MovementOpening, MovementRestock, MovementReturn, MovementTransferReturn, MovementCountGain:
+			e.total += m.quantity
+		case MovementTransferOut, MovementCountLoss:
+			e.total -= m.quantity
+		case MovementInTransit:
+			e.inTransit += m.quantity
+		case MovementTransferIn:
+			e.inTransit -= m.quantity
+			e.total += m.quantity
+		case MovementTransitLoss, MovementTransferCancel:
+			e.inTransit -= m.quantity
+		case MovementReceipt:
+			e.total += m.lot.quantity
+			e.lots = append(e.lots, m.lot)
+		case MovementReserve:
+			e.reserved += m.quantity
+			if m.held != "" {
+				booked[key] += m.quantity
+			}
+		case MovementRelease:
+			e.reserved -= m.quantity
+			if m.held != "" {
+				booked[key] -= m.quantity
+			}
+		case MovementFulfil:
+			e.reserved -= m.quantity
+			e.total -= m.quantity
+			if m.held != "" {
+				booked[key] -= m.quantity
+			}
+		case MovementDamaged:
+			e.damaged += m.quantity
+		}
+		replayed[key] = e
+	}
+
+	held := make(map[string]int)
+	for _, res := range inv.reservations {
+		held[variantKey(res.isbn, res.location, res.condition)] += res.quantity
+	}
+	expected := make(map[string]int)
+	for _, tr := range inv.transfers {
+		for _, l := range tr.lines {
+			expected[stockKey(l.isbn, tr.to)] += l.Outstanding()
+		}
+	}
+	for key, current := range inv.entries {
+		want := replayed[key]
+		check := func(measure string, ledger, recorded int) {
+			if ledger != recorded {
+				report.Discrepancies = append(report.Discrepancies, StockDiscrepancy{
+					ISBN:      current.book.ISBN(),
+					Location:  current.location,
+					Condition: current.Condition(),
+					Measure:   measure,
+					Ledger:    ledger,
+					Recorded:  recorded,
+				})
+			}
+		}
+		check("total", want.total, current.total)
+		check("reserved", want.reserved, current.reserved)
+		check("damaged", want.damaged, current.damaged)
+		check("in_transit", want.inTransit, current.inTransit)
+		check("lots", len(want.lots), len(current.lots))
+		check("copies", copies[key], current.countCopies(CopyAvailable)+current.countCopies(CopyReserved))
+		check("reservations", booked[key], held[key])
+		check("transfers", want.inTransit, expected[key])
+	}
+	return report
+}
+
+// StockChange reports the balances of a stock entry just after a change.
+// Listeners run outside the lock, so changes from concurrent callers may
+// arrive out of order; Seq, the ledger position of the change's last
+// movement, orders them.
+type StockChange struct {
+	Entry StockEntry
+	Seq   int
+	At    time.Time
+}
+
+// OnStockChange registers fn to be told about every committed change to a
+// stock entry, e.g. to alert on low stock. It is called after the
+// inventory's lock is released, so it may call back into the inventory.
+func (inv *Inventory) OnStockChange(fn func(StockChange)) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	inv.watch = fn
+}
+
+// commitLocked validates the movements against copies of the affected entries
+// and, only if all of them apply, appends them to the ledger and stores the
+// new balances. Entries in created are new and not yet in the inventory.
+// Copies that become available go to waiting backorders first. The caller
+// must hold the write lock.
+func (inv *Inventory) commitLocked(ms []Movement, created ...StockEntry) ([]Movement, error) {
+	pending := make(map[string]StockEntry, len(ms))
+	for _, e := range created {
+		pending[e.key()] = e
+	}
+	for _, m := range ms {
+		key := m.key()
+		e, ok := pending[key]
+		if !ok {
+			if e, ok = inv.entries[key]; !ok {
+				return nil, fmt.Errorf("book %s not found in inventory at %s", m.isbn, m.where())
+			}
+			e = e.clone()
+		}
+		if err := e.apply(m); err != nil {
+			return nil, fmt.Errorf("book %s at %s: %w", m.isbn, m.where(), err)
+		}
+		pending[key] = e
+	}
+	for i := range ms {
+		ms[i].seq = len(inv.ledger) + 1
+		inv.costLocked(&ms[i], pending[ms[i].key()].book.Price().Currency())
+		inv.ledger = append(inv.ledger, ms[i])
+	}
+	var freed, changed []string
+	for key, e := range pending {
+		if before, ok := inv.entries[key]; !ok || e.Available() > before.Available() {
+			freed = append(freed, key)
+		}
+		inv.entries[key] = e
+		changed = append(changed, key)
+	}
+	if inv.watch != nil {
+		slices.Sort(changed)
+		for _, key := range changed {
+			inv.changes = append(inv.changes, StockChange{Entry: inv.entries[key].clone(), Seq: len(inv.ledger), At: inv.clock.Now()})
+		}
+	}
+	for _, key := range freed {
+		inv.allocateLocked(key)
+	}
+	return ms, nil
+}
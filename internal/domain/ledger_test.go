@@ -0,0 +1,151 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInventory_RecordsMovements(t *testing.T) {
+	clock := NewFakeClock(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+	inv := NewInventory(clock)
+	book := testStockBook(t, "9780306406157", 1000, "EUR")
+	entry, _ := NewStockEntry(book, 3)
+	if err := inv.Create(entry, MovementInfo{Actor: "alice"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clock.Advance(time.Hour)
+	if _, err := inv.Restock(book.ISBN(), DefaultLocation, 2, MovementInfo{Actor: "bob", Reference: "DN-7"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := inv.Reserve(book.ISBN(), DefaultLocation, 10, MovementInfo{}); err == nil {
+		t.Fatal("expected shortage error")
+	}
+
+	ms := inv.Movements(book.ISBN())
+	if len(ms) != 2 {
+		t.Fatalf("expected 2 movements, failed changes must not be recorded, got %d", len(ms))
+	}
+	if ms[0].Type() != MovementOpening || ms[0].Quantity() != 3 || ms[0].Reason() != ReasonInitialStock || ms[0].Actor() != "alice" {
+		t.Errorf("unexpected opening movement: %+v", ms[0])
+	}
+	if ms[1].Seq() != 2 || ms[1].Reason() != ReasonRestock || ms[1].Reference() != "DN-7" || !ms[1].At().Equal(clock.Now()) {
+		t.Errorf("unexpected restock movement: %+v", ms[1])
+	}
+}
+
+func TestInventory_ReconcileBalanced(t *testing.T) {
+	inv := NewInventory(nil)
+	book := testStockBook(t, "9780306406157", 1000, "EUR")
+	entry, _ := NewStockEntry(book, 5)
+	if err := inv.Add(entry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cost, _ := NewMoney(400, "EUR")
+	lot, _ := NewStockLot(2, cost, "Acme", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	inv.ReceiveLot(book, DefaultLocation, lot, MovementInfo{})
+	res, _ := inv.Hold(book.ISBN(), DefaultLocation, 3, "cart_1", time.Now(), time.Hour)
+	inv.Hold(book.ISBN(), DefaultLocation, 1, "cart_2", time.Now(), time.Hour)
+	inv.ReleaseReservation(res.ID(), MovementInfo{})
+
+	rec := inv.Reconcile()
+	if !rec.Balanced() {
+		t.Errorf("expected balanced ledger, got %+v", rec.Discrepancies)
+	}
+	if rec.Movements != 5 {
+		t.Errorf("expected 5 movements, got %d", rec.Movements)
+	}
+}
+
+func TestInventory_ReconcileDetectsDrift(t *testing.T) {
+	inv := NewInventory(nil)
+	book := testStockBook(t, "9780306406157", 1000, "EUR")
+	entry, _ := NewStockEntry(book, 5)
+	if err := inv.Add(entry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Simulate a balance changed behind the ledger's back.
+	drifted := inv.entries[stockKey(book.ISBN(), DefaultLocation)]
+	drifted.total = 4
+	inv.entries[stockKey(book.ISBN(), DefaultLocation)] = drifted
+
+	rec := inv.Reconcile()
+	if len(rec.Discrepancies) != 1 {
+		t.Fatalf("expected one discrepancy, got %+v", rec.Discrepancies)
+	}
+	d := rec.Discrepancies[0]
+	if d.Measure != "total" || d.Ledger != 5 || d.Recorded != 4 {
+		t.Errorf("unexpected discrepancy: %+v", d)
+	}
+}
+
+func TestInventory_ReconcileAcceptsHoldsWithoutRecords(t *testing.T) {
+	inv := NewInventory(nil)
+	book := testStockBook(t, "9780306406157", 1000, "EUR")
+	entry, _ := NewStockEntry(book, 5)
+	if err := inv.Add(entry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := inv.Reserve(book.ISBN(), DefaultLocation, 2, MovementInfo{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	res, _ := inv.Hold(book.ISBN(), DefaultLocation, 1, "cart_1", time.Now(), time.Hour)
+
+	if rec := inv.Reconcile(); !rec.Balanced() {
+		t.Errorf("expected balanced ledger, got %+v", rec.Discrepancies)
+	}
+
+	// A reservation record lost behind the ledger's back is still caught.
+	delete(inv.reservations, res.ID())
+	rec := inv.Reconcile()
+	if len(rec.Discrepancies) != 1 || rec.Discrepancies[0].Measure != "reservations" || rec.Discrepancies[0].Ledger != 1 {
+		t.Errorf("expected one missing reservation, got %+v", rec.Discrepancies)
+	}
+}
+
+func TestInventory_OnStockChangeReportsEveryCommittedChange(t *testing.T) {
+	inv := NewInventory(nil)
+	book := testStockBook(t, "9780306406157", 1000, "EUR")
+	var seen []int
+	inv.OnStockChange(func(c StockChange) {
+		// Listeners run outside the lock and may read the inventory.
+		e, _ := inv.Find(c.Entry.Book().ISBN())
+		seen = append(seen, e.Available())
+	})
+	entry, _ := NewStockEntry(book, 5)
+	if err := inv.Add(entry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	inv.Reserve(book.ISBN(), "", 2, MovementInfo{})
+	if _, err := inv.Reserve(book.ISBN(), "", 9, MovementInfo{}); err == nil {
+		t.Fatal("expected error reserving more than available")
+	}
+	if len(seen) != 2 || seen[0] != 5 || seen[1] != 3 {
+		t.Errorf("got changes %v, want [5 3]: one per committed change, none for the refused one", seen)
+	}
+}
+
+func TestInventory_AddNeverRewritesTheLedger(t *testing.T) {
+	inv := NewInventory(nil)
+	book := testStockBook(t, "9780306406157", 1000, "EUR")
+	entry, _ := NewStockEntry(book, 5)
+	if err := inv.Add(entry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	inv.Restock(book.ISBN(), "", 2, MovementInfo{})
+	if err := inv.Add(entry); err == nil {
+		t.Error("expected error adding a book already stocked at the location")
+	}
+	if n := len(inv.Movements(book.ISBN())); n != 2 {
+		t.Errorf("got %d movements, want the opening and the restock kept", n)
+	}
+	elsewhere, _ := NewStockEntry(book, 1)
+	elsewhere.location = "nowhere"
+	if err := inv.Add(elsewhere); err == nil {
+		t.Error("expected error adding stock at an unknown location")
+	}
+	if e, _ := inv.Find(book.ISBN()); e.Total() != 7 {
+		t.Errorf("got %d total, want 7", e.Total())
+	}
+}
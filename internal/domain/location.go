@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DefaultLocation is where stock lives when no location is named.
+const DefaultLocation = "main"
+
+// LocationKind distinguishes shops from stock-holding warehouses.
+type LocationKind string
+
+const (
+	LocationStore     LocationKind = "store"
+	LocationWarehouse LocationKind = "warehouse"
+)
+
+// Location is a shop or warehouse that holds stock, identified by a short code.
+type Location struct {
+	code string
+	name string
+	kind LocationKind
+}
+
+func NewLocation(code, name string, kind LocationKind) (Location, error) {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if code == "" {
+		return Location{}, errors.New("location code must not be empty")
+	}
+	if strings.ContainsAny(code, "@/ ") {
+		return Location{}, fmt.Errorf("location code %q must not contain '@', '/' or spaces", code)
+	}
+	if kind != LocationStore && kind != LocationWarehouse {
+		return Location{}, fmt.Errorf("unknown location kind: %s", kind)
+	}
+	if name == "" {
+		name = code
+	}
+	return Location{code: code, name: name, kind: kind}, nil
+}
+
+func (l Location) Code() string       { return l.code }
+func (l Location) Name() string       { return l.name }
+func (l Location) Kind() LocationKind { return l.kind }
+
+// stockKey identifies a book's stock at one location.
+func stockKey(isbn ISBN, location string) string {
+	return isbn.String() + "@" + location
+}
+
+// locationOr maps an unnamed location to DefaultLocation.
+func locationOr(location string) string {
+	if location == "" {
+		return DefaultLocation
+	}
+	return strings.ToLower(location)
+}
+
+// AddLocation registers a shop or warehouse that can hold stock.
+func (inv *Inventory) AddLocation(loc Location) error {
+	inv.mu.Lock()
+	defer inv.unlock()
+	if _, ok := inv.locations[loc.code]; ok {
+		return fmt.Errorf("location %s already exists", loc.code)
+	}
+	inv.locations[loc.code] = loc
+	return nil
+}
+
+// FindLocation returns the location with the given code.
+func (inv *Inventory) FindLocation(code string) (Location, error) {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	loc, ok := inv.locations[locationOr(code)]
+	if !ok {
+		return Location{}, fmt.Errorf("location %s not found", code)
+	}
+	return loc, nil
+}
+
+// Locations returns all registered locations ordered by code.
+func (inv *Inventory) Locations() []Location {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	result := make([]Location, 0, len(inv.locations))
+	for _, loc := range inv.locations {
+		result = append(result, loc)
+	}
+	slices.SortFunc(result, func(a, b Location) int { return strings.Compare(a.code, b.code) })
+	return result
+}
+
+// aggregate sums a book's stock across locations into one entry without a
+// location or bin.
+func aggregate(entries []StockEntry) StockEntry {
+	var sum StockEntry
+	for i, e := range entries {
+		if i == 0 {
+			sum.book = e.book
+		}
+		sum.total += e.total
+		sum.reserved += e.reserved
+		sum.damaged += e.damaged
+		sum.inTransit += e.inTransit
+		sum.lots = append(sum.lots, e.lots...)
+		sum.copies = append(sum.copies, e.Copies()...)
+	}
+	return sum
+}
@@ -0,0 +1,144 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func testLocations(t *testing.T, inv *Inventory) {
+	t.Helper()
+	shop, err := NewLocation("Shop", "High Street shop", LocationStore)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := inv.AddLocation(shop); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestNewLocation_Validation(t *testing.T) {
+	loc, err := NewLocation(" Shop ", "", LocationStore)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if loc.Code() != "shop" || loc.Name() != "shop" {
+		t.Errorf("got code %q name %q, want shop/shop", loc.Code(), loc.Name())
+	}
+	for _, code := range []string{"", "a@b", "a/b", "a b"} {
+		if _, err := NewLocation(code, "", LocationStore); err == nil {
+			t.Errorf("expected error for code %q", code)
+		}
+	}
+	if _, err := NewLocation("x", "", "depot"); err == nil {
+		t.Error("expected error for unknown kind")
+	}
+}
+
+func TestInventory_AggregatesAcrossLocations(t *testing.T) {
+	inv := NewInventory(nil)
+	testLocations(t, inv)
+	book := testStockBook(t, "9780306406157", 1000, "EUR")
+	main, _ := NewStockEntry(book, 5)
+	shop, _ := NewStockEntry(book, 2)
+	if err := inv.Create(main, MovementInfo{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := inv.Create(shop.WithLocation("shop", "A-3"), MovementInfo{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := inv.Hold(book.ISBN(), "shop", 1, "cart-1", time.Now(), time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sum, err := inv.Find(book.ISBN())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sum.Total() != 7 || sum.Reserved() != 1 || sum.Location() != "" {
+		t.Errorf("got total %d reserved %d location %q, want 7/1/\"\"", sum.Total(), sum.Reserved(), sum.Location())
+	}
+	at, err := inv.FindAt(book.ISBN(), "shop")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if at.Total() != 2 || at.Available() != 1 || at.Bin() != "A-3" {
+		t.Errorf("got total %d available %d bin %q, want 2/1/A-3", at.Total(), at.Available(), at.Bin())
+	}
+	byLoc := inv.StockByLocation(book.ISBN())
+	if len(byLoc) != 2 || byLoc[0].Location() != "main" || byLoc[1].Location() != "shop" {
+		t.Errorf("unexpected breakdown: %+v", byLoc)
+	}
+	if !inv.Reconcile().Balanced() {
+		t.Errorf("ledger does not balance: %+v", inv.Reconcile().Discrepancies)
+	}
+}
+
+func TestInventory_ReportsPerLocation(t *testing.T) {
+	inv := NewInventory(nil)
+	testLocations(t, inv)
+	book := testStockBook(t, "9780306406157", 1000, "EUR")
+	main, _ := NewStockEntry(book, 10)
+	shop, _ := NewStockEntry(book, 1)
+	if err := inv.Add(main); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := inv.Add(shop.WithLocation("shop", "")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := inv.ValueByCurrency("shop")["EUR"]; got.Amount() != 1000 {
+		t.Errorf("shop value: got %d, want 1000", got.Amount())
+	}
+	if got := inv.ValueByCurrency("")["EUR"]; got.Amount() != 11000 {
+		t.Errorf("total value: got %d, want 11000", got.Amount())
+	}
+	if low := inv.LowStockBooksAt("shop", 5); len(low) != 1 {
+		t.Errorf("expected book to be low at the shop, got %d", len(low))
+	}
+	if low := inv.LowStockBooks(5); len(low) != 0 {
+		t.Errorf("expected no low stock in aggregate, got %d", len(low))
+	}
+}
+
+func TestInventory_HoldIsScopedToLocation(t *testing.T) {
+	inv := NewInventory(nil)
+	testLocations(t, inv)
+	book := testStockBook(t, "9780306406157", 1000, "EUR")
+	main, _ := NewStockEntry(book, 5)
+	shop, _ := NewStockEntry(book, 1)
+	if err := inv.Add(main); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := inv.Add(shop.WithLocation("shop", "")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := inv.Hold(book.ISBN(), "shop", 2, "cart-1", now, time.Minute); err == nil {
+		t.Fatal("expected hold beyond the shop's stock to fail")
+	}
+	res, err := inv.Hold(book.ISBN(), "shop", 1, "cart-1", now, time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Location() != "shop" {
+		t.Errorf("got location %q, want shop", res.Location())
+	}
+	if at, _ := inv.FindAt(book.ISBN(), DefaultLocation); at.Reserved() != 0 {
+		t.Errorf("main warehouse reserved %d, want 0", at.Reserved())
+	}
+}
+
+func TestInventory_UnknownLocation(t *testing.T) {
+	inv := NewInventory(nil)
+	entry, _ := NewStockEntry(testStockBook(t, "9780306406157", 1000, "EUR"), 1)
+	if err := inv.Create(entry.WithLocation("nowhere", ""), MovementInfo{}); err == nil {
+		t.Error("expected error for unknown location")
+	}
+	if _, err := inv.FindLocation("nowhere"); err == nil {
+		t.Error("expected error finding unknown location")
+	}
+	if err := inv.AddLocation(Location{code: DefaultLocation, kind: LocationWarehouse}); err == nil {
+		t.Error("expected error adding duplicate location")
+	}
+}
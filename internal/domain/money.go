@@ -52,13 +52,15 @@ func (m Money) MultiplyPercent(percent int) Money {
 }
 
 // Display formats the money for human display, e.g. "12.99 EUR".
+// Negative amounts are prefixed with a minus sign, e.g. "-0.50 EUR".
 func (m Money) Display() string {
-	whole := m.amount / 100
-	cents := m.amount % 100
-	if cents < 0 {
-		cents = -cents
+	sign := ""
+	amount := m.amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
 	}
-	return fmt.Sprintf("%d.%02d %s", whole, cents, m.currency)
+	return fmt.Sprintf("%s%d.%02d %s", sign, amount/100, amount%100, m.currency)
 }
 
 // IsZero returns true if the amount is zero.
@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// PricePeriod is a price in effect from effectiveFrom until effectiveTo.
+// A zero effectiveTo means the period is open-ended.
+type PricePeriod struct {
+	price         Money
+	effectiveFrom time.Time
+	effectiveTo   time.Time
+}
+
+func (p PricePeriod) Price() Money             { return p.price }
+func (p PricePeriod) EffectiveFrom() time.Time { return p.effectiveFrom }
+func (p PricePeriod) EffectiveTo() time.Time   { return p.effectiveTo }
+
+// Contains returns true if the period covers the given time.
+func (p PricePeriod) Contains(t time.Time) bool {
+	if t.Before(p.effectiveFrom) {
+		return false
+	}
+	return p.effectiveTo.IsZero() || t.Before(p.effectiveTo)
+}
+
+// PriceTimeline is the price history of a single book, including scheduled
+// future prices. When periods overlap, the one that started most recently
+// wins, so a bounded promotion temporarily overrides the regular price and
+// the regular price resumes when the promotion ends.
+type PriceTimeline struct {
+	isbn    ISBN
+	periods []PricePeriod // sorted by effectiveFrom
+}
+
+// NewPriceTimeline starts a timeline with an open-ended initial price.
+func NewPriceTimeline(isbn ISBN, initial Money, from time.Time) PriceTimeline {
+	return PriceTimeline{
+		isbn:    isbn,
+		periods: []PricePeriod{{price: initial, effectiveFrom: from}},
+	}
+}
+
+func (t PriceTimeline) ISBN() ISBN { return t.isbn }
+
+// Periods returns the timeline's periods ordered by start time.
+func (t PriceTimeline) Periods() []PricePeriod {
+	return append([]PricePeriod(nil), t.periods...)
+}
+
+// Schedule adds a price taking effect at from, optionally ending at to (zero
+// for open-ended). History is immutable: from must not lie before now.
+func (t *PriceTimeline) Schedule(price Money, from, to, now time.Time) error {
+	if price.Amount() < 0 {
+		return errors.New("price must not be negative")
+	}
+	if len(t.periods) > 0 && price.Currency() != t.periods[0].price.Currency() {
+		return fmt.Errorf("price must be in %s", t.periods[0].price.Currency())
+	}
+	if from.Before(now) {
+		return errors.New("prices cannot be scheduled in the past")
+	}
+	if !to.IsZero() && !to.After(from) {
+		return errors.New("price period must end after it starts")
+	}
+
+	// Build the new periods on a copy: copies of the timeline handed out
+	// earlier share the old array and may be read concurrently.
+	periods := append(t.Periods(), PricePeriod{price: price, effectiveFrom: from, effectiveTo: to})
+	sort.SliceStable(periods, func(i, j int) bool {
+		return periods[i].effectiveFrom.Before(periods[j].effectiveFrom)
+	})
+	t.periods = periods
+	return nil
+}
+
+// PriceAt returns the price in effect at the given time. Returns false if no
+// period covers it, e.g. before the book was first priced.
+func (t PriceTimeline) PriceAt(at time.Time) (Money, bool) {
+	for i := len(t.periods) - 1; i >= 0; i-- {
+		if t.periods[i].Contains(at) {
+			return t.periods[i].price, true
+		}
+	}
+	return Money{}, false
+}
@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPriceTimeline_BoundedPeriodOverridesThenResumes(t *testing.T) {
+	isbn, _ := NewISBN("9780306406157")
+	regular, _ := NewMoney(2000, "EUR")
+	blackFriday, _ := NewMoney(1500, "EUR")
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	from := time.Date(2025, 11, 28, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	timeline := NewPriceTimeline(isbn, regular, start)
+	if err := timeline.Schedule(blackFriday, from, to, start); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cases := []struct {
+		at   time.Time
+		want int
+	}{
+		{from.Add(-time.Second), 2000},
+		{from, 1500},
+		{to.Add(-time.Second), 1500},
+		{to, 2000},
+	}
+	for _, c := range cases {
+		got, ok := timeline.PriceAt(c.at)
+		if !ok || got.Amount() != c.want {
+			t.Errorf("at %s: expected %d, got %d (ok=%v)", c.at, c.want, got.Amount(), ok)
+		}
+	}
+	if _, ok := timeline.PriceAt(start.Add(-time.Second)); ok {
+		t.Error("expected no price before the timeline starts")
+	}
+}
+
+func TestPriceTimeline_RejectsPastSchedule(t *testing.T) {
+	isbn, _ := NewISBN("9780306406157")
+	price, _ := NewMoney(2000, "EUR")
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	timeline := NewPriceTimeline(isbn, price, now)
+	if err := timeline.Schedule(price, now.Add(-time.Hour), time.Time{}, now); err == nil {
+		t.Fatal("expected error for a price scheduled in the past")
+	}
+}
+
+func TestPriceTimeline_ScheduleLeavesEarlierCopiesAlone(t *testing.T) {
+	isbn, _ := NewISBN("9780306406157")
+	regular, _ := NewMoney(2000, "EUR")
+	sale, _ := NewMoney(1500, "EUR")
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	timeline := NewPriceTimeline(isbn, regular, start)
+	timeline.Schedule(regular, start.AddDate(0, 6, 0), time.Time{}, start)
+	timeline.Schedule(regular, start.AddDate(0, 9, 0), time.Time{}, start)
+	earlier := timeline
+	// Scheduled before the later periods, so sorting moves it forward.
+	timeline.Schedule(sale, start.AddDate(0, 3, 0), start.AddDate(0, 4, 0), start)
+
+	if got := earlier.Periods(); len(got) != 3 || got[1].Price() != regular {
+		t.Errorf("earlier copy changed: %+v", got)
+	}
+	if got := timeline.Periods(); len(got) != 4 || got[1].Price() != sale {
+		t.Errorf("unexpected periods %+v", got)
+	}
+}
@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// PromotionKind identifies what a promotion code grants.
+type PromotionKind string
+
+const (
+	PromotionPercent  PromotionKind = "percent"     // Value percent off eligible lines
+	PromotionFixed    PromotionKind = "fixed"       // Value cents off eligible lines
+	PromotionBuyXGetY PromotionKind = "buy_x_get_y" // for every BuyQuantity+GetQuantity eligible copies, GetQuantity cheapest are free
+)
+
+// PromotionTerms describe what a promotion grants and when it may be used.
+// Zero-valued limits and validity bounds mean "unlimited".
+type PromotionTerms struct {
+	Kind           PromotionKind
+	Value          int
+	BuyQuantity    int
+	GetQuantity    int
+	Genres         []Genre // empty means every genre is eligible
+	ValidFrom      time.Time
+	ValidUntil     time.Time
+	MaxRedemptions int
+	MaxPerCustomer int
+	Stackable      bool // may be combined with other codes and the automatic cart discount
+}
+
+// Promotion is a redeemable code such as "BOOKWEEK20".
+type Promotion struct {
+	code  string
+	terms PromotionTerms
+}
+
+func NewPromotion(code string, terms PromotionTerms) (Promotion, error) {
+	code = NormalizePromotionCode(code)
+	if code == "" {
+		return Promotion{}, errors.New("promotion code must not be empty")
+	}
+	for _, c := range code {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '-' && c != '_' {
+			return Promotion{}, fmt.Errorf("promotion code contains invalid character %q", c)
+		}
+	}
+
+	switch terms.Kind {
+	case PromotionPercent:
+		if terms.Value <= 0 || terms.Value > 100 {
+			return Promotion{}, errors.New("percent promotion value must be between 1 and 100")
+		}
+	case PromotionFixed:
+		if terms.Value <= 0 {
+			return Promotion{}, errors.New("fixed promotion value must be positive")
+		}
+	case PromotionBuyXGetY:
+		if terms.BuyQuantity <= 0 || terms.GetQuantity <= 0 {
+			return Promotion{}, errors.New("buy and get quantities must be positive")
+		}
+	default:
+		return Promotion{}, fmt.Errorf("unknown promotion kind: %s", terms.Kind)
+	}
+
+	for _, g := range terms.Genres {
+		if !isValidGenre(g) {
+			return Promotion{}, fmt.Errorf("unknown genre: %s", g)
+		}
+	}
+	if !terms.ValidFrom.IsZero() && !terms.ValidUntil.IsZero() && !terms.ValidFrom.Before(terms.ValidUntil) {
+		return Promotion{}, errors.New("validity window must end after it starts")
+	}
+	if terms.MaxRedemptions < 0 || terms.MaxPerCustomer < 0 {
+		return Promotion{}, errors.New("redemption limits must not be negative")
+	}
+	terms.Genres = slices.Clone(terms.Genres)
+	return Promotion{code: code, terms: terms}, nil
+}
+
+// NormalizePromotionCode trims and upper-cases a code so lookups are case-insensitive.
+func NormalizePromotionCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (p Promotion) Code() string { return p.code }
+
+// Terms returns a copy of the promotion's terms.
+func (p Promotion) Terms() PromotionTerms {
+	t := p.terms
+	t.Genres = slices.Clone(t.Genres)
+	return t
+}
+
+// IsActive returns true if now falls within the promotion's validity window.
+func (p Promotion) IsActive(now time.Time) bool {
+	if !p.terms.ValidFrom.IsZero() && now.Before(p.terms.ValidFrom) {
+		return false
+	}
+	if !p.terms.ValidUntil.IsZero() && !now.Before(p.terms.ValidUntil) {
+		return false
+	}
+	return true
+}
+
+// AppliesTo returns true if books of the given genre are eligible.
+func (p Promotion) AppliesTo(genre Genre) bool {
+	return len(p.terms.Genres) == 0 || slices.Contains(p.terms.Genres, genre)
+}
+
+// CheckRedeemable returns an error if the promotion cannot be redeemed at now,
+// given how often it has been redeemed overall and by the customer.
+func (p Promotion) CheckRedeemable(now time.Time, total, byCustomer int) error {
+	if !p.IsActive(now) {
+		return fmt.Errorf("promotion %s is not active", p.code)
+	}
+	if p.terms.MaxRedemptions > 0 && total >= p.terms.MaxRedemptions {
+		return fmt.Errorf("promotion %s has been fully redeemed", p.code)
+	}
+	if p.terms.MaxPerCustomer > 0 && byCustomer >= p.terms.MaxPerCustomer {
+		return fmt.Errorf("promotion %s already used the maximum number of times", p.code)
+	}
+	return nil
+}
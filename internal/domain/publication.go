@@ -0,0 +1,153 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DatePrecision records how much of a publication date is actually known.
+// Many older books only carry a year, or a year and month.
+type DatePrecision string
+
+const (
+	PrecisionDay   DatePrecision = "day"
+	PrecisionMonth DatePrecision = "month"
+	PrecisionYear  DatePrecision = "year"
+)
+
+// PublicationDate is a date known to day, month or year precision. Partial
+// dates are anchored at the first instant of their period, so "1975" is
+// treated as 1 January 1975 when computing ages.
+type PublicationDate struct {
+	t         time.Time
+	precision DatePrecision
+}
+
+// NewPublicationDate truncates t to the start of its month or year for the
+// coarser precisions. Day precision keeps t unchanged.
+func NewPublicationDate(t time.Time, precision DatePrecision) (PublicationDate, error) {
+	switch precision {
+	case PrecisionDay:
+	case PrecisionMonth:
+		t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	case PrecisionYear:
+		t = time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return PublicationDate{}, fmt.Errorf("unknown date precision: %s", precision)
+	}
+	return PublicationDate{t: t, precision: precision}, nil
+}
+
+// ParsePublicationDate accepts "2006", "2006-01", "2006-01-02" or an RFC 3339
+// time. Dates without a time zone are taken as UTC.
+func ParsePublicationDate(s string) (PublicationDate, error) {
+	layouts := []struct {
+		layout    string
+		precision DatePrecision
+	}{
+		{time.RFC3339, PrecisionDay},
+		{time.DateOnly, PrecisionDay},
+		{"2006-01", PrecisionMonth},
+		{"2006", PrecisionYear},
+	}
+	for _, l := range layouts {
+		if t, err := time.Parse(l.layout, s); err == nil {
+			return NewPublicationDate(t, l.precision)
+		}
+	}
+	return PublicationDate{}, errors.New("publication date must be YYYY, YYYY-MM, YYYY-MM-DD or an RFC 3339 time")
+}
+
+func (d PublicationDate) Time() time.Time          { return d.t }
+func (d PublicationDate) Precision() DatePrecision { return d.precision }
+
+// String formats the date to its known precision.
+func (d PublicationDate) String() string {
+	switch d.precision {
+	case PrecisionYear:
+		return d.t.Format("2006")
+	case PrecisionMonth:
+		return d.t.Format("2006-01")
+	default:
+		return d.t.Format(time.DateOnly)
+	}
+}
+
+// YearsSince returns the number of whole calendar years between the date and
+// now; a book published on 29 February turns a year older on 1 March in
+// non-leap years. Dates after now give zero.
+func (d PublicationDate) YearsSince(now time.Time) int {
+	years := now.Year() - d.t.Year()
+	if years > 0 && d.t.AddDate(years, 0, 0).After(now) {
+		years--
+	}
+	return max(years, 0)
+}
+
+// AgeThresholds decide when a book counts as a classic or a recent release.
+type AgeThresholds struct {
+	ClassicYears int `json:"classic_years"` // classic once at least this many years old
+	RecentYears  int `json:"recent_years"`  // recent while younger than this many years
+}
+
+// AgePolicy holds age thresholds with optional per-genre overrides.
+type AgePolicy struct {
+	Default AgeThresholds           `json:"default"`
+	Genres  map[Genre]AgeThresholds `json:"genres,omitempty"`
+}
+
+// DefaultAgePolicy makes books classics after 50 years, children's books
+// after 25, and treats anything published within the last year as recent.
+var DefaultAgePolicy = AgePolicy{
+	Default: AgeThresholds{ClassicYears: 50, RecentYears: 1},
+	Genres: map[Genre]AgeThresholds{
+		GenreChildren: {ClassicYears: 25, RecentYears: 1},
+	},
+}
+
+// Validate checks that every threshold is positive.
+func (p AgePolicy) Validate() error {
+	if err := p.Default.validate(); err != nil {
+		return fmt.Errorf("default: %w", err)
+	}
+	for g, t := range p.Genres {
+		if !isValidGenre(g) {
+			return fmt.Errorf("unknown genre: %s", g)
+		}
+		if err := t.validate(); err != nil {
+			return fmt.Errorf("%s: %w", g, err)
+		}
+	}
+	return nil
+}
+
+// For returns the thresholds for a genre. A nil policy behaves like
+// DefaultAgePolicy.
+func (p *AgePolicy) For(g Genre) AgeThresholds {
+	if p == nil {
+		return DefaultAgePolicy.For(g)
+	}
+	if t, ok := p.Genres[g]; ok {
+		return t
+	}
+	return p.Default
+}
+
+// IsClassic reports whether the book is at least the genre's classic age.
+func (p *AgePolicy) IsClassic(b Book, now time.Time) bool {
+	return b.published.YearsSince(now) >= p.For(b.genre).ClassicYears
+}
+
+// IsRecent reports whether the book is younger than the genre's recent age.
+// Books not yet published count as recent.
+func (p *AgePolicy) IsRecent(b Book, now time.Time) bool {
+	return b.published.t.AddDate(p.For(b.genre).RecentYears, 0, 0).After(now)
+}
+
+func (t AgeThresholds) validate() error {
+	if t.ClassicYears <= 0 || t.RecentYears <= 0 {
+		return errors.New("age thresholds must be positive")
+	}
+	return nil
+}
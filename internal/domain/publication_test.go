@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePublicationDate_Precisions(t *testing.T) {
+	cases := []struct {
+		in        string
+		want      time.Time
+		precision DatePrecision
+		display   string
+	}{
+		{"1975", time.Date(1975, 1, 1, 0, 0, 0, 0, time.UTC), PrecisionYear, "1975"},
+		{"1975-08", time.Date(1975, 8, 1, 0, 0, 0, 0, time.UTC), PrecisionMonth, "1975-08"},
+		{"1975-08-14", time.Date(1975, 8, 14, 0, 0, 0, 0, time.UTC), PrecisionDay, "1975-08-14"},
+		{"1975-08-14T10:30:00Z", time.Date(1975, 8, 14, 10, 30, 0, 0, time.UTC), PrecisionDay, "1975-08-14"},
+	}
+	for _, c := range cases {
+		d, err := ParsePublicationDate(c.in)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.in, err)
+		}
+		if !d.Time().Equal(c.want) || d.Precision() != c.precision || d.String() != c.display {
+			t.Errorf("%s: got %v %s %q", c.in, d.Time(), d.Precision(), d.String())
+		}
+	}
+}
+
+func TestParsePublicationDate_Invalid(t *testing.T) {
+	for _, in := range []string{"", "75", "1975-13", "August 1975"} {
+		if _, err := ParsePublicationDate(in); err == nil {
+			t.Errorf("%q: expected error", in)
+		}
+	}
+}
+
+func TestPublicationDate_YearsSinceUsesCalendarYears(t *testing.T) {
+	d, _ := NewPublicationDate(time.Date(1975, 6, 1, 0, 0, 0, 0, time.UTC), PrecisionDay)
+
+	// 50*365 days after publication falls 12 leap days short of the anniversary.
+	if got := d.YearsSince(time.Date(2025, 5, 31, 0, 0, 0, 0, time.UTC)); got != 49 {
+		t.Errorf("expected 49 the day before the anniversary, got %d", got)
+	}
+	if got := d.YearsSince(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)); got != 50 {
+		t.Errorf("expected 50 on the anniversary, got %d", got)
+	}
+}
+
+func TestPublicationDate_LeapDayAnniversary(t *testing.T) {
+	d, _ := NewPublicationDate(time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC), PrecisionDay)
+
+	if got := d.YearsSince(time.Date(2021, 2, 28, 0, 0, 0, 0, time.UTC)); got != 0 {
+		t.Errorf("expected 0 on 28 February, got %d", got)
+	}
+	if got := d.YearsSince(time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)); got != 1 {
+		t.Errorf("expected 1 on 1 March, got %d", got)
+	}
+}
+
+func TestAgePolicy_GenreThresholds(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	published, _ := ParsePublicationDate("1995")
+	isbn, _ := NewISBN("9780306406157")
+	author, _ := NewAuthor("Jane", "Doe")
+	price, _ := NewMoney(1000, "EUR")
+
+	children, _ := NewBook(isbn, "Picture Book", author, price, now, GenreChildren)
+	children = children.WithPublication(published)
+	fiction, _ := NewBook(isbn, "Novel", author, price, now, GenreFiction)
+	fiction = fiction.WithPublication(published)
+
+	if !children.IsClassic(now) {
+		t.Errorf("expected a 30 year old children's book to be a classic")
+	}
+	if fiction.IsClassic(now) {
+		t.Errorf("expected a 30 year old novel not to be a classic")
+	}
+
+	strict := &AgePolicy{Default: AgeThresholds{ClassicYears: 30, RecentYears: 1}}
+	if !strict.IsClassic(fiction, now) {
+		t.Errorf("expected the custom policy to make the novel a classic")
+	}
+}
+
+func TestAgePolicy_Validate(t *testing.T) {
+	if err := DefaultAgePolicy.Validate(); err != nil {
+		t.Fatalf("default policy invalid: %v", err)
+	}
+	bad := AgePolicy{Default: AgeThresholds{ClassicYears: 50, RecentYears: 1}, Genres: map[Genre]AgeThresholds{GenreChildren: {}}}
+	if err := bad.Validate(); err == nil {
+		t.Errorf("expected error for zero thresholds")
+	}
+}
@@ -0,0 +1,307 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// PurchaseOrderStatus tracks a purchase order through its life.
+type PurchaseOrderStatus string
+
+const (
+	// PurchaseOrderDraft is awaiting review; its lines may still change.
+	PurchaseOrderDraft PurchaseOrderStatus = "draft"
+	// PurchaseOrderApproved has been reviewed and may be placed with the supplier.
+	PurchaseOrderApproved PurchaseOrderStatus = "approved"
+	// PurchaseOrderSent has been placed with the supplier; nothing has arrived yet.
+	PurchaseOrderSent PurchaseOrderStatus = "sent"
+	// PurchaseOrderPartiallyReceived has had some but not all copies delivered.
+	PurchaseOrderPartiallyReceived PurchaseOrderStatus = "partially_received"
+	// PurchaseOrderClosed is complete, or was closed with copies outstanding
+	// that the supplier will not deliver.
+	PurchaseOrderClosed PurchaseOrderStatus = "closed"
+)
+
+// PurchaseOrderLine asks a supplier for copies of one book.
+type PurchaseOrderLine struct {
+	isbn       ISBN
+	quantity   int
+	unitCost   Money     // supplier's price per copy, zero until agreed
+	expectedAt time.Time // when the copies should arrive, zero if unknown
+	received   int
+}
+
+func (l PurchaseOrderLine) ISBN() ISBN            { return l.isbn }
+func (l PurchaseOrderLine) Quantity() int         { return l.quantity }
+func (l PurchaseOrderLine) UnitCost() Money       { return l.unitCost }
+func (l PurchaseOrderLine) ExpectedAt() time.Time { return l.expectedAt }
+func (l PurchaseOrderLine) Received() int         { return l.received }
+
+// Outstanding returns the copies not yet delivered.
+func (l PurchaseOrderLine) Outstanding() int {
+	return l.quantity - l.received
+}
+
+// PurchaseOrderReceipt records copies delivered against an order. The landed
+// unit cost is the supplier's price plus the delivery's share of freight,
+// duties and other charges.
+type PurchaseOrderReceipt struct {
+	isbn           ISBN
+	quantity       int
+	unitCost       Money
+	landedUnitCost Money
+	receivedAt     time.Time
+}
+
+func (r PurchaseOrderReceipt) ISBN() ISBN            { return r.isbn }
+func (r PurchaseOrderReceipt) Quantity() int         { return r.quantity }
+func (r PurchaseOrderReceipt) UnitCost() Money       { return r.unitCost }
+func (r PurchaseOrderReceipt) LandedUnitCost() Money { return r.landedUnitCost }
+func (r PurchaseOrderReceipt) ReceivedAt() time.Time { return r.receivedAt }
+
+// PurchaseOrder is an order for books from one supplier, delivered to one
+// location. It moves from draft, optionally through approved, to sent, then
+// partially received and finally closed.
+type PurchaseOrder struct {
+	id         string
+	supplier   string
+	location   string
+	lines      []PurchaseOrderLine
+	receipts   []PurchaseOrderReceipt
+	status     PurchaseOrderStatus
+	createdAt  time.Time
+	approvedBy string
+	approvedAt time.Time
+	sentAt     time.Time
+	closedAt   time.Time
+}
+
+// NewPurchaseOrder starts an empty draft order for the supplier, delivered
+// to DefaultLocation.
+func NewPurchaseOrder(supplier string, now time.Time) PurchaseOrder {
+	return PurchaseOrder{id: NewID("po"), supplier: supplier, location: DefaultLocation, status: PurchaseOrderDraft, createdAt: now}
+}
+
+func (o PurchaseOrder) ID() string                  { return o.id }
+func (o PurchaseOrder) Supplier() string            { return o.supplier }
+func (o PurchaseOrder) Location() string            { return o.location }
+func (o PurchaseOrder) Status() PurchaseOrderStatus { return o.status }
+func (o PurchaseOrder) CreatedAt() time.Time        { return o.createdAt }
+func (o PurchaseOrder) ApprovedBy() string          { return o.approvedBy }
+func (o PurchaseOrder) ApprovedAt() time.Time       { return o.approvedAt }
+func (o PurchaseOrder) SentAt() time.Time           { return o.sentAt }
+func (o PurchaseOrder) ClosedAt() time.Time         { return o.closedAt }
+
+// Lines returns the order's lines ordered by ISBN.
+func (o PurchaseOrder) Lines() []PurchaseOrderLine {
+	return append([]PurchaseOrderLine(nil), o.lines...)
+}
+
+// Receipts returns the deliveries against the order, oldest first.
+func (o PurchaseOrder) Receipts() []PurchaseOrderReceipt {
+	return append([]PurchaseOrderReceipt(nil), o.receipts...)
+}
+
+// Quantity returns the copies of the book on the order.
+func (o PurchaseOrder) Quantity(isbn ISBN) int {
+	for _, l := range o.lines {
+		if l.isbn == isbn {
+			return l.quantity
+		}
+	}
+	return 0
+}
+
+// IsOpen returns true until the order is closed.
+func (o PurchaseOrder) IsOpen() bool {
+	return o.status != PurchaseOrderClosed
+}
+
+// isEditable returns an error once the order has been sent.
+func (o PurchaseOrder) isEditable() error {
+	if o.status != PurchaseOrderDraft && o.status != PurchaseOrderApproved {
+		return fmt.Errorf("purchase order %s is %s", o.id, o.status)
+	}
+	return nil
+}
+
+// DeliverTo changes where an unsent order will be delivered.
+func (o *PurchaseOrder) DeliverTo(location string) error {
+	if err := o.isEditable(); err != nil {
+		return err
+	}
+	o.location = locationOr(location)
+	return nil
+}
+
+// SetLine sets the copies of a book on a draft order, adding the line if
+// needed. A quantity of zero removes the line. A zero unit cost or
+// expectedAt keeps the line's current one.
+func (o *PurchaseOrder) SetLine(isbn ISBN, quantity int, unitCost Money, expectedAt time.Time) error {
+	if o.status != PurchaseOrderDraft {
+		return fmt.Errorf("purchase order %s is %s", o.id, o.status)
+	}
+	if quantity < 0 {
+		return errors.New("quantity must not be negative")
+	}
+	if unitCost.Amount() < 0 {
+		return errors.New("unit cost must not be negative")
+	}
+	o.lines = o.Lines() // copies of an order must not share lines
+	i := slices.IndexFunc(o.lines, func(l PurchaseOrderLine) bool { return l.isbn == isbn })
+	switch {
+	case quantity == 0 && i >= 0:
+		o.lines = slices.Delete(o.lines, i, i+1)
+	case quantity == 0:
+	case i >= 0:
+		o.lines[i].quantity = quantity
+		if unitCost.Currency() != "" {
+			o.lines[i].unitCost = unitCost
+		}
+		if !expectedAt.IsZero() {
+			o.lines[i].expectedAt = expectedAt
+		}
+	default:
+		o.lines = append(o.lines, PurchaseOrderLine{isbn: isbn, quantity: quantity, unitCost: unitCost, expectedAt: expectedAt})
+		slices.SortFunc(o.lines, func(a, b PurchaseOrderLine) int { return strings.Compare(a.isbn.String(), b.isbn.String()) })
+	}
+	return nil
+}
+
+// Approve marks a reviewed draft as ready to place with the supplier.
+func (o *PurchaseOrder) Approve(by string, now time.Time) error {
+	if o.status != PurchaseOrderDraft {
+		return fmt.Errorf("purchase order %s is %s", o.id, o.status)
+	}
+	if len(o.lines) == 0 {
+		return errors.New("purchase order has no lines")
+	}
+	o.status = PurchaseOrderApproved
+	o.approvedBy = by
+	o.approvedAt = now
+	return nil
+}
+
+// Send places a draft or approved order with its supplier. Every line must
+// be costed in the supplier's currency and the order must reach the
+// supplier's minimum. Lines without an expected date are expected after the
+// supplier's lead time.
+func (o *PurchaseOrder) Send(s Supplier, now time.Time) error {
+	if err := o.isEditable(); err != nil {
+		return err
+	}
+	if s.code != o.supplier {
+		return fmt.Errorf("purchase order %s is for supplier %s, not %s", o.id, o.supplier, s.code)
+	}
+	if len(o.lines) == 0 {
+		return errors.New("purchase order has no lines")
+	}
+	value := Money{currency: s.currency}
+	for _, l := range o.lines {
+		if l.unitCost.Currency() != s.currency {
+			return fmt.Errorf("line %s must be costed in %s", l.isbn, s.currency)
+		}
+		value.amount += l.quantity * l.unitCost.amount
+	}
+	if value.amount < s.minimumOrder.amount {
+		return fmt.Errorf("order value %s is below the supplier minimum of %s", value.Display(), s.minimumOrder.Display())
+	}
+	o.lines = o.Lines()
+	for i := range o.lines {
+		if o.lines[i].expectedAt.IsZero() {
+			o.lines[i].expectedAt = now.Add(s.leadTime)
+		}
+	}
+	o.status = PurchaseOrderSent
+	o.sentAt = now
+	return nil
+}
+
+// Receive books a delivery against a sent order and returns its receipts.
+// Deliveries may be partial; receiving more of a book than is outstanding is
+// refused. Charges such as freight and duties, in the order's currency, are
+// spread over the delivered copies in proportion to their cost. The order
+// closes once everything has arrived.
+func (o *PurchaseOrder) Receive(items []StockRequest, charges Money, now time.Time) ([]PurchaseOrderReceipt, error) {
+	if o.status != PurchaseOrderSent && o.status != PurchaseOrderPartiallyReceived {
+		return nil, fmt.Errorf("purchase order %s is %s", o.id, o.status)
+	}
+	if len(items) == 0 {
+		return nil, errors.New("delivery must include at least one book")
+	}
+	if charges.Amount() < 0 {
+		return nil, errors.New("charges must not be negative")
+	}
+	o.lines = o.Lines()
+	currency := o.lines[0].unitCost.currency
+	if charges.Amount() > 0 && charges.Currency() != currency {
+		return nil, fmt.Errorf("charges must be in %s", currency)
+	}
+
+	receipts := make([]PurchaseOrderReceipt, 0, len(items))
+	var value int
+	for _, item := range items {
+		i := slices.IndexFunc(o.lines, func(l PurchaseOrderLine) bool { return l.isbn == item.ISBN })
+		if i < 0 {
+			return nil, fmt.Errorf("book %s is not on purchase order %s", item.ISBN, o.id)
+		}
+		if item.Quantity <= 0 {
+			return nil, errors.New("receipt quantity must be positive")
+		}
+		if item.Quantity > o.lines[i].Outstanding() {
+			return nil, fmt.Errorf("cannot receive %d of %s: only %d outstanding", item.Quantity, item.ISBN, o.lines[i].Outstanding())
+		}
+		o.lines[i].received += item.Quantity
+		cost := o.lines[i].unitCost
+		receipts = append(receipts, PurchaseOrderReceipt{isbn: item.ISBN, quantity: item.Quantity, unitCost: cost, landedUnitCost: cost, receivedAt: now})
+		value += item.Quantity * cost.amount
+	}
+	allocateCharges(receipts, charges.Amount(), value)
+
+	o.receipts = append(o.Receipts(), receipts...)
+	o.status = PurchaseOrderClosed
+	for _, l := range o.lines {
+		if l.Outstanding() > 0 {
+			o.status = PurchaseOrderPartiallyReceived
+		}
+	}
+	if o.status == PurchaseOrderClosed {
+		o.closedAt = now
+	}
+	return receipts, nil
+}
+
+// allocateCharges adds each receipt's share of the charges to its landed
+// unit cost, by cost or, if nothing was costed, by quantity. Shares are
+// rounded down to the cent.
+func allocateCharges(receipts []PurchaseOrderReceipt, charges, value int) {
+	if charges == 0 {
+		return
+	}
+	var copies int
+	for _, r := range receipts {
+		copies += r.quantity
+	}
+	for i, r := range receipts {
+		var share int
+		if value > 0 {
+			share = charges * r.quantity * r.unitCost.amount / value
+		} else {
+			share = charges * r.quantity / copies
+		}
+		receipts[i].landedUnitCost.amount += share / r.quantity
+	}
+}
+
+// Close ends a sent order. Copies still outstanding will not be delivered.
+func (o *PurchaseOrder) Close(now time.Time) error {
+	if o.status != PurchaseOrderSent && o.status != PurchaseOrderPartiallyReceived {
+		return fmt.Errorf("purchase order %s is %s", o.id, o.status)
+	}
+	o.status = PurchaseOrderClosed
+	o.closedAt = now
+	return nil
+}
@@ -0,0 +1,150 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func testSupplier(t *testing.T, minimumCents int) Supplier {
+	t.Helper()
+	minimum, _ := NewMoney(minimumCents, "EUR")
+	s, err := NewSupplier("Acme", "Acme Books", 30, 10*24*time.Hour, minimum)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return s
+}
+
+func testSentOrder(t *testing.T, now time.Time, lines map[ISBN][2]int) PurchaseOrder {
+	t.Helper()
+	po := NewPurchaseOrder("acme", now)
+	for isbn, l := range lines {
+		cost, _ := NewMoney(l[1], "EUR")
+		if err := po.SetLine(isbn, l[0], cost, time.Time{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := po.Send(testSupplier(t, 0), now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return po
+}
+
+func TestNewSupplier_Validation(t *testing.T) {
+	eur, _ := NewMoney(0, "EUR")
+	negative, _ := NewMoney(-1, "EUR")
+	if _, err := NewSupplier("", "", 0, 0, eur); err == nil {
+		t.Error("expected error for empty code")
+	}
+	if _, err := NewSupplier("acme", "", 0, 0, Money{}); err == nil {
+		t.Error("expected error for missing currency")
+	}
+	if _, err := NewSupplier("acme", "", 0, 0, negative); err == nil {
+		t.Error("expected error for negative minimum")
+	}
+	if _, err := NewSupplier("acme", "", -1, 0, eur); err == nil {
+		t.Error("expected error for negative terms")
+	}
+}
+
+func TestPurchaseOrder_SendChecksCostsAndMinimum(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	isbn := testStockBook(t, "9780306406157", 1000, "EUR").ISBN()
+	supplier := testSupplier(t, 5000)
+
+	po := NewPurchaseOrder("acme", now)
+	po.SetLine(isbn, 4, Money{}, time.Time{})
+	if err := po.Send(supplier, now); err == nil {
+		t.Error("expected error sending an uncosted line")
+	}
+	usd, _ := NewMoney(600, "USD")
+	po.SetLine(isbn, 4, usd, time.Time{})
+	if err := po.Send(supplier, now); err == nil {
+		t.Error("expected error sending a line costed in the wrong currency")
+	}
+	eur, _ := NewMoney(600, "EUR")
+	po.SetLine(isbn, 4, eur, time.Time{})
+	if err := po.Send(supplier, now); err == nil {
+		t.Error("expected error sending below the minimum order")
+	}
+	po.SetLine(isbn, 10, Money{}, time.Time{})
+	if err := po.Send(supplier, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if po.Status() != PurchaseOrderSent {
+		t.Errorf("got status %s, want %s", po.Status(), PurchaseOrderSent)
+	}
+	if got := po.Lines()[0].ExpectedAt(); !got.Equal(now.Add(supplier.LeadTime())) {
+		t.Errorf("got expected arrival %v, want the supplier's lead time out", got)
+	}
+	if err := po.SetLine(isbn, 1, Money{}, time.Time{}); err == nil {
+		t.Error("expected error editing a sent order")
+	}
+}
+
+func TestPurchaseOrder_PartialReceiptsThenClosed(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	isbn := testStockBook(t, "9780306406157", 1000, "EUR").ISBN()
+	po := testSentOrder(t, now, map[ISBN][2]int{isbn: {10, 500}})
+
+	if _, err := po.Receive([]StockRequest{{ISBN: isbn, Quantity: 4}}, Money{}, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if po.Status() != PurchaseOrderPartiallyReceived || po.Lines()[0].Outstanding() != 6 {
+		t.Errorf("got status %s with %d outstanding", po.Status(), po.Lines()[0].Outstanding())
+	}
+	if _, err := po.Receive([]StockRequest{{ISBN: isbn, Quantity: 7}}, Money{}, now); err == nil {
+		t.Error("expected error receiving more than outstanding")
+	}
+	if _, err := po.Receive([]StockRequest{{ISBN: isbn, Quantity: 6}}, Money{}, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if po.Status() != PurchaseOrderClosed || po.ClosedAt().IsZero() || len(po.Receipts()) != 2 {
+		t.Errorf("got status %s with %d receipts, want closed with 2", po.Status(), len(po.Receipts()))
+	}
+	if _, err := po.Receive([]StockRequest{{ISBN: isbn, Quantity: 1}}, Money{}, now); err == nil {
+		t.Error("expected error receiving on a closed order")
+	}
+}
+
+func TestPurchaseOrder_LandedCostSpreadsChargesByValue(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	a := testStockBook(t, "9780306406157", 1000, "EUR").ISBN()
+	b := testStockBook(t, "9780140449136", 1000, "EUR").ISBN()
+	po := testSentOrder(t, now, map[ISBN][2]int{a: {10, 300}, b: {5, 600}})
+
+	freight, _ := NewMoney(1200, "EUR")
+	receipts, err := po.Receive([]StockRequest{{ISBN: a, Quantity: 10}, {ISBN: b, Quantity: 5}}, freight, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Each line is worth 30.00, so each carries 6.00 of the freight.
+	if got := receipts[0].LandedUnitCost().Amount(); got != 360 {
+		t.Errorf("landed cost of a: got %d, want 360", got)
+	}
+	if got := receipts[1].LandedUnitCost().Amount(); got != 720 {
+		t.Errorf("landed cost of b: got %d, want 720", got)
+	}
+	usd, _ := NewMoney(100, "USD")
+	po2 := testSentOrder(t, now, map[ISBN][2]int{a: {1, 300}})
+	if _, err := po2.Receive([]StockRequest{{ISBN: a, Quantity: 1}}, usd, now); err == nil {
+		t.Error("expected error for charges in another currency")
+	}
+}
+
+func TestPurchaseOrder_CloseShort(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	isbn := testStockBook(t, "9780306406157", 1000, "EUR").ISBN()
+	draft := NewPurchaseOrder("acme", now)
+	if err := draft.Close(now); err == nil {
+		t.Error("expected error closing an unsent order")
+	}
+	po := testSentOrder(t, now, map[ISBN][2]int{isbn: {10, 500}})
+	po.Receive([]StockRequest{{ISBN: isbn, Quantity: 3}}, Money{}, now)
+	if err := po.Close(now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if po.IsOpen() || po.Lines()[0].Outstanding() != 7 {
+		t.Errorf("got open %v with %d outstanding, want closed with 7", po.IsOpen(), po.Lines()[0].Outstanding())
+	}
+}
@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ReorderPolicy says when and how much of a book to buy. Once the copies
+// available or on their way fall to the reorder point, reorderQuantity
+// copies are ordered from the supplier, arriving after leadTime.
+type ReorderPolicy struct {
+	isbn            ISBN
+	reorderPoint    int
+	reorderQuantity int
+	leadTime        time.Duration
+	supplier        string
+}
+
+func NewReorderPolicy(isbn ISBN, reorderPoint, reorderQuantity int, leadTime time.Duration, supplier string) (ReorderPolicy, error) {
+	supplier = strings.TrimSpace(supplier)
+	switch {
+	case reorderPoint < 0:
+		return ReorderPolicy{}, errors.New("reorder point must not be negative")
+	case reorderQuantity <= 0:
+		return ReorderPolicy{}, errors.New("reorder quantity must be positive")
+	case leadTime < 0:
+		return ReorderPolicy{}, errors.New("lead time must not be negative")
+	case supplier == "":
+		return ReorderPolicy{}, errors.New("supplier must not be empty")
+	}
+	return ReorderPolicy{isbn: isbn, reorderPoint: reorderPoint, reorderQuantity: reorderQuantity, leadTime: leadTime, supplier: supplier}, nil
+}
+
+func (p ReorderPolicy) ISBN() ISBN              { return p.isbn }
+func (p ReorderPolicy) ReorderPoint() int       { return p.reorderPoint }
+func (p ReorderPolicy) ReorderQuantity() int    { return p.reorderQuantity }
+func (p ReorderPolicy) LeadTime() time.Duration { return p.leadTime }
+func (p ReorderPolicy) Supplier() string        { return p.supplier }
+
+// NeedsReorder returns true if the entry's available and in-transit copies,
+// plus onOrder copies already on purchase orders, are at or below the
+// reorder point.
+func (p ReorderPolicy) NeedsReorder(entry StockEntry, onOrder int) bool {
+	return entry.Available()+entry.InTransit()+onOrder <= p.reorderPoint
+}
+
+// SuggestPurchaseOrders drafts purchase orders for every book that needs
+// reordering, one per supplier. Entries should be summed across locations;
+// books without a policy are skipped. onOrder holds the copies already on
+// open purchase orders, so repeated runs do not order the same books twice.
+// Lines are costed at the book's average cost so far, if it has one, for the
+// reviewer to confirm against the supplier's price.
+func SuggestPurchaseOrders(entries []StockEntry, policies []ReorderPolicy, onOrder map[ISBN]int, now time.Time) []PurchaseOrder {
+	byISBN := make(map[ISBN]ReorderPolicy, len(policies))
+	for _, p := range policies {
+		byISBN[p.isbn] = p
+	}
+	bySupplier := make(map[string]*PurchaseOrder)
+	for _, e := range entries {
+		p, ok := byISBN[e.book.ISBN()]
+		if !ok || !p.NeedsReorder(e, onOrder[p.isbn]) {
+			continue
+		}
+		po, ok := bySupplier[p.supplier]
+		if !ok {
+			draft := NewPurchaseOrder(p.supplier, now)
+			po = &draft
+			bySupplier[p.supplier] = po
+		}
+		cost, _ := e.AverageUnitCost()
+		po.lines = append(po.lines, PurchaseOrderLine{isbn: p.isbn, quantity: p.reorderQuantity, unitCost: cost, expectedAt: now.Add(p.leadTime)})
+	}
+
+	result := make([]PurchaseOrder, 0, len(bySupplier))
+	for _, po := range bySupplier {
+		slices.SortFunc(po.lines, func(a, b PurchaseOrderLine) int { return strings.Compare(a.isbn.String(), b.isbn.String()) })
+		result = append(result, *po)
+	}
+	slices.SortFunc(result, func(a, b PurchaseOrder) int { return strings.Compare(a.supplier, b.supplier) })
+	return result
+}
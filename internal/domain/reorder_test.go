@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func testReorderPolicy(t *testing.T, isbn ISBN, point, qty int, supplier string) ReorderPolicy {
+	t.Helper()
+	p, err := NewReorderPolicy(isbn, point, qty, 7*24*time.Hour, supplier)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return p
+}
+
+func TestNewReorderPolicy_Validation(t *testing.T) {
+	isbn := testStockBook(t, "9780306406157", 1000, "EUR").ISBN()
+	tests := []struct {
+		name     string
+		point    int
+		qty      int
+		leadTime time.Duration
+		supplier string
+	}{
+		{"negative point", -1, 5, 0, "acme"},
+		{"zero quantity", 2, 0, 0, "acme"},
+		{"negative lead time", 2, 5, -time.Hour, "acme"},
+		{"no supplier", 2, 5, 0, " "},
+	}
+	for _, tt := range tests {
+		if _, err := NewReorderPolicy(isbn, tt.point, tt.qty, tt.leadTime, tt.supplier); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
+
+func TestSuggestPurchaseOrders_GroupsBySupplier(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	a := testStockBook(t, "9780306406157", 1000, "EUR")
+	b := testStockBook(t, "9780140449136", 1000, "EUR")
+	c := testStockBook(t, "9780131103627", 1000, "EUR")
+	low1, _ := NewStockEntry(a, 2)
+	low2, _ := NewStockEntry(b, 1)
+	plenty, _ := NewStockEntry(c, 50)
+	policies := []ReorderPolicy{
+		testReorderPolicy(t, a.ISBN(), 3, 10, "acme"),
+		testReorderPolicy(t, b.ISBN(), 3, 20, "acme"),
+		testReorderPolicy(t, c.ISBN(), 3, 5, "globex"),
+	}
+
+	drafts := SuggestPurchaseOrders([]StockEntry{low1, low2, plenty}, policies, nil, now)
+	if len(drafts) != 1 {
+		t.Fatalf("expected one draft, got %d", len(drafts))
+	}
+	po := drafts[0]
+	if po.Supplier() != "acme" || po.Status() != PurchaseOrderDraft {
+		t.Errorf("got supplier %s status %s, want acme draft", po.Supplier(), po.Status())
+	}
+	if po.Quantity(a.ISBN()) != 10 || po.Quantity(b.ISBN()) != 20 {
+		t.Errorf("got quantities %d and %d, want 10 and 20", po.Quantity(a.ISBN()), po.Quantity(b.ISBN()))
+	}
+	if got := po.Lines()[0].ExpectedAt(); !got.Equal(now.Add(7 * 24 * time.Hour)) {
+		t.Errorf("got expected arrival %v, want a week out", got)
+	}
+}
+
+func TestSuggestPurchaseOrders_CountsStockOnTheWay(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	book := testStockBook(t, "9780306406157", 1000, "EUR")
+	entry, _ := NewStockEntry(book, 1)
+	policies := []ReorderPolicy{testReorderPolicy(t, book.ISBN(), 3, 10, "acme")}
+
+	if drafts := SuggestPurchaseOrders([]StockEntry{entry}, policies, map[ISBN]int{book.ISBN(): 10}, now); len(drafts) != 0 {
+		t.Errorf("expected no draft while copies are on order, got %d", len(drafts))
+	}
+	entry.inTransit = 5
+	if drafts := SuggestPurchaseOrders([]StockEntry{entry}, policies, nil, now); len(drafts) != 0 {
+		t.Errorf("expected no draft while copies are in transit, got %d", len(drafts))
+	}
+}
+
+func TestPurchaseOrder_EditAndApprove(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	a := testStockBook(t, "9780306406157", 1000, "EUR").ISBN()
+	b := testStockBook(t, "9780140449136", 1000, "EUR").ISBN()
+	po := NewPurchaseOrder("acme", now)
+	if err := po.Approve("alice", now); err == nil {
+		t.Error("expected error approving an empty order")
+	}
+	po.SetLine(a, 5, Money{}, time.Time{})
+	po.SetLine(b, 3, Money{}, time.Time{})
+	copied := po
+	po.SetLine(a, 8, Money{}, time.Time{})
+	po.SetLine(b, 0, Money{}, time.Time{})
+	if po.Quantity(a) != 8 || len(po.Lines()) != 1 {
+		t.Errorf("got %d lines with %d of a, want 1 line with 8", len(po.Lines()), po.Quantity(a))
+	}
+	if copied.Quantity(a) != 5 {
+		t.Errorf("editing changed a copy of the order: got %d, want 5", copied.Quantity(a))
+	}
+
+	if err := po.Approve("alice", now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if po.Status() != PurchaseOrderApproved || po.ApprovedBy() != "alice" {
+		t.Errorf("got status %s approved by %q", po.Status(), po.ApprovedBy())
+	}
+	if err := po.SetLine(a, 1, Money{}, time.Time{}); err == nil {
+		t.Error("expected error editing an approved order")
+	}
+}
@@ -0,0 +1,255 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ReservationStatus tracks a reservation through its life.
+type ReservationStatus string
+
+const (
+	// ReservationActive holds stock until it expires, is released or confirmed.
+	ReservationActive ReservationStatus = "active"
+	// ReservationConfirmed holds stock for a committed sale and never expires.
+	ReservationConfirmed ReservationStatus = "confirmed"
+	// ReservationFulfilled has been shipped; its copies have left the stock.
+	ReservationFulfilled ReservationStatus = "fulfilled"
+)
+
+// Reservation is a hold on copies of one book on behalf of a holder, such as
+// a cart or a customer.
+type Reservation struct {
+	id        string
+	isbn      ISBN
+	location  string
+	condition Condition // empty for new copies
+	serial    string    // set when one serialized copy is held
+	quantity  int
+	holder    string
+	status    ReservationStatus
+	createdAt time.Time
+	expiresAt time.Time
+}
+
+func (r Reservation) ID() string                { return r.id }
+func (r Reservation) ISBN() ISBN                { return r.isbn }
+func (r Reservation) Location() string          { return r.location }
+func (r Reservation) Condition() Condition      { return r.condition.orNew() }
+func (r Reservation) Serial() string            { return r.serial }
+func (r Reservation) Quantity() int             { return r.quantity }
+func (r Reservation) Holder() string            { return r.holder }
+func (r Reservation) Status() ReservationStatus { return r.status }
+func (r Reservation) CreatedAt() time.Time      { return r.createdAt }
+func (r Reservation) ExpiresAt() time.Time      { return r.expiresAt }
+
+// movement records a change to the reserved copies.
+func (r Reservation) movement(typ MovementType, info MovementInfo, at time.Time) Movement {
+	m := newMovement(r.isbn, r.location, typ, r.quantity, info, at)
+	m.condition = r.condition
+	m.copy = SerialCopy{serial: r.serial}
+	m.held = r.id
+	return m
+}
+
+// IsExpired returns true if an active reservation has run out at the given time.
+func (r Reservation) IsExpired(now time.Time) bool {
+	return r.status == ReservationActive && !now.Before(r.expiresAt)
+}
+
+// Hold reserves copies at a location for a holder until now+ttl, recording
+// who holds them.
+func (inv *Inventory) Hold(isbn ISBN, location string, quantity int, holder string, now time.Time, ttl time.Duration) (Reservation, error) {
+	held, err := inv.HoldMany([]StockRequest{{ISBN: isbn, Location: location, Quantity: quantity}}, holder, now, ttl)
+	if err != nil {
+		return Reservation{}, err
+	}
+	return held[0], nil
+}
+
+// HoldMany reserves every request for the holder or, on any shortage, none.
+// Each request becomes its own reservation.
+func (inv *Inventory) HoldMany(reqs []StockRequest, holder string, now time.Time, ttl time.Duration) ([]Reservation, error) {
+	holder = strings.TrimSpace(holder)
+	if holder == "" {
+		return nil, errors.New("reservation holder must not be empty")
+	}
+	if ttl <= 0 {
+		return nil, errors.New("reservation ttl must be positive")
+	}
+	inv.mu.Lock()
+	defer inv.unlock()
+	for _, r := range reqs {
+		e, ok := inv.entries[variantKey(r.ISBN, locationOr(r.Location), r.Condition)]
+		if ok && !e.book.IsReleased(now) {
+			return nil, fmt.Errorf("book %s is not released until %s; pre-order it instead", r.ISBN, e.book.published)
+		}
+	}
+	held := make([]Reservation, 0, len(reqs))
+	ms := make([]Movement, 0, len(reqs))
+	for _, r := range reqs {
+		res := Reservation{
+			id:        NewID("res"),
+			isbn:      r.ISBN,
+			location:  locationOr(r.Location),
+			condition: r.Condition,
+			quantity:  r.Quantity,
+			holder:    holder,
+			status:    ReservationActive,
+			createdAt: now,
+			expiresAt: now.Add(ttl),
+		}
+		held = append(held, res)
+		ms = append(ms, res.movement(MovementReserve, MovementInfo{Actor: holder, Reference: res.id}, now))
+	}
+	if _, err := inv.commitLocked(ms); err != nil {
+		return nil, err
+	}
+	for _, res := range held {
+		inv.reservations[res.id] = res
+	}
+	return held, nil
+}
+
+// FindReservation returns the reservation with the given ID.
+func (inv *Inventory) FindReservation(id string) (Reservation, error) {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	res, ok := inv.reservations[id]
+	if !ok {
+		return Reservation{}, fmt.Errorf("reservation %s not found", id)
+	}
+	return res, nil
+}
+
+// Reservations returns all outstanding reservations, oldest first.
+func (inv *Inventory) Reservations() []Reservation {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	result := make([]Reservation, 0, len(inv.reservations))
+	for _, res := range inv.reservations {
+		result = append(result, res)
+	}
+	slices.SortFunc(result, func(a, b Reservation) int {
+		if c := a.createdAt.Compare(b.createdAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.id, b.id)
+	})
+	return result
+}
+
+// ExtendReservation keeps an active reservation until now+ttl.
+func (inv *Inventory) ExtendReservation(id string, now time.Time, ttl time.Duration) (Reservation, error) {
+	if ttl <= 0 {
+		return Reservation{}, errors.New("reservation ttl must be positive")
+	}
+	return inv.updateReservation(id, now, func(res *Reservation) error {
+		res.expiresAt = now.Add(ttl)
+		return nil
+	})
+}
+
+// ConfirmReservation turns an active reservation into a committed one that
+// no longer expires.
+func (inv *Inventory) ConfirmReservation(id string, now time.Time) (Reservation, error) {
+	return inv.updateReservation(id, now, func(res *Reservation) error {
+		res.status = ReservationConfirmed
+		return nil
+	})
+}
+
+// ReleaseReservation cancels the reservation and returns its copies to
+// available stock.
+func (inv *Inventory) ReleaseReservation(id string, info MovementInfo) (Reservation, error) {
+	inv.mu.Lock()
+	defer inv.unlock()
+	res, ok := inv.reservations[id]
+	if !ok {
+		return Reservation{}, fmt.Errorf("reservation %s not found", id)
+	}
+	if err := inv.dropReservationLocked(res, info, inv.clock.Now()); err != nil {
+		return Reservation{}, err
+	}
+	return res, nil
+}
+
+// FulfilReservation ships the reserved copies, removing them from stock. The
+// reservation must be confirmed or still active.
+func (inv *Inventory) FulfilReservation(id string, info MovementInfo) (Reservation, error) {
+	inv.mu.Lock()
+	defer inv.unlock()
+	res, ok := inv.reservations[id]
+	if !ok {
+		return Reservation{}, fmt.Errorf("reservation %s not found", id)
+	}
+	now := inv.clock.Now()
+	if res.IsExpired(now) {
+		return Reservation{}, fmt.Errorf("reservation %s has expired", id)
+	}
+	if info.Actor == "" {
+		info.Actor = res.holder
+	}
+	info.Reference = res.id
+	if _, err := inv.commitLocked([]Movement{res.movement(MovementFulfil, info, now)}); err != nil {
+		return Reservation{}, err
+	}
+	delete(inv.reservations, id)
+	res.status = ReservationFulfilled
+	return res, nil
+}
+
+// ExpireReservations releases every active reservation that has run out and
+// returns them.
+func (inv *Inventory) ExpireReservations(now time.Time) []Reservation {
+	inv.mu.Lock()
+	defer inv.unlock()
+	var expired []Reservation
+	for _, res := range inv.reservations {
+		if !res.IsExpired(now) {
+			continue
+		}
+		if err := inv.dropReservationLocked(res, MovementInfo{Reason: ReasonHoldExpired}, now); err == nil {
+			expired = append(expired, res)
+		}
+	}
+	return expired
+}
+
+// updateReservation changes an active reservation that has not yet expired.
+func (inv *Inventory) updateReservation(id string, now time.Time, fn func(*Reservation) error) (Reservation, error) {
+	inv.mu.Lock()
+	defer inv.unlock()
+	res, ok := inv.reservations[id]
+	if !ok {
+		return Reservation{}, fmt.Errorf("reservation %s not found", id)
+	}
+	if res.status != ReservationActive {
+		return Reservation{}, fmt.Errorf("reservation %s is %s", id, res.status)
+	}
+	if res.IsExpired(now) {
+		return Reservation{}, fmt.Errorf("reservation %s has expired", id)
+	}
+	if err := fn(&res); err != nil {
+		return Reservation{}, err
+	}
+	inv.reservations[id] = res
+	return res, nil
+}
+
+// dropReservationLocked removes the reservation and records the release of
+// its copies. The caller must hold the write lock.
+func (inv *Inventory) dropReservationLocked(res Reservation, info MovementInfo, at time.Time) error {
+	if info.Actor == "" {
+		info.Actor = res.holder
+	}
+	info.Reference = res.id
+	if _, err := inv.commitLocked([]Movement{res.movement(MovementRelease, info, at)}); err != nil {
+		return err
+	}
+	delete(inv.reservations, res.id)
+	return nil
+}
@@ -0,0 +1,194 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func testReservationInventory(t *testing.T, total int) (*Inventory, Book) {
+	t.Helper()
+	inv := NewInventory(nil)
+	book := testStockBook(t, "9780306406157", 1000, "EUR")
+	entry, _ := NewStockEntry(book, total)
+	if err := inv.Add(entry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return inv, book
+}
+
+func TestInventory_HoldRecordsReservation(t *testing.T) {
+	inv, book := testReservationInventory(t, 5)
+	clock := NewFakeClock(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+
+	res, err := inv.Hold(book.ISBN(), DefaultLocation, 2, "cart_1", clock.Now(), 15*time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Holder() != "cart_1" || res.Quantity() != 2 || res.Status() != ReservationActive {
+		t.Errorf("unexpected reservation: %+v", res)
+	}
+	if want := clock.Now().Add(15 * time.Minute); !res.ExpiresAt().Equal(want) {
+		t.Errorf("expected expiry %v, got %v", want, res.ExpiresAt())
+	}
+	if e, _ := inv.Find(book.ISBN()); e.Reserved() != 2 {
+		t.Errorf("expected 2 reserved, got %d", e.Reserved())
+	}
+	if found, err := inv.FindReservation(res.ID()); err != nil || found.ID() != res.ID() {
+		t.Errorf("expected to find reservation %s, got %v", res.ID(), err)
+	}
+}
+
+func TestInventory_HoldManyAllOrNothing(t *testing.T) {
+	inv, book := testReservationInventory(t, 2)
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	_, err := inv.HoldMany([]StockRequest{{ISBN: book.ISBN(), Quantity: 1}, {ISBN: book.ISBN(), Quantity: 2}}, "cart_1", now, time.Minute)
+	if err == nil {
+		t.Fatal("expected shortage error")
+	}
+	if len(inv.Reservations()) != 0 {
+		t.Errorf("expected no reservations after a failed hold")
+	}
+	if e, _ := inv.Find(book.ISBN()); e.Reserved() != 0 {
+		t.Errorf("expected nothing reserved, got %d", e.Reserved())
+	}
+}
+
+func TestInventory_ExpireReservationsReleasesStock(t *testing.T) {
+	inv, book := testReservationInventory(t, 5)
+	clock := NewFakeClock(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+
+	short, _ := inv.Hold(book.ISBN(), DefaultLocation, 2, "cart_1", clock.Now(), 10*time.Minute)
+	long, _ := inv.Hold(book.ISBN(), DefaultLocation, 1, "cart_2", clock.Now(), time.Hour)
+	confirmed, _ := inv.Hold(book.ISBN(), DefaultLocation, 1, "order_1", clock.Now(), 10*time.Minute)
+	if _, err := inv.ConfirmReservation(confirmed.ID(), clock.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clock.Advance(10 * time.Minute)
+	expired := inv.ExpireReservations(clock.Now())
+	if len(expired) != 1 || expired[0].ID() != short.ID() {
+		t.Fatalf("expected only %s to expire, got %v", short.ID(), expired)
+	}
+	if e, _ := inv.Find(book.ISBN()); e.Reserved() != 2 {
+		t.Errorf("expected 2 copies still reserved, got %d", e.Reserved())
+	}
+	if _, err := inv.FindReservation(long.ID()); err != nil {
+		t.Errorf("expected %s to remain: %v", long.ID(), err)
+	}
+}
+
+func TestInventory_ExtendReservation(t *testing.T) {
+	inv, book := testReservationInventory(t, 5)
+	clock := NewFakeClock(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+	res, _ := inv.Hold(book.ISBN(), DefaultLocation, 1, "cart_1", clock.Now(), 10*time.Minute)
+
+	clock.Advance(5 * time.Minute)
+	extended, err := inv.ExtendReservation(res.ID(), clock.Now(), 10*time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := clock.Now().Add(10 * time.Minute); !extended.ExpiresAt().Equal(want) {
+		t.Errorf("expected expiry %v, got %v", want, extended.ExpiresAt())
+	}
+
+	clock.Advance(10 * time.Minute)
+	if _, err := inv.ExtendReservation(res.ID(), clock.Now(), time.Minute); err == nil {
+		t.Errorf("expected error extending an expired reservation")
+	}
+}
+
+func TestInventory_ReleaseReservation(t *testing.T) {
+	inv, book := testReservationInventory(t, 5)
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	res, _ := inv.Hold(book.ISBN(), DefaultLocation, 3, "cart_1", now, time.Minute)
+
+	if _, err := inv.ReleaseReservation(res.ID(), MovementInfo{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, _ := inv.Find(book.ISBN()); e.Reserved() != 0 {
+		t.Errorf("expected nothing reserved, got %d", e.Reserved())
+	}
+	if _, err := inv.ReleaseReservation(res.ID(), MovementInfo{}); err == nil {
+		t.Errorf("expected error releasing twice")
+	}
+}
+
+func TestInventory_FulfilReservationConsumesStock(t *testing.T) {
+	clock := NewFakeClock(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+	inv := NewInventory(clock)
+	book := testStockBook(t, "9780306406157", 1000, "EUR")
+	entry, _ := NewStockEntry(book, 5)
+	if err := inv.Add(entry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	res, _ := inv.Hold(book.ISBN(), DefaultLocation, 2, "cart_1", clock.Now(), time.Hour)
+
+	shipped, err := inv.FulfilReservation(res.ID(), MovementInfo{Actor: "warehouse"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if shipped.Status() != ReservationFulfilled {
+		t.Errorf("expected fulfilled, got %s", shipped.Status())
+	}
+	e, _ := inv.Find(book.ISBN())
+	if e.Total() != 3 || e.Reserved() != 0 {
+		t.Errorf("expected 3 total and 0 reserved, got %d and %d", e.Total(), e.Reserved())
+	}
+	if _, err := inv.FulfilReservation(res.ID(), MovementInfo{}); err == nil {
+		t.Errorf("expected error fulfilling twice")
+	}
+	if !inv.Reconcile().Balanced() {
+		t.Errorf("expected balanced ledger after fulfilment")
+	}
+}
+
+func TestInventory_FulfilExpiredReservationFails(t *testing.T) {
+	clock := NewFakeClock(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+	inv := NewInventory(clock)
+	book := testStockBook(t, "9780306406157", 1000, "EUR")
+	entry, _ := NewStockEntry(book, 5)
+	if err := inv.Add(entry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	res, _ := inv.Hold(book.ISBN(), DefaultLocation, 2, "cart_1", clock.Now(), time.Minute)
+
+	clock.Advance(time.Minute)
+	if _, err := inv.FulfilReservation(res.ID(), MovementInfo{}); err == nil {
+		t.Errorf("expected error fulfilling an expired reservation")
+	}
+}
+
+func TestInventory_ReturnsToSellableOrDamaged(t *testing.T) {
+	inv := NewInventory(nil)
+	book := testStockBook(t, "9780306406157", 1000, "EUR")
+	entry, _ := NewStockEntry(book, 1)
+	if err := inv.Add(entry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	inv.Return(book.ISBN(), DefaultLocation, 2, ReturnSellable, MovementInfo{})
+	e, err := inv.Return(book.ISBN(), DefaultLocation, 1, ReturnDamaged, MovementInfo{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e.Total() != 3 || e.Available() != 3 || e.Damaged() != 1 {
+		t.Errorf("expected 3 sellable and 1 damaged, got %d, %d available, %d damaged", e.Total(), e.Available(), e.Damaged())
+	}
+	if _, err := inv.Return(book.ISBN(), DefaultLocation, 1, "lost", MovementInfo{}); err == nil {
+		t.Errorf("expected error for unknown condition")
+	}
+}
+
+func TestStockEntry_FulfilNeedsReservedCopies(t *testing.T) {
+	book := testStockBook(t, "9780306406157", 1000, "EUR")
+	entry, _ := NewStockEntry(book, 5)
+	entry.Reserve(1)
+
+	if err := entry.Fulfil(2); err == nil {
+		t.Errorf("expected error fulfilling more than reserved")
+	}
+	if err := entry.Fulfil(1); err != nil || entry.Total() != 4 || entry.Reserved() != 0 {
+		t.Errorf("expected 4 total and 0 reserved, got %d, %d (%v)", entry.Total(), entry.Reserved(), err)
+	}
+}
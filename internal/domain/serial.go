@@ -0,0 +1,361 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// CopyStatus tracks a serialized copy from the shelf to its sale.
+type CopyStatus string
+
+const (
+	CopyAvailable CopyStatus = "available"
+	CopyReserved  CopyStatus = "reserved"
+	CopySold      CopyStatus = "sold"
+)
+
+// SerialCopy is one physical copy of a book tracked on its own, such as a
+// rare, signed or first-edition copy. It carries its own price and
+// description and is reserved by serial rather than by quantity.
+type SerialCopy struct {
+	serial      string
+	book        Book
+	location    string
+	condition   Condition
+	price       Money
+	printing    PublicationDate // when this copy was printed; zero if unknown
+	provenance  string
+	signature   string
+	photos      []string
+	status      CopyStatus
+	reservation string // the reservation holding the copy, or that sold it
+	addedAt     time.Time
+}
+
+// NewSerialCopy describes a copy with a unique serial, a condition and the
+// price it sells for.
+func NewSerialCopy(serial string, condition Condition, price Money) (SerialCopy, error) {
+	serial = strings.TrimSpace(serial)
+	if serial == "" {
+		return SerialCopy{}, errors.New("copy serial must not be empty")
+	}
+	if !slices.Contains(conditions, condition.orNew()) {
+		return SerialCopy{}, fmt.Errorf("unknown condition %q", condition)
+	}
+	if price.Amount() < 0 {
+		return SerialCopy{}, errors.New("price must not be negative")
+	}
+	return SerialCopy{serial: serial, condition: condition.orNew(), price: price}, nil
+}
+
+// WithProvenance returns a copy with notes on where it has been, such as
+// previous owners or the auction it was bought at.
+func (c SerialCopy) WithProvenance(notes string) SerialCopy {
+	c.provenance = strings.TrimSpace(notes)
+	return c
+}
+
+// WithSignature returns a copy described as signed, e.g. "signed by the
+// author on the title page".
+func (c SerialCopy) WithSignature(signature string) SerialCopy {
+	c.signature = strings.TrimSpace(signature)
+	return c
+}
+
+// WithPrinting returns a copy printed at the given date, which may be long
+// before the catalogued edition.
+func (c SerialCopy) WithPrinting(d PublicationDate) SerialCopy {
+	c.printing = d
+	return c
+}
+
+// WithPhotos returns a copy with photos of its condition, referenced by
+// relative path.
+func (c SerialCopy) WithPhotos(paths ...string) (SerialCopy, error) {
+	photos := make([]string, 0, len(paths))
+	for _, p := range paths {
+		p = strings.TrimSpace(p)
+		if p == "" || strings.HasPrefix(p, "/") || slices.Contains(strings.Split(p, "/"), "..") {
+			return SerialCopy{}, fmt.Errorf("photo path %q must be relative", p)
+		}
+		photos = append(photos, p)
+	}
+	c.photos = photos
+	return c, nil
+}
+
+func (c SerialCopy) Serial() string       { return c.serial }
+func (c SerialCopy) Book() Book           { return c.book }
+func (c SerialCopy) Location() string     { return c.location }
+func (c SerialCopy) Condition() Condition { return c.condition }
+func (c SerialCopy) Price() Money         { return c.price }
+func (c SerialCopy) Provenance() string   { return c.provenance }
+func (c SerialCopy) Signature() string    { return c.signature }
+func (c SerialCopy) IsSigned() bool       { return c.signature != "" }
+func (c SerialCopy) Status() CopyStatus   { return c.status }
+func (c SerialCopy) Reservation() string  { return c.reservation }
+func (c SerialCopy) AddedAt() time.Time   { return c.addedAt }
+func (c SerialCopy) Photos() []string     { return slices.Clone(c.photos) }
+
+func (c SerialCopy) clone() SerialCopy {
+	c.photos = c.Photos()
+	return c
+}
+
+// Printing returns when the copy was printed, if known.
+func (c SerialCopy) Printing() (PublicationDate, bool) {
+	return c.printing, !c.printing.t.IsZero()
+}
+
+// Offer returns the book as sold in this copy: at the copy's price and, if
+// known, dated by its printing, so that age-based pricing such as the
+// classic surcharge applies to the copy itself.
+func (c SerialCopy) Offer() Book {
+	b := c.book
+	b.price = c.price
+	if !c.printing.t.IsZero() {
+		b.published = c.printing
+	}
+	return b
+}
+
+// Copies returns the entry's serialized copies, sold ones included, in the
+// order they were added.
+func (s StockEntry) Copies() []SerialCopy {
+	result := make([]SerialCopy, 0, len(s.copies))
+	for _, c := range s.copies {
+		result = append(result, c.clone())
+	}
+	return result
+}
+
+// countCopies returns the number of the entry's serialized copies with the
+// given status.
+func (s StockEntry) countCopies(status CopyStatus) int {
+	var n int
+	for _, c := range s.copies {
+		if c.status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// unserialized returns the available copies that quantity requests may take.
+// Serialized copies are only ever reserved by serial.
+func (s StockEntry) unserialized() int {
+	return s.Available() - s.countCopies(CopyAvailable)
+}
+
+// listValue returns what the entry's copies sell for: serialized copies
+// still held at their own price, the rest at the entry's price.
+func (s StockEntry) listValue() int {
+	value := s.Price().Amount() * s.total
+	for _, c := range s.copies {
+		if c.status == CopyAvailable || c.status == CopyReserved {
+			value += c.price.amount - s.Price().Amount()
+		}
+	}
+	return value
+}
+
+// applyCopy folds a movement of one serialized copy into the entry.
+func (s *StockEntry) applyCopy(m Movement) error {
+	if m.quantity != 1 {
+		return fmt.Errorf("copy %s: quantity must be 1", m.copy.serial)
+	}
+	i := slices.IndexFunc(s.copies, func(c SerialCopy) bool { return c.serial == m.copy.serial })
+	if m.typ == MovementRestock {
+		if i >= 0 {
+			return fmt.Errorf("copy %s is already in stock", m.copy.serial)
+		}
+		c := m.copy.clone()
+		c.status = CopyAvailable
+		s.copies = append(s.copies, c)
+		s.total++
+		return s.checkInvariants()
+	}
+	if i < 0 {
+		return fmt.Errorf("copy %s not found", m.copy.serial)
+	}
+	c := &s.copies[i]
+	want := map[MovementType]CopyStatus{MovementReserve: CopyAvailable, MovementRelease: CopyReserved, MovementFulfil: CopyReserved}
+	status, ok := want[m.typ]
+	if !ok {
+		return fmt.Errorf("copy %s cannot take a %s movement", c.serial, m.typ)
+	}
+	if c.status != status {
+		return fmt.Errorf("copy %s is %s", c.serial, c.status)
+	}
+	switch m.typ {
+	case MovementReserve:
+		c.status, c.reservation = CopyReserved, m.info.Reference
+		s.reserved++
+	case MovementRelease:
+		c.status, c.reservation = CopyAvailable, ""
+		s.reserved--
+	case MovementFulfil:
+		c.status = CopySold
+		s.reserved--
+		s.total--
+	}
+	return s.checkInvariants()
+}
+
+// findCopyLocked returns the key of the entry holding a copy and the copy's
+// index there. The caller must hold the lock.
+func (inv *Inventory) findCopyLocked(serial string) (string, int, bool) {
+	for key, e := range inv.entries {
+		if i := slices.IndexFunc(e.copies, func(c SerialCopy) bool { return c.serial == serial }); i >= 0 {
+			return key, i, true
+		}
+	}
+	return "", 0, false
+}
+
+// AddCopy puts a serialized copy of the book into stock at a location, in
+// the entry for the copy's condition. The entry is created if the book is
+// not stocked there in that condition yet. Serials are unique across the
+// inventory.
+func (inv *Inventory) AddCopy(book Book, location string, c SerialCopy, info MovementInfo) (SerialCopy, error) {
+	if c.serial == "" {
+		return SerialCopy{}, errors.New("copy serial must not be empty")
+	}
+	if c.price.Currency() != book.Price().Currency() {
+		return SerialCopy{}, fmt.Errorf("copy is priced in %s, book is priced in %s", c.price.Currency(), book.Price().Currency())
+	}
+	inv.mu.Lock()
+	defer inv.unlock()
+	location = locationOr(location)
+	if _, ok := inv.locations[location]; !ok {
+		return SerialCopy{}, fmt.Errorf("location %s not found", location)
+	}
+	if _, _, ok := inv.findCopyLocked(c.serial); ok {
+		return SerialCopy{}, fmt.Errorf("copy %s already exists", c.serial)
+	}
+
+	c.book, c.location, c.addedAt = book, location, inv.clock.Now()
+	m := newMovement(book.ISBN(), location, MovementRestock, 1, info, c.addedAt)
+	if c.condition.IsUsed() {
+		m.condition = c.condition
+	}
+	m.copy = c.clone()
+	var created []StockEntry
+	if _, ok := inv.entries[m.key()]; !ok {
+		entry := StockEntry{book: book, location: location, condition: m.condition}
+		if entry.condition.IsUsed() {
+			// A new used variant sells unserialized copies at this copy's
+			// price until repriced.
+			entry.price = c.price
+			if price, ok := inv.variantPriceLocked(book.ISBN(), entry.condition); ok {
+				entry.price = price
+			}
+		}
+		created = append(created, entry)
+	}
+	if _, err := inv.commitLocked([]Movement{m}, created...); err != nil {
+		return SerialCopy{}, err
+	}
+	key, i, _ := inv.findCopyLocked(c.serial)
+	return inv.entries[key].copies[i].clone(), nil
+}
+
+// FindCopy returns the serialized copy with the given serial.
+func (inv *Inventory) FindCopy(serial string) (SerialCopy, error) {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	key, i, ok := inv.findCopyLocked(serial)
+	if !ok {
+		return SerialCopy{}, fmt.Errorf("copy %s not found", serial)
+	}
+	return inv.entries[key].copies[i].clone(), nil
+}
+
+// Copies returns the book's serialized copies in every condition at every
+// location, oldest first.
+func (inv *Inventory) Copies(isbn ISBN) []SerialCopy {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	var result []SerialCopy
+	for _, e := range inv.entries {
+		if e.book.ISBN() == isbn {
+			result = append(result, e.Copies()...)
+		}
+	}
+	slices.SortFunc(result, func(a, b SerialCopy) int {
+		if c := a.addedAt.Compare(b.addedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.serial, b.serial)
+	})
+	return result
+}
+
+// ReviseCopy replaces the description and price of an unsold copy with
+// those of c, which must carry the same serial and condition.
+func (inv *Inventory) ReviseCopy(c SerialCopy) (SerialCopy, error) {
+	inv.mu.Lock()
+	defer inv.unlock()
+	key, i, ok := inv.findCopyLocked(c.serial)
+	if !ok {
+		return SerialCopy{}, fmt.Errorf("copy %s not found", c.serial)
+	}
+	e := inv.entries[key].clone()
+	current := e.copies[i]
+	switch {
+	case current.status == CopySold:
+		return SerialCopy{}, fmt.Errorf("copy %s is sold", c.serial)
+	case c.condition != current.condition:
+		return SerialCopy{}, fmt.Errorf("copy %s is in %s condition; add it again to regrade it", c.serial, current.condition)
+	case c.price.Currency() != current.price.Currency():
+		return SerialCopy{}, fmt.Errorf("copy must be priced in %s", current.price.Currency())
+	}
+	current.price, current.printing = c.price, c.printing
+	current.provenance, current.signature, current.photos = c.provenance, c.signature, c.Photos()
+	e.copies[i] = current
+	inv.entries[key] = e
+	return current.clone(), nil
+}
+
+// HoldCopy reserves one serialized copy for a holder until now+ttl. Like
+// HoldMany, it refuses copies of a book that is not released yet.
+func (inv *Inventory) HoldCopy(serial, holder string, now time.Time, ttl time.Duration) (Reservation, error) {
+	holder = strings.TrimSpace(holder)
+	if holder == "" {
+		return Reservation{}, errors.New("reservation holder must not be empty")
+	}
+	if ttl <= 0 {
+		return Reservation{}, errors.New("reservation ttl must be positive")
+	}
+	inv.mu.Lock()
+	defer inv.unlock()
+	key, i, ok := inv.findCopyLocked(serial)
+	if !ok {
+		return Reservation{}, fmt.Errorf("copy %s not found", serial)
+	}
+	e := inv.entries[key]
+	if !e.book.IsReleased(now) {
+		return Reservation{}, fmt.Errorf("book %s is not released until %s; pre-order it instead", e.book.ISBN(), e.book.published)
+	}
+	c := e.copies[i]
+	res := Reservation{
+		id:        NewID("res"),
+		isbn:      e.book.ISBN(),
+		location:  e.location,
+		condition: e.condition,
+		serial:    c.serial,
+		quantity:  1,
+		holder:    holder,
+		status:    ReservationActive,
+		createdAt: now,
+		expiresAt: now.Add(ttl),
+	}
+	if _, err := inv.commitLocked([]Movement{res.movement(MovementReserve, MovementInfo{Actor: holder, Reference: res.id}, now)}); err != nil {
+		return Reservation{}, err
+	}
+	inv.reservations[res.id] = res
+	return res, nil
+}
@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func testSignedCopy(t *testing.T, inv *Inventory, book Book, serial string) SerialCopy {
+	t.Helper()
+	price, _ := NewMoney(25000, "EUR")
+	c, err := NewSerialCopy(serial, ConditionVeryGood, price)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c, err = inv.AddCopy(book, "", c.WithSignature("signed on the title page"), MovementInfo{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return c
+}
+
+func TestInventory_SerializedCopiesAreReservedBySerial(t *testing.T) {
+	inv := NewInventory(nil)
+	book := testStockBook(t, "9780306406157", 1000, "EUR")
+	price, _ := NewMoney(400, "EUR")
+	used, _ := NewUsedStockEntry(book, ConditionVeryGood, price, 2)
+	if err := inv.Add(used); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testSignedCopy(t, inv, book, "SIG-1")
+
+	if e, _ := inv.FindVariant(book.ISBN(), "", ConditionVeryGood); e.Available() != 3 || len(e.Copies()) != 1 {
+		t.Fatalf("got %d available, %d copies; want 3 and 1", e.Available(), len(e.Copies()))
+	}
+	if got := inv.ValueByCurrency("")["EUR"]; got.Amount() != 2*400+25000 {
+		t.Errorf("got stock value %d, want %d with the signed copy at its own price", got.Amount(), 2*400+25000)
+	}
+	if _, err := inv.HoldMany([]StockRequest{{ISBN: book.ISBN(), Condition: ConditionVeryGood, Quantity: 3}}, "alice", time.Now(), time.Hour); err == nil {
+		t.Error("expected error: a quantity hold must not take the signed copy")
+	}
+	res, err := inv.HoldCopy("SIG-1", "alice", time.Now(), time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := inv.HoldCopy("SIG-1", "bob", time.Now(), time.Hour); err == nil {
+		t.Error("expected error holding a reserved copy")
+	}
+	if c, _ := inv.FindCopy("SIG-1"); c.Status() != CopyReserved || c.Reservation() != res.ID() {
+		t.Errorf("got %s under %q, want reserved under %s", c.Status(), c.Reservation(), res.ID())
+	}
+	if _, err := inv.FulfilReservation(res.ID(), MovementInfo{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c, _ := inv.FindCopy("SIG-1"); c.Status() != CopySold {
+		t.Errorf("got %s, want sold", c.Status())
+	}
+	if e, _ := inv.FindVariant(book.ISBN(), "", ConditionVeryGood); e.Total() != 2 {
+		t.Errorf("got %d total, want 2", e.Total())
+	}
+	if got := inv.ValueByCurrency("")["EUR"]; got.Amount() != 2*400 {
+		t.Errorf("got stock value %d after the sale, want %d", got.Amount(), 2*400)
+	}
+	if ms := inv.Movements(book.ISBN()); ms[len(ms)-1].Serial() != "SIG-1" {
+		t.Errorf("got sale of copy %q, want SIG-1", ms[len(ms)-1].Serial())
+	}
+	if !inv.Reconcile().Balanced() {
+		t.Errorf("ledger does not balance: %+v", inv.Reconcile().Discrepancies)
+	}
+}
+
+func TestInventory_ExpiredCopyHoldFreesTheCopy(t *testing.T) {
+	inv := NewInventory(nil)
+	book := testStockBook(t, "9780306406157", 1000, "EUR")
+	testSignedCopy(t, inv, book, "SIG-1")
+	now := time.Now()
+	inv.HoldCopy("SIG-1", "alice", now, time.Minute)
+	inv.ExpireReservations(now.Add(time.Hour))
+	if c, _ := inv.FindCopy("SIG-1"); c.Status() != CopyAvailable || c.Reservation() != "" {
+		t.Errorf("got %s under %q, want available", c.Status(), c.Reservation())
+	}
+	if !inv.Reconcile().Balanced() {
+		t.Errorf("ledger does not balance: %+v", inv.Reconcile().Discrepancies)
+	}
+}
+
+func TestInventory_CopyOfUnreleasedBookCannotBeHeld(t *testing.T) {
+	inv := NewInventory(nil)
+	isbn, _ := NewISBN("9780306406157")
+	author, _ := NewAuthor("Jane", "Doe")
+	price, _ := NewMoney(1000, "EUR")
+	release := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	book, _ := NewBook(isbn, "Forthcoming", author, price, release, GenreFiction)
+	testSignedCopy(t, inv, book, "SIG-1")
+
+	if _, err := inv.HoldCopy("SIG-1", "alice", release.Add(-time.Hour), time.Hour); err == nil {
+		t.Error("expected error holding a copy before the book's release")
+	}
+	if _, err := inv.HoldCopy("SIG-1", "alice", release, time.Hour); err != nil {
+		t.Errorf("unexpected error on release day: %v", err)
+	}
+}
+
+func TestSerialCopy_Details(t *testing.T) {
+	inv := NewInventory(nil)
+	book := testStockBook(t, "9780306406157", 1000, "EUR")
+	c := testSignedCopy(t, inv, book, "SIG-1")
+	if _, err := inv.AddCopy(book, "", c, MovementInfo{}); err == nil {
+		t.Error("expected error adding a serial twice")
+	}
+	if _, err := c.WithPhotos("/etc/passwd"); err == nil {
+		t.Error("expected error for an absolute photo path")
+	}
+	if _, err := c.WithPhotos("copies/../../secret.jpg"); err == nil {
+		t.Error("expected error for a photo path leaving its directory")
+	}
+
+	first, _ := ParsePublicationDate("1950")
+	c, _ = c.WithPhotos("copies/sig-1/front.jpg")
+	revised, err := inv.ReviseCopy(c.WithPrinting(first).WithProvenance("from the author's estate"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(revised.Photos()) != 1 || revised.Provenance() != "from the author's estate" || !revised.IsSigned() {
+		t.Errorf("unexpected revised copy %+v", revised)
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if book.IsClassic(now) || !revised.Offer().IsClassic(now) {
+		t.Error("a 1950 printing should be a classic though the catalogued edition is not")
+	}
+}
@@ -0,0 +1,317 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// CountKind says what a stock count covers.
+type CountKind string
+
+const (
+	// CountFull counts every title held at a location.
+	CountFull CountKind = "full"
+	// CountCycle counts one genre at a location, so that shelves can be
+	// checked a section at a time without closing the location.
+	CountCycle CountKind = "cycle"
+)
+
+// CountStatus tracks a stock count through its life.
+type CountStatus string
+
+const (
+	// CountOpen is being counted. Counts are blind: expected quantities are
+	// not shown to the counters.
+	CountOpen CountStatus = "open"
+	// CountSubmitted has every title counted and awaits review of its variances.
+	CountSubmitted CountStatus = "submitted"
+	// CountApproved has had its variances posted to the ledger.
+	CountApproved CountStatus = "approved"
+	// CountCancelled was abandoned; nothing was posted.
+	CountCancelled CountStatus = "cancelled"
+)
+
+// Reason codes for stock count adjustments. ReasonStockCount is the default.
+const (
+	ReasonStockCount ReasonCode = "stock_count" // unexplained difference found by counting
+	ReasonShrinkage  ReasonCode = "shrinkage"   // copies gone missing from the shelf
+	ReasonTheft      ReasonCode = "theft"       // copies known to be stolen
+	ReasonMiscount   ReasonCode = "miscount"    // an earlier receipt or sale was booked wrongly
+	ReasonFound      ReasonCode = "found"       // copies turned up that were thought lost
+)
+
+var countReasons = []ReasonCode{ReasonStockCount, ReasonShrinkage, ReasonTheft, ReasonMiscount, ReasonFound}
+
+// IsCountReason reports whether r may explain a stock count adjustment.
+func (r ReasonCode) IsCountReason() bool {
+	return slices.Contains(countReasons, r)
+}
+
+// CountLine is one title in one condition on a stock count. Expected is
+// the system quantity when the line was last counted, or when the count
+// started until it is, so copies sold or received while the count is under
+// way are not taken for a variance.
+type CountLine struct {
+	isbn      ISBN
+	condition Condition // empty for new copies
+	expected  int
+	counted   int
+	isCounted bool
+	reason    ReasonCode
+}
+
+func (l CountLine) ISBN() ISBN           { return l.isbn }
+func (l CountLine) Condition() Condition { return l.condition.orNew() }
+func (l CountLine) Expected() int        { return l.expected }
+func (l CountLine) Counted() int         { return l.counted }
+func (l CountLine) IsCounted() bool      { return l.isCounted }
+func (l CountLine) Reason() ReasonCode   { return l.reason }
+
+// Variance returns counted less expected copies: positive when copies were
+// found, negative when copies are missing.
+func (l CountLine) Variance() int {
+	return l.counted - l.expected
+}
+
+// StockCount is a physical count of the copies on the shelves at one
+// location. Lines are entered blind while the count is open, reviewed once
+// submitted, and on approval every variance is posted to the ledger as an
+// adjustment referencing the count.
+type StockCount struct {
+	id         string
+	kind       CountKind
+	location   string
+	genre      Genre // set for cycle counts
+	lines      []CountLine
+	status     CountStatus
+	createdAt  time.Time
+	updatedAt  time.Time
+	approvedBy string
+}
+
+func (c StockCount) ID() string           { return c.id }
+func (c StockCount) Kind() CountKind      { return c.kind }
+func (c StockCount) Location() string     { return c.location }
+func (c StockCount) Genre() Genre         { return c.genre }
+func (c StockCount) Status() CountStatus  { return c.status }
+func (c StockCount) CreatedAt() time.Time { return c.createdAt }
+func (c StockCount) UpdatedAt() time.Time { return c.updatedAt }
+func (c StockCount) ApprovedBy() string   { return c.approvedBy }
+
+// Lines returns the count's lines ordered by ISBN, then condition from best
+// to worst.
+func (c StockCount) Lines() []CountLine {
+	return append([]CountLine(nil), c.lines...)
+}
+
+// Variances returns the lines whose count differs from the system quantity.
+func (c StockCount) Variances() []CountLine {
+	var result []CountLine
+	for _, l := range c.lines {
+		if l.isCounted && l.Variance() != 0 {
+			result = append(result, l)
+		}
+	}
+	return result
+}
+
+// Uncounted returns the number of lines not counted yet.
+func (c StockCount) Uncounted() int {
+	var n int
+	for _, l := range c.lines {
+		if !l.isCounted {
+			n++
+		}
+	}
+	return n
+}
+
+func (c StockCount) clone() StockCount {
+	c.lines = c.Lines()
+	return c
+}
+
+// adjustCount corrects total stock by a counted variance. Copies that are
+// reserved cannot be counted away, nor can serialized copies, which are
+// tracked one by one.
+func (s *StockEntry) adjustCount(n int) error {
+	if n == 0 {
+		return errors.New("count adjustment must not be zero")
+	}
+	if serialized := s.countCopies(CopyAvailable) + s.countCopies(CopyReserved); s.total+n < serialized {
+		return fmt.Errorf("cannot count away serialized copies: %d held", serialized)
+	}
+	s.total += n
+	return nil
+}
+
+// StartCount opens a stock count at a location, recording the system
+// quantity of every title it covers in each condition held. A cycle count covers one genre and
+// needs it named; a full count covers every title at the location.
+func (inv *Inventory) StartCount(kind CountKind, location string, genre Genre) (StockCount, error) {
+	switch {
+	case kind == CountCycle && genre == "":
+		return StockCount{}, errors.New("cycle count must name a genre")
+	case kind == CountFull && genre != "":
+		return StockCount{}, errors.New("full count covers every genre")
+	case kind != CountCycle && kind != CountFull:
+		return StockCount{}, fmt.Errorf("unknown count kind %q: want full or cycle", kind)
+	}
+	inv.mu.Lock()
+	defer inv.unlock()
+	location = locationOr(location)
+	if _, ok := inv.locations[location]; !ok {
+		return StockCount{}, fmt.Errorf("location %s not found", location)
+	}
+
+	now := inv.clock.Now()
+	c := StockCount{id: NewID("cnt"), kind: kind, location: location, genre: genre, status: CountOpen, createdAt: now, updatedAt: now}
+	for _, e := range inv.entries {
+		if e.location == location && (genre == "" || e.book.Genre() == genre) {
+			c.lines = append(c.lines, CountLine{isbn: e.book.ISBN(), condition: e.condition, expected: e.total})
+		}
+	}
+	if len(c.lines) == 0 {
+		return StockCount{}, fmt.Errorf("nothing to count at %s", location)
+	}
+	slices.SortFunc(c.lines, func(a, b CountLine) int {
+		if c := strings.Compare(a.isbn.String(), b.isbn.String()); c != 0 {
+			return c
+		}
+		return slices.Index(conditions, a.Condition()) - slices.Index(conditions, b.Condition())
+	})
+	inv.counts[c.id] = c
+	return c.clone(), nil
+}
+
+// RecordCount enters the copies counted of a title in a condition on an
+// open count, and takes the system quantity they are compared with at the
+// same moment. Counting a title again replaces the earlier figures.
+func (inv *Inventory) RecordCount(id string, isbn ISBN, condition Condition, counted int) (StockCount, error) {
+	if counted < 0 {
+		return StockCount{}, errors.New("counted quantity must not be negative")
+	}
+	return inv.updateCount(id, func(c *StockCount) error {
+		i := slices.IndexFunc(c.lines, func(l CountLine) bool { return l.isbn == isbn && l.Condition() == condition.orNew() })
+		if i < 0 {
+			return fmt.Errorf("book %s in %s condition is not on count %s", isbn, condition.orNew(), c.id)
+		}
+		c.lines[i].expected = inv.entries[variantKey(isbn, c.location, c.lines[i].condition)].total
+		c.lines[i].counted = counted
+		c.lines[i].isCounted = true
+		return nil
+	}, CountOpen)
+}
+
+// SubmitCount closes an open count for review once every title is counted.
+func (inv *Inventory) SubmitCount(id string) (StockCount, error) {
+	return inv.updateCount(id, func(c *StockCount) error {
+		if n := c.Uncounted(); n > 0 {
+			return fmt.Errorf("count %s has %d titles still to count", c.id, n)
+		}
+		c.status = CountSubmitted
+		return nil
+	}, CountOpen)
+}
+
+// ApproveCount posts a submitted count's variances to the ledger as count
+// adjustments, each with a reason code from reasons or ReasonStockCount. A
+// book's reason applies to its copies in every condition.
+// Variances are applied to the stock as it is now, so sales made while the
+// count was under way, before or after a title was counted, are kept and not
+// counted again. Either every adjustment posts or none does.
+func (inv *Inventory) ApproveCount(id string, reasons map[ISBN]ReasonCode, info MovementInfo) (StockCount, error) {
+	for isbn, reason := range reasons {
+		if !reason.IsCountReason() {
+			return StockCount{}, fmt.Errorf("book %s: unknown count reason %q", isbn, reason)
+		}
+	}
+	return inv.updateCount(id, func(c *StockCount) error {
+		now := inv.clock.Now()
+		var ms []Movement
+		for i, l := range c.lines {
+			if l.Variance() == 0 {
+				continue
+			}
+			reason := reasons[l.isbn]
+			if reason == "" {
+				reason = ReasonStockCount
+			}
+			c.lines[i].reason = reason
+			typ, n := MovementCountGain, l.Variance()
+			if n < 0 {
+				typ, n = MovementCountLoss, -n
+			}
+			m := MovementInfo{Reason: reason, Actor: info.Actor, Reference: c.id}
+			adjustment := newMovement(l.isbn, c.location, typ, n, m, now)
+			adjustment.condition = l.condition
+			ms = append(ms, adjustment)
+		}
+		if _, err := inv.commitLocked(ms); err != nil {
+			return err
+		}
+		c.status = CountApproved
+		c.approvedBy = info.Actor
+		return nil
+	}, CountSubmitted)
+}
+
+// CancelCount abandons an open or submitted count without posting anything.
+func (inv *Inventory) CancelCount(id string) (StockCount, error) {
+	return inv.updateCount(id, func(c *StockCount) error {
+		c.status = CountCancelled
+		return nil
+	}, CountOpen, CountSubmitted)
+}
+
+// FindCount returns the stock count with the given ID.
+func (inv *Inventory) FindCount(id string) (StockCount, error) {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	c, ok := inv.counts[id]
+	if !ok {
+		return StockCount{}, fmt.Errorf("stock count %s not found", id)
+	}
+	return c.clone(), nil
+}
+
+// Counts returns every stock count, oldest first.
+func (inv *Inventory) Counts() []StockCount {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	result := make([]StockCount, 0, len(inv.counts))
+	for _, c := range inv.counts {
+		result = append(result, c.clone())
+	}
+	slices.SortFunc(result, func(a, b StockCount) int {
+		if c := a.createdAt.Compare(b.createdAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.id, b.id)
+	})
+	return result
+}
+
+// updateCount changes a count that is in one of the given statuses. Nothing
+// changes if fn fails.
+func (inv *Inventory) updateCount(id string, fn func(*StockCount) error, statuses ...CountStatus) (StockCount, error) {
+	inv.mu.Lock()
+	defer inv.unlock()
+	c, ok := inv.counts[id]
+	if !ok {
+		return StockCount{}, fmt.Errorf("stock count %s not found", id)
+	}
+	if !slices.Contains(statuses, c.status) {
+		return StockCount{}, fmt.Errorf("stock count %s is %s", id, c.status)
+	}
+	c = c.clone()
+	if err := fn(&c); err != nil {
+		return StockCount{}, err
+	}
+	c.updatedAt = inv.clock.Now()
+	inv.counts[id] = c
+	return c.clone(), nil
+}
@@ -0,0 +1,133 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func testCountInventory(t *testing.T) (*Inventory, Book, Book) {
+	t.Helper()
+	inv := NewInventory(nil)
+	fiction := testStockBook(t, "9780306406157", 1000, "EUR")
+	isbn, _ := NewISBN("9780131103627")
+	author, _ := NewAuthor("Brian", "Kernighan")
+	price, _ := NewMoney(4000, "EUR")
+	science, _ := NewBook(isbn, "The C Programming Language", author, price, time.Date(1988, 4, 1, 0, 0, 0, 0, time.UTC), GenreScience)
+	for _, b := range []Book{fiction, science} {
+		entry, _ := NewStockEntry(b, 10)
+		if err := inv.Add(entry); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	return inv, fiction, science
+}
+
+func TestInventory_CycleCountPostsVariances(t *testing.T) {
+	inv, fiction, _ := testCountInventory(t)
+	c, err := inv.StartCount(CountCycle, "", GenreFiction)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lines := c.Lines(); len(lines) != 1 || lines[0].ISBN() != fiction.ISBN() || lines[0].Expected() != 10 {
+		t.Fatalf("expected the fiction title only, got %+v", lines)
+	}
+	if _, err := inv.SubmitCount(c.ID()); err == nil {
+		t.Error("expected error submitting before every title is counted")
+	}
+	inv.RecordCount(c.ID(), fiction.ISBN(), ConditionNew, 9)
+	if _, err := inv.RecordCount(c.ID(), fiction.ISBN(), ConditionNew, 7); err != nil {
+		t.Fatalf("unexpected error recounting: %v", err)
+	}
+	if _, err := inv.SubmitCount(c.ID()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A sale while the count was reviewed is kept.
+	res, _ := inv.Hold(fiction.ISBN(), "", 1, "alice", time.Now(), time.Hour)
+	if _, err := inv.FulfilReservation(res.ID(), MovementInfo{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c, err = inv.ApproveCount(c.ID(), map[ISBN]ReasonCode{fiction.ISBN(): ReasonTheft}, MovementInfo{Actor: "manager"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v := c.Variances(); c.Status() != CountApproved || len(v) != 1 || v[0].Variance() != -3 || v[0].Reason() != ReasonTheft {
+		t.Errorf("got status %s, variances %+v; want approved with -3 for theft", c.Status(), v)
+	}
+	e, _ := inv.Find(fiction.ISBN())
+	if e.Total() != 6 {
+		t.Errorf("got %d total, want 6", e.Total())
+	}
+	ms := inv.Movements(fiction.ISBN())
+	if last := ms[len(ms)-1]; last.Type() != MovementCountLoss || last.Reference() != c.ID() || last.Actor() != "manager" {
+		t.Errorf("unexpected adjustment movement: %s ref %q by %q", last.Type(), last.Reference(), last.Actor())
+	}
+	if !inv.Reconcile().Balanced() {
+		t.Errorf("ledger does not balance: %+v", inv.Reconcile().Discrepancies)
+	}
+}
+
+func TestInventory_FullCountCoversEveryTitle(t *testing.T) {
+	inv, fiction, science := testCountInventory(t)
+	c, _ := inv.StartCount(CountFull, "", "")
+	if len(c.Lines()) != 2 {
+		t.Fatalf("got %d lines, want 2", len(c.Lines()))
+	}
+	inv.RecordCount(c.ID(), fiction.ISBN(), ConditionNew, 10)
+	inv.RecordCount(c.ID(), science.ISBN(), ConditionNew, 12)
+	inv.SubmitCount(c.ID())
+	if _, err := inv.ApproveCount(c.ID(), map[ISBN]ReasonCode{science.ISBN(): "lost_it"}, MovementInfo{}); err == nil {
+		t.Error("expected error for an unknown reason")
+	}
+	if _, err := inv.ApproveCount(c.ID(), nil, MovementInfo{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, _ := inv.Find(science.ISBN()); e.Total() != 12 {
+		t.Errorf("got %d total, want 12", e.Total())
+	}
+	ms := inv.Movements(fiction.ISBN())
+	if ms[len(ms)-1].Type() == MovementCountGain || ms[len(ms)-1].Type() == MovementCountLoss {
+		t.Error("a title without variance should not be adjusted")
+	}
+}
+
+func TestInventory_CountCannotRemoveReservedCopies(t *testing.T) {
+	inv, fiction, _ := testCountInventory(t)
+	c, _ := inv.StartCount(CountCycle, "", GenreFiction)
+	inv.RecordCount(c.ID(), fiction.ISBN(), ConditionNew, 2)
+	inv.SubmitCount(c.ID())
+	inv.Reserve(fiction.ISBN(), "", 5, MovementInfo{})
+	if _, err := inv.ApproveCount(c.ID(), nil, MovementInfo{}); err == nil {
+		t.Fatal("expected error counting away reserved copies")
+	}
+	if c, _ = inv.FindCount(c.ID()); c.Status() != CountSubmitted {
+		t.Errorf("got status %s, want the count left submitted", c.Status())
+	}
+	if c, _ = inv.CancelCount(c.ID()); c.Status() != CountCancelled {
+		t.Errorf("got status %s, want cancelled", c.Status())
+	}
+	if _, err := inv.StartCount(CountCycle, "", ""); err == nil {
+		t.Error("expected error for a cycle count without a genre")
+	}
+}
+
+func TestInventory_CountKeepsSalesMadeWhileCounting(t *testing.T) {
+	inv, fiction, _ := testCountInventory(t)
+	c, _ := inv.StartCount(CountCycle, "", GenreFiction)
+	testSell(t, inv, fiction, 1)
+	// 9 should be on the shelf; one more has gone missing.
+	inv.RecordCount(c.ID(), fiction.ISBN(), ConditionNew, 8)
+	testSell(t, inv, fiction, 1)
+	inv.SubmitCount(c.ID())
+
+	c, err := inv.ApproveCount(c.ID(), nil, MovementInfo{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v := c.Variances(); len(v) != 1 || v[0].Expected() != 9 || v[0].Variance() != -1 {
+		t.Errorf("got variances %+v, want 1 missing of 9 expected", v)
+	}
+	if e, _ := inv.FindAt(fiction.ISBN(), ""); e.Total() != 7 {
+		t.Errorf("got %d in stock, want 7 after two sales and one missing", e.Total())
+	}
+}
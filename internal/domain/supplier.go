@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Supplier is a publisher or distributor we buy books from, identified by a
+// short code. Orders are placed and invoiced in the supplier's currency.
+type Supplier struct {
+	code             string
+	name             string
+	currency         string
+	paymentTermsDays int           // days after delivery the invoice is due
+	leadTime         time.Duration // usual time from sending an order to delivery
+	minimumOrder     Money         // smallest order value the supplier accepts
+}
+
+func NewSupplier(code, name string, paymentTermsDays int, leadTime time.Duration, minimumOrder Money) (Supplier, error) {
+	code = strings.ToLower(strings.TrimSpace(code))
+	switch {
+	case code == "":
+		return Supplier{}, errors.New("supplier code must not be empty")
+	case strings.ContainsAny(code, "/ "):
+		return Supplier{}, fmt.Errorf("supplier code %q must not contain '/' or spaces", code)
+	case minimumOrder.Currency() == "":
+		return Supplier{}, errors.New("supplier currency must not be empty")
+	case minimumOrder.Amount() < 0:
+		return Supplier{}, errors.New("minimum order must not be negative")
+	case paymentTermsDays < 0:
+		return Supplier{}, errors.New("payment terms must not be negative")
+	case leadTime < 0:
+		return Supplier{}, errors.New("lead time must not be negative")
+	}
+	if name == "" {
+		name = code
+	}
+	return Supplier{
+		code:             code,
+		name:             name,
+		currency:         minimumOrder.Currency(),
+		paymentTermsDays: paymentTermsDays,
+		leadTime:         leadTime,
+		minimumOrder:     minimumOrder,
+	}, nil
+}
+
+func (s Supplier) Code() string            { return s.code }
+func (s Supplier) Name() string            { return s.name }
+func (s Supplier) Currency() string        { return s.currency }
+func (s Supplier) PaymentTermsDays() int   { return s.paymentTermsDays }
+func (s Supplier) LeadTime() time.Duration { return s.leadTime }
+func (s Supplier) MinimumOrder() Money     { return s.minimumOrder }
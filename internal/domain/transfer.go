@@ -0,0 +1,314 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// TransferStatus tracks a transfer order through its life.
+type TransferStatus string
+
+const (
+	// TransferInTransit has left the source and nothing has arrived yet.
+	TransferInTransit TransferStatus = "in_transit"
+	// TransferPartiallyReceived has had some but not all copies arrive.
+	TransferPartiallyReceived TransferStatus = "partially_received"
+	// TransferReceived has had every copy arrive.
+	TransferReceived TransferStatus = "received"
+	// TransferClosed was closed with copies missing; they were written off.
+	TransferClosed TransferStatus = "closed"
+	// TransferCancelled was recalled; copies still in transit went back to the source.
+	TransferCancelled TransferStatus = "cancelled"
+)
+
+// TransferLine is one book on a transfer order. Every shipped copy ends up
+// received, lost or returned to the source; until then it is outstanding.
+type TransferLine struct {
+	isbn     ISBN
+	shipped  int
+	received int
+	lost     int
+	returned int
+}
+
+func (l TransferLine) ISBN() ISBN    { return l.isbn }
+func (l TransferLine) Shipped() int  { return l.shipped }
+func (l TransferLine) Received() int { return l.received }
+func (l TransferLine) Lost() int     { return l.lost }
+func (l TransferLine) Returned() int { return l.returned }
+
+// Outstanding returns the copies still in transit.
+func (l TransferLine) Outstanding() int {
+	return l.shipped - l.received - l.lost - l.returned
+}
+
+// Transfer moves copies from one location to another. Shipping debits the
+// source at once; the copies are held in transit at the destination until
+// they are received, written off or recalled.
+type Transfer struct {
+	id        string
+	from      string
+	to        string
+	lines     []TransferLine
+	status    TransferStatus
+	createdAt time.Time
+	updatedAt time.Time
+}
+
+func (t Transfer) ID() string             { return t.id }
+func (t Transfer) From() string           { return t.from }
+func (t Transfer) To() string             { return t.to }
+func (t Transfer) Status() TransferStatus { return t.status }
+func (t Transfer) CreatedAt() time.Time   { return t.createdAt }
+func (t Transfer) UpdatedAt() time.Time   { return t.updatedAt }
+
+func (t Transfer) Lines() []TransferLine {
+	return append([]TransferLine(nil), t.lines...)
+}
+
+// IsOpen returns true while copies may still arrive.
+func (t Transfer) IsOpen() bool {
+	return t.status == TransferInTransit || t.status == TransferPartiallyReceived
+}
+
+// Discrepancies returns the lines where fewer copies arrived than were
+// shipped and the shortfall was written off.
+func (t Transfer) Discrepancies() []TransferLine {
+	var result []TransferLine
+	for _, l := range t.lines {
+		if l.lost > 0 {
+			result = append(result, l)
+		}
+	}
+	return result
+}
+
+func (t Transfer) clone() Transfer {
+	t.lines = t.Lines()
+	return t
+}
+
+// dispatch takes n available copies out of stock to ship elsewhere.
+func (s *StockEntry) dispatch(n int) error {
+	if n <= 0 {
+		return errors.New("transfer quantity must be positive")
+	}
+	if s.unserialized() < n {
+		return fmt.Errorf("insufficient stock: %d available, %d requested", s.unserialized(), n)
+	}
+	s.total -= n
+	return nil
+}
+
+// expect records n copies on their way to this entry.
+func (s *StockEntry) expect(n int) error {
+	if n <= 0 {
+		return errors.New("transfer quantity must be positive")
+	}
+	s.inTransit += n
+	return nil
+}
+
+// arrive moves n in-transit copies into stock.
+func (s *StockEntry) arrive(n int) error {
+	if n <= 0 {
+		return errors.New("receipt quantity must be positive")
+	}
+	if n > s.inTransit {
+		return fmt.Errorf("cannot receive %d: only %d in transit", n, s.inTransit)
+	}
+	s.inTransit -= n
+	s.total += n
+	return nil
+}
+
+// writeOffTransit stops expecting n in-transit copies.
+func (s *StockEntry) writeOffTransit(n int) error {
+	if n <= 0 {
+		return errors.New("transfer quantity must be positive")
+	}
+	if n > s.inTransit {
+		return fmt.Errorf("cannot write off %d: only %d in transit", n, s.inTransit)
+	}
+	s.inTransit -= n
+	return nil
+}
+
+// Transfer ships new copies from one location to another. Requests for the
+// same book add up; their locations and conditions are ignored. Either every
+// book ships or, on any shortage at the source, none does. The destination
+// gets an empty entry for books it does not stock yet.
+func (inv *Inventory) Transfer(from, to string, reqs []StockRequest, info MovementInfo) (Transfer, error) {
+	from, to = locationOr(from), locationOr(to)
+	if from == to {
+		return Transfer{}, errors.New("transfer source and destination must differ")
+	}
+	if len(reqs) == 0 {
+		return Transfer{}, errors.New("transfer must include at least one book")
+	}
+	inv.mu.Lock()
+	defer inv.unlock()
+	for _, code := range []string{from, to} {
+		if _, ok := inv.locations[code]; !ok {
+			return Transfer{}, fmt.Errorf("location %s not found", code)
+		}
+	}
+
+	now := inv.clock.Now()
+	tr := Transfer{id: NewID("tr"), from: from, to: to, status: TransferInTransit, createdAt: now, updatedAt: now}
+	info.Reference = tr.id
+	var ms []Movement
+	var created []StockEntry
+	for _, r := range reqs {
+		if r.Quantity <= 0 {
+			return Transfer{}, errors.New("transfer quantity must be positive")
+		}
+		source, ok := inv.entries[stockKey(r.ISBN, from)]
+		if !ok {
+			return Transfer{}, fmt.Errorf("book %s not found in inventory at %s", r.ISBN, from)
+		}
+		if i := slices.IndexFunc(tr.lines, func(l TransferLine) bool { return l.isbn == r.ISBN }); i >= 0 {
+			tr.lines[i].shipped += r.Quantity
+		} else {
+			tr.lines = append(tr.lines, TransferLine{isbn: r.ISBN, shipped: r.Quantity})
+			if _, ok := inv.entries[stockKey(r.ISBN, to)]; !ok {
+				created = append(created, StockEntry{book: source.book, location: to})
+			}
+		}
+		ms = append(ms,
+			newMovement(r.ISBN, from, MovementTransferOut, r.Quantity, info, now),
+			newMovement(r.ISBN, to, MovementInTransit, r.Quantity, info, now))
+	}
+	if _, err := inv.commitLocked(ms, created...); err != nil {
+		return Transfer{}, err
+	}
+	inv.transfers[tr.id] = tr
+	return tr.clone(), nil
+}
+
+// ReceiveTransfer books copies that arrived at the destination. Receipts may
+// be partial; receiving more of a book than is still in transit is refused.
+func (inv *Inventory) ReceiveTransfer(id string, reqs []StockRequest, info MovementInfo) (Transfer, error) {
+	if len(reqs) == 0 {
+		return Transfer{}, errors.New("receipt must include at least one book")
+	}
+	return inv.updateTransfer(id, info, func(tr *Transfer, now time.Time) ([]Movement, error) {
+		var ms []Movement
+		for _, r := range reqs {
+			i := slices.IndexFunc(tr.lines, func(l TransferLine) bool { return l.isbn == r.ISBN })
+			if i < 0 {
+				return nil, fmt.Errorf("book %s is not on transfer %s", r.ISBN, tr.id)
+			}
+			if r.Quantity <= 0 {
+				return nil, errors.New("receipt quantity must be positive")
+			}
+			if r.Quantity > tr.lines[i].Outstanding() {
+				return nil, fmt.Errorf("cannot receive %d of %s: only %d outstanding", r.Quantity, r.ISBN, tr.lines[i].Outstanding())
+			}
+			tr.lines[i].received += r.Quantity
+			ms = append(ms, newMovement(r.ISBN, tr.to, MovementTransferIn, r.Quantity, info, now))
+		}
+		tr.status = TransferReceived
+		for _, l := range tr.lines {
+			if l.Outstanding() > 0 {
+				tr.status = TransferPartiallyReceived
+			}
+		}
+		return ms, nil
+	})
+}
+
+// CloseTransfer ends a transfer that will receive nothing more. Copies still
+// in transit are written off as lost and show up as discrepancies.
+func (inv *Inventory) CloseTransfer(id string, info MovementInfo) (Transfer, error) {
+	return inv.updateTransfer(id, info, func(tr *Transfer, now time.Time) ([]Movement, error) {
+		var ms []Movement
+		for i, l := range tr.lines {
+			if n := l.Outstanding(); n > 0 {
+				tr.lines[i].lost += n
+				ms = append(ms, newMovement(l.isbn, tr.to, MovementTransitLoss, n, info, now))
+			}
+		}
+		tr.status = TransferClosed
+		return ms, nil
+	})
+}
+
+// CancelTransfer recalls a transfer: copies still in transit go back into
+// stock at the source. Copies already received stay where they are.
+func (inv *Inventory) CancelTransfer(id string, info MovementInfo) (Transfer, error) {
+	return inv.updateTransfer(id, info, func(tr *Transfer, now time.Time) ([]Movement, error) {
+		var ms []Movement
+		for i, l := range tr.lines {
+			if n := l.Outstanding(); n > 0 {
+				tr.lines[i].returned += n
+				ms = append(ms,
+					newMovement(l.isbn, tr.to, MovementTransferCancel, n, info, now),
+					newMovement(l.isbn, tr.from, MovementTransferReturn, n, info, now))
+			}
+		}
+		tr.status = TransferCancelled
+		return ms, nil
+	})
+}
+
+// FindTransfer returns the transfer with the given ID.
+func (inv *Inventory) FindTransfer(id string) (Transfer, error) {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	tr, ok := inv.transfers[id]
+	if !ok {
+		return Transfer{}, fmt.Errorf("transfer %s not found", id)
+	}
+	return tr.clone(), nil
+}
+
+// Transfers returns every transfer, oldest first.
+func (inv *Inventory) Transfers() []Transfer {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	result := make([]Transfer, 0, len(inv.transfers))
+	for _, tr := range inv.transfers {
+		result = append(result, tr.clone())
+	}
+	slices.SortFunc(result, func(a, b Transfer) int {
+		if c := a.createdAt.Compare(b.createdAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.id, b.id)
+	})
+	return result
+}
+
+// updateTransfer changes an open transfer and commits the movements the
+// change produces, all referencing the transfer. Nothing changes if any
+// movement fails.
+func (inv *Inventory) updateTransfer(id string, info MovementInfo, fn func(*Transfer, time.Time) ([]Movement, error)) (Transfer, error) {
+	inv.mu.Lock()
+	defer inv.unlock()
+	tr, ok := inv.transfers[id]
+	if !ok {
+		return Transfer{}, fmt.Errorf("transfer %s not found", id)
+	}
+	if !tr.IsOpen() {
+		return Transfer{}, fmt.Errorf("transfer %s is %s", id, tr.status)
+	}
+	tr = tr.clone()
+	now := inv.clock.Now()
+	ms, err := fn(&tr, now)
+	if err != nil {
+		return Transfer{}, err
+	}
+	for i := range ms {
+		ms[i].info.Reference = tr.id
+	}
+	if _, err := inv.commitLocked(ms); err != nil {
+		return Transfer{}, err
+	}
+	tr.updatedAt = now
+	inv.transfers[id] = tr
+	return tr.clone(), nil
+}
@@ -0,0 +1,134 @@
+package domain
+
+import "testing"
+
+func testTransferInventory(t *testing.T) (*Inventory, Book) {
+	t.Helper()
+	inv := NewInventory(nil)
+	testLocations(t, inv)
+	book := testStockBook(t, "9780306406157", 1000, "EUR")
+	entry, _ := NewStockEntry(book, 10)
+	if err := inv.Add(entry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return inv, book
+}
+
+func TestInventory_TransferHoldsCopiesInTransit(t *testing.T) {
+	inv, book := testTransferInventory(t)
+	tr, err := inv.Transfer(DefaultLocation, "shop", []StockRequest{{ISBN: book.ISBN(), Quantity: 4}}, MovementInfo{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tr.Status() != TransferInTransit {
+		t.Errorf("got status %s, want %s", tr.Status(), TransferInTransit)
+	}
+	src, _ := inv.FindAt(book.ISBN(), DefaultLocation)
+	dst, _ := inv.FindAt(book.ISBN(), "shop")
+	if src.Total() != 6 || dst.Total() != 0 || dst.InTransit() != 4 {
+		t.Errorf("got source %d, destination %d with %d in transit; want 6, 0, 4", src.Total(), dst.Total(), dst.InTransit())
+	}
+	sum, _ := inv.Find(book.ISBN())
+	if sum.Total()+sum.InTransit() != 10 {
+		t.Errorf("copies went missing: %d total, %d in transit", sum.Total(), sum.InTransit())
+	}
+	if !inv.Reconcile().Balanced() {
+		t.Errorf("ledger does not balance: %+v", inv.Reconcile().Discrepancies)
+	}
+}
+
+func TestInventory_TransferShortageShipsNothing(t *testing.T) {
+	inv, book := testTransferInventory(t)
+	if _, err := inv.Reserve(book.ISBN(), DefaultLocation, 8, MovementInfo{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := inv.Transfer(DefaultLocation, "shop", []StockRequest{{ISBN: book.ISBN(), Quantity: 3}}, MovementInfo{}); err == nil {
+		t.Fatal("expected error shipping reserved copies")
+	}
+	if _, err := inv.FindAt(book.ISBN(), "shop"); err == nil {
+		t.Error("destination entry should not have been created")
+	}
+	if _, err := inv.Transfer(DefaultLocation, DefaultLocation, []StockRequest{{ISBN: book.ISBN(), Quantity: 1}}, MovementInfo{}); err == nil {
+		t.Error("expected error transferring to the same location")
+	}
+}
+
+func TestInventory_PartialReceiptThenClose(t *testing.T) {
+	inv, book := testTransferInventory(t)
+	tr, _ := inv.Transfer(DefaultLocation, "shop", []StockRequest{{ISBN: book.ISBN(), Quantity: 5}}, MovementInfo{})
+
+	tr, err := inv.ReceiveTransfer(tr.ID(), []StockRequest{{ISBN: book.ISBN(), Quantity: 3}}, MovementInfo{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tr.Status() != TransferPartiallyReceived {
+		t.Errorf("got status %s, want %s", tr.Status(), TransferPartiallyReceived)
+	}
+	if _, err := inv.ReceiveTransfer(tr.ID(), []StockRequest{{ISBN: book.ISBN(), Quantity: 3}}, MovementInfo{}); err == nil {
+		t.Error("expected error receiving more than outstanding")
+	}
+
+	tr, err = inv.CloseTransfer(tr.ID(), MovementInfo{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d := tr.Discrepancies()
+	if tr.Status() != TransferClosed || len(d) != 1 || d[0].Lost() != 2 || d[0].Received() != 3 {
+		t.Errorf("unexpected closed transfer: status %s, discrepancies %+v", tr.Status(), d)
+	}
+	dst, _ := inv.FindAt(book.ISBN(), "shop")
+	if dst.Total() != 3 || dst.InTransit() != 0 {
+		t.Errorf("destination: got %d total, %d in transit; want 3, 0", dst.Total(), dst.InTransit())
+	}
+	if _, err := inv.ReceiveTransfer(tr.ID(), []StockRequest{{ISBN: book.ISBN(), Quantity: 1}}, MovementInfo{}); err == nil {
+		t.Error("expected error receiving on a closed transfer")
+	}
+	if !inv.Reconcile().Balanced() {
+		t.Errorf("ledger does not balance: %+v", inv.Reconcile().Discrepancies)
+	}
+}
+
+func TestInventory_FullReceipt(t *testing.T) {
+	inv, book := testTransferInventory(t)
+	tr, _ := inv.Transfer(DefaultLocation, "shop", []StockRequest{{ISBN: book.ISBN(), Quantity: 2}, {ISBN: book.ISBN(), Quantity: 1}}, MovementInfo{})
+	if lines := tr.Lines(); len(lines) != 1 || lines[0].Shipped() != 3 {
+		t.Fatalf("expected one merged line of 3, got %+v", lines)
+	}
+	tr, err := inv.ReceiveTransfer(tr.ID(), []StockRequest{{ISBN: book.ISBN(), Quantity: 3}}, MovementInfo{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tr.Status() != TransferReceived || len(tr.Discrepancies()) != 0 {
+		t.Errorf("got status %s with %d discrepancies, want received with none", tr.Status(), len(tr.Discrepancies()))
+	}
+}
+
+func TestInventory_CancelTransferReturnsOutstanding(t *testing.T) {
+	inv, book := testTransferInventory(t)
+	tr, _ := inv.Transfer(DefaultLocation, "shop", []StockRequest{{ISBN: book.ISBN(), Quantity: 5}}, MovementInfo{})
+	inv.ReceiveTransfer(tr.ID(), []StockRequest{{ISBN: book.ISBN(), Quantity: 1}}, MovementInfo{})
+
+	tr, err := inv.CancelTransfer(tr.ID(), MovementInfo{Actor: "alice"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tr.Status() != TransferCancelled || tr.Lines()[0].Returned() != 4 {
+		t.Errorf("got status %s, returned %d; want cancelled, 4", tr.Status(), tr.Lines()[0].Returned())
+	}
+	src, _ := inv.FindAt(book.ISBN(), DefaultLocation)
+	dst, _ := inv.FindAt(book.ISBN(), "shop")
+	if src.Total() != 9 || dst.Total() != 1 || dst.InTransit() != 0 {
+		t.Errorf("got source %d, destination %d with %d in transit; want 9, 1, 0", src.Total(), dst.Total(), dst.InTransit())
+	}
+	for _, m := range inv.Movements(book.ISBN()) {
+		if m.Type() == MovementTransferReturn && (m.Reference() != tr.ID() || m.Reason() != ReasonTransferCancel) {
+			t.Errorf("unexpected return movement: reference %q, reason %s", m.Reference(), m.Reason())
+		}
+	}
+	if _, err := inv.CancelTransfer(tr.ID(), MovementInfo{}); err == nil {
+		t.Error("expected error cancelling twice")
+	}
+	if !inv.Reconcile().Balanced() {
+		t.Errorf("ledger does not balance: %+v", inv.Reconcile().Discrepancies)
+	}
+}
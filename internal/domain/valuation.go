@@ -0,0 +1,334 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// CostMethod decides which costs go to the copies sold and which stay with
+// the copies still in stock.
+type CostMethod string
+
+const (
+	// CostFIFO assumes the oldest copies are sold first.
+	CostFIFO CostMethod = "fifo"
+	// CostWeightedAverage spreads cost evenly over the copies held,
+	// re-averaging whenever copies come in.
+	CostWeightedAverage CostMethod = "weighted_average"
+	// CostRetail estimates cost from retail value, using the ratio of cost
+	// to retail over all copies taken in, per currency. It is an estimate
+	// for valuation reports and is never used to cost a sale.
+	CostRetail CostMethod = "retail"
+)
+
+// ParseCostMethod returns the cost method with the given name.
+func ParseCostMethod(s string) (CostMethod, error) {
+	switch m := CostMethod(strings.ToLower(strings.TrimSpace(s))); m {
+	case CostFIFO, CostWeightedAverage, CostRetail:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown cost method %q: want fifo, weighted_average or retail", s)
+	}
+}
+
+// PriceLookup returns a book's retail price at a moment, or false if it is
+// not known.
+type PriceLookup func(isbn ISBN, at time.Time) (Money, bool)
+
+// costLayer is a batch of copies taken in at one unit cost.
+type costLayer struct {
+	quantity int
+	unitCost int
+}
+
+// costPool follows the cost of one book's copies in one condition through
+// the ledger under FIFO and weighted average at once. A copy counts from when
+// it is taken in until it is sold or lost, wherever it is held, so transfers
+// between locations leave the pool unchanged. Damaged returns are not taken
+// back in: they have no value to carry.
+type costPool struct {
+	copies int
+	layers []costLayer // for FIFO, oldest first
+	value  int         // for weighted average, the cost of the copies held
+}
+
+// takeIn adds the copies a movement brings in and returns their number and
+// unit cost. Copies arriving without a cost, such as opening stock, restocks,
+// customer returns and copies found by a count, come in at the average cost
+// of the copies held.
+func (p *costPool) takeIn(m Movement) (quantity, unitCost int) {
+	switch m.typ {
+	case MovementReceipt:
+		quantity, unitCost = m.lot.quantity, m.lot.unitCost.amount
+	case MovementOpening, MovementRestock, MovementReturn, MovementCountGain:
+		quantity = m.quantity
+		if p.copies > 0 {
+			unitCost = p.value / p.copies
+		}
+	}
+	if quantity <= 0 {
+		return 0, 0
+	}
+	p.copies += quantity
+	p.layers = append(p.layers, costLayer{quantity: quantity, unitCost: unitCost})
+	p.value += quantity * unitCost
+	return quantity, unitCost
+}
+
+// takeOut removes n copies and returns what they cost under FIFO and under
+// weighted average.
+func (p *costPool) takeOut(n int) (fifo, average int) {
+	n = min(n, p.copies)
+	if n <= 0 {
+		return 0, 0
+	}
+	for left := n; left > 0; {
+		l := &p.layers[0]
+		take := min(left, l.quantity)
+		fifo += take * l.unitCost
+		l.quantity -= take
+		left -= take
+		if l.quantity == 0 {
+			p.layers = p.layers[1:]
+		}
+	}
+	average = p.value * n / p.copies
+	p.copies -= n
+	p.value -= average
+	return fifo, average
+}
+
+// fifoValue returns the cost of the copies held under FIFO.
+func (p *costPool) fifoValue() int {
+	var v int
+	for _, l := range p.layers {
+		v += l.quantity * l.unitCost
+	}
+	return v
+}
+
+// takesOut reports whether a movement removes copies from the business:
+// sales, copies lost in transit and copies a count found missing.
+func (m Movement) takesOut() bool {
+	return m.typ == MovementFulfil || m.isLoss()
+}
+
+func (m Movement) isLoss() bool {
+	return m.typ == MovementTransitLoss || m.typ == MovementCountLoss
+}
+
+// variant identifies a book in one condition, wherever its copies are held.
+type variant struct {
+	isbn      ISBN
+	condition Condition
+}
+
+func (m Movement) variant() variant { return variant{m.isbn, m.Condition()} }
+
+// costLocked runs a movement through its variant's cost pool. A sale or
+// loss is stamped with what the copies it takes out had cost under the
+// inventory's cost method. The caller must hold the write lock.
+func (inv *Inventory) costLocked(m *Movement, currency string) {
+	pool, ok := inv.pools[m.variant()]
+	if !ok {
+		pool = &costPool{}
+		inv.pools[m.variant()] = pool
+	}
+	if !m.takesOut() {
+		pool.takeIn(*m)
+		return
+	}
+	fifo, average := pool.takeOut(m.quantity)
+	m.cost = Money{amount: fifo, currency: currency}
+	if inv.costMethod == CostWeightedAverage {
+		m.cost.amount = average
+	}
+}
+
+// CostMethod returns the method used to cost sales.
+func (inv *Inventory) CostMethod() CostMethod {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	return inv.costMethod
+}
+
+// SetCostMethod changes the method used to cost sales from now on. Sales
+// already made keep their cost. The retail method only estimates, so it
+// cannot be used.
+func (inv *Inventory) SetCostMethod(method CostMethod) error {
+	if method != CostFIFO && method != CostWeightedAverage {
+		return fmt.Errorf("sales cannot be costed with the %s method", method)
+	}
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	inv.costMethod = method
+	return nil
+}
+
+// ValuationLine values one book's stock in one condition.
+type ValuationLine struct {
+	Book       Book
+	Condition  Condition
+	Copies     int   // copies owned, including those in transit
+	Value      Money // cost of the copies owned
+	Sold       int
+	COGS       Money // cost of the copies sold
+	Lost       int   // copies lost in transit or found missing by a count
+	WrittenOff Money // cost of the copies lost
+}
+
+// ValuationTotal sums a valuation's lines in one currency.
+type ValuationTotal struct {
+	Currency   string
+	Titles     int
+	Copies     int
+	Value      Money
+	Sold       int
+	COGS       Money
+	Lost       int
+	WrittenOff Money
+}
+
+// Valuation is the cost of the stock held at a moment, with the cost of the
+// copies sold or lost up to then.
+type Valuation struct {
+	Method CostMethod
+	AsOf   time.Time
+	Lines  []ValuationLine  // ordered by ISBN, then condition from best to worst
+	Totals []ValuationTotal // ordered by currency
+	// CostToRetail is the cost-to-retail ratio per currency in basis points;
+	// set for the retail method only.
+	CostToRetail map[string]int
+}
+
+// bookValuation gathers one book's figures in one condition while the
+// ledger is replayed.
+type bookValuation struct {
+	book             Book // priced at the variant's price
+	pool             costPool
+	sold, lost       int
+	cogs, writtenOff [2]int // FIFO, weighted average
+
+	costIn, retailIn, retailSold, retailLost int // for the retail method
+}
+
+// Valuation replays the ledger up to asOf and values every book's stock in
+// each condition with the given method. Retail prices for the retail method
+// come from prices, falling back to the book's current price; used copies
+// are always at their own price. The other methods ignore prices.
+func (inv *Inventory) Valuation(method CostMethod, asOf time.Time, prices PriceLookup) (Valuation, error) {
+	if _, err := ParseCostMethod(string(method)); err != nil {
+		return Valuation{}, err
+	}
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+
+	retail := func(b Book, used bool, at time.Time) int {
+		if prices != nil && !used {
+			if p, ok := prices(b.isbn, at); ok && p.currency == b.price.currency {
+				return p.amount
+			}
+		}
+		return b.price.amount
+	}
+	books := make(map[variant]*bookValuation)
+	for _, e := range inv.entries {
+		books[variant{e.book.ISBN(), e.Condition()}] = &bookValuation{book: e.Offer()}
+	}
+	for _, m := range inv.ledger {
+		if m.at.After(asOf) {
+			continue
+		}
+		bv, ok := books[m.variant()]
+		if !ok {
+			continue
+		}
+		used := m.condition.IsUsed()
+		switch {
+		case m.typ == MovementFulfil:
+			fifo, average := bv.pool.takeOut(m.quantity)
+			bv.sold += m.quantity
+			bv.cogs[0] += fifo
+			bv.cogs[1] += average
+			bv.retailSold += m.quantity * retail(bv.book, used, m.at)
+		case m.isLoss():
+			fifo, average := bv.pool.takeOut(m.quantity)
+			bv.lost += m.quantity
+			bv.writtenOff[0] += fifo
+			bv.writtenOff[1] += average
+			bv.retailLost += m.quantity * retail(bv.book, used, m.at)
+		default:
+			n, unitCost := bv.pool.takeIn(m)
+			bv.costIn += n * unitCost
+			bv.retailIn += n * retail(bv.book, used, m.at)
+		}
+	}
+
+	v := Valuation{Method: method, AsOf: asOf}
+	costIn, retailIn := make(map[string]int), make(map[string]int)
+	for _, bv := range books {
+		currency := bv.book.price.currency
+		costIn[currency] += bv.costIn
+		retailIn[currency] += bv.retailIn
+	}
+	if method == CostRetail {
+		v.CostToRetail = make(map[string]int, len(costIn))
+		for currency, retailValue := range retailIn {
+			if retailValue > 0 {
+				v.CostToRetail[currency] = costIn[currency] * 10000 / retailValue
+			}
+		}
+	}
+
+	totals := make(map[string]*ValuationTotal)
+	for key, bv := range books {
+		if bv.pool.copies == 0 && bv.sold == 0 && bv.lost == 0 {
+			continue
+		}
+		currency := bv.book.price.currency
+		line := ValuationLine{Book: bv.book, Condition: key.condition, Copies: bv.pool.copies, Sold: bv.sold, Lost: bv.lost}
+		var value, cogs, writtenOff int
+		switch method {
+		case CostFIFO:
+			value, cogs, writtenOff = bv.pool.fifoValue(), bv.cogs[0], bv.writtenOff[0]
+		case CostWeightedAverage:
+			value, cogs, writtenOff = bv.pool.value, bv.cogs[1], bv.writtenOff[1]
+		case CostRetail:
+			if r := retailIn[currency]; r > 0 {
+				ratio := func(retailValue int) int { return retailValue * costIn[currency] / r }
+				value = ratio(bv.pool.copies * retail(bv.book, key.condition.IsUsed(), asOf))
+				cogs, writtenOff = ratio(bv.retailSold), ratio(bv.retailLost)
+			}
+		}
+		line.Value = Money{amount: value, currency: currency}
+		line.COGS = Money{amount: cogs, currency: currency}
+		line.WrittenOff = Money{amount: writtenOff, currency: currency}
+		v.Lines = append(v.Lines, line)
+
+		t, ok := totals[currency]
+		if !ok {
+			t = &ValuationTotal{Currency: currency, Value: Money{currency: currency}, COGS: Money{currency: currency}, WrittenOff: Money{currency: currency}}
+			totals[currency] = t
+		}
+		t.Titles++
+		t.Copies += line.Copies
+		t.Sold += line.Sold
+		t.Lost += line.Lost
+		t.Value.amount += value
+		t.COGS.amount += cogs
+		t.WrittenOff.amount += writtenOff
+	}
+	slices.SortFunc(v.Lines, func(a, b ValuationLine) int {
+		if c := strings.Compare(a.Book.isbn.String(), b.Book.isbn.String()); c != 0 {
+			return c
+		}
+		return slices.Index(conditions, a.Condition) - slices.Index(conditions, b.Condition)
+	})
+	for _, t := range totals {
+		v.Totals = append(v.Totals, *t)
+	}
+	slices.SortFunc(v.Totals, func(a, b ValuationTotal) int { return strings.Compare(a.Currency, b.Currency) })
+	return v, nil
+}
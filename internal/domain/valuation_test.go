@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+// testCostedInventory receives two lots of a EUR 10.00 book: 2 copies at
+// 4.00 on 1 March and 2 at 6.00 on 1 April.
+func testCostedInventory(t *testing.T) (*Inventory, *FakeClock, Book) {
+	t.Helper()
+	clock := NewFakeClock(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	inv := NewInventory(clock)
+	book := testStockBook(t, "9780306406157", 1000, "EUR")
+	for _, cents := range []int{400, 600} {
+		cost, _ := NewMoney(cents, "EUR")
+		lot, _ := NewStockLot(2, cost, "acme", clock.Now())
+		if _, err := inv.ReceiveLot(book, "", lot, MovementInfo{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		clock.Set(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC))
+	}
+	return inv, clock, book
+}
+
+func testSell(t *testing.T, inv *Inventory, book Book, n int) Movement {
+	t.Helper()
+	res, err := inv.Hold(book.ISBN(), "", n, "alice", inv.clock.Now(), time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := inv.FulfilReservation(res.ID(), MovementInfo{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ms := inv.Movements(book.ISBN())
+	return ms[len(ms)-1]
+}
+
+func TestInventory_FulfilmentRecordsCostOfGoodsSold(t *testing.T) {
+	inv, _, book := testCostedInventory(t)
+	m := testSell(t, inv, book, 3)
+	if cost, ok := m.Cost(); !ok || cost.Amount() != 1400 {
+		t.Errorf("got FIFO cost %d, want 1400 (2 x 4.00 + 6.00)", cost.Amount())
+	}
+
+	if err := inv.SetCostMethod(CostWeightedAverage); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m = testSell(t, inv, book, 1)
+	// 20.00 over 4 copies, less 15.00 for the 3 sold at the average.
+	if cost, _ := m.Cost(); cost.Amount() != 500 {
+		t.Errorf("got average cost %d for the last copy, want 500", cost.Amount())
+	}
+	if err := inv.SetCostMethod(CostRetail); err == nil {
+		t.Error("expected error costing sales with the retail method")
+	}
+}
+
+func TestInventory_SaleCostsMatchValuation(t *testing.T) {
+	inv, clock, book := testCostedInventory(t)
+	sold := testSell(t, inv, book, 1).cost.Amount()
+	cost, _ := NewMoney(800, "EUR")
+	lot, _ := NewStockLot(2, cost, "acme", clock.Now())
+	if _, err := inv.ReceiveLot(book, "", lot, MovementInfo{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sold += testSell(t, inv, book, 4).cost.Amount()
+
+	v, err := inv.Valuation(CostFIFO, clock.Now(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 4.00 + 4.00, 6.00 + 6.00 + 8.00, with 8.00 left in stock.
+	if sold != 2800 || v.Lines[0].COGS.Amount() != sold || v.Lines[0].Value.Amount() != 800 {
+		t.Errorf("got sales costed at %d, valuation COGS %d and value %d; want 2800, 2800 and 800",
+			sold, v.Lines[0].COGS.Amount(), v.Lines[0].Value.Amount())
+	}
+}
+
+func TestInventory_ValuationMethods(t *testing.T) {
+	inv, clock, book := testCostedInventory(t)
+	testSell(t, inv, book, 1)
+
+	tests := []struct {
+		method      CostMethod
+		value, cogs int
+	}{
+		{CostFIFO, 1600, 400},
+		{CostWeightedAverage, 1500, 500},
+		// Cost to retail is 2000 / 4000; 3 copies at 10.00 retail.
+		{CostRetail, 1500, 500},
+	}
+	for _, tt := range tests {
+		v, err := inv.Valuation(tt.method, clock.Now(), nil)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.method, err)
+		}
+		if len(v.Lines) != 1 || v.Lines[0].Copies != 3 || v.Lines[0].Sold != 1 {
+			t.Fatalf("%s: unexpected lines %+v", tt.method, v.Lines)
+		}
+		if got := v.Lines[0]; got.Value.Amount() != tt.value || got.COGS.Amount() != tt.cogs {
+			t.Errorf("%s: got value %d, COGS %d; want %d, %d", tt.method, got.Value.Amount(), got.COGS.Amount(), tt.value, tt.cogs)
+		}
+	}
+	if _, err := inv.Valuation("lifo", clock.Now(), nil); err == nil {
+		t.Error("expected error for an unknown method")
+	}
+}
+
+func TestInventory_ValuationAsOf(t *testing.T) {
+	inv, _, _ := testCostedInventory(t)
+	v, _ := inv.Valuation(CostFIFO, time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC), nil)
+	if len(v.Totals) != 1 || v.Totals[0].Copies != 2 || v.Totals[0].Value.Amount() != 800 {
+		t.Errorf("got totals %+v, want 2 copies worth 800 before the second lot", v.Totals)
+	}
+
+	// A markdown to 8.00 lowers the retail valuation.
+	marked := func(isbn ISBN, at time.Time) (Money, bool) {
+		price, _ := NewMoney(800, "EUR")
+		return price, !at.Before(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC))
+	}
+	v, _ = inv.Valuation(CostRetail, time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC), marked)
+	// Taken in: 2 x 10.00 + 2 x 8.00 = 36.00 retail for 20.00 cost.
+	if v.CostToRetail["EUR"] != 5555 || v.Lines[0].Value.Amount() != 1777 {
+		t.Errorf("got ratio %d and value %d, want 5555 and 1777", v.CostToRetail["EUR"], v.Lines[0].Value.Amount())
+	}
+}
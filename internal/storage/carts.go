@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+// CartRepository stores carts in memory. Expired carts are treated as absent.
+type CartRepository struct {
+	mu    sync.RWMutex
+	carts map[string]domain.Cart // keyed by cart ID
+}
+
+func NewCartRepository() *CartRepository {
+	return &CartRepository{carts: make(map[string]domain.Cart)}
+}
+
+func (r *CartRepository) Save(cart domain.Cart) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.carts[cart.ID()] = cart
+}
+
+// FindByID returns the cart with the given ID unless it has expired at now.
+func (r *CartRepository) FindByID(id string, now time.Time) (domain.Cart, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.carts[id]
+	if !ok || c.IsExpired(now) {
+		return domain.Cart{}, fmt.Errorf("cart %s not found", id)
+	}
+	return c, nil
+}
+
+// Update applies fn to the stored cart under the repository lock, so
+// concurrent changes to the same cart are not lost. The cart is saved only if
+// fn succeeds.
+func (r *CartRepository) Update(id string, now time.Time, fn func(*domain.Cart) error) (domain.Cart, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.carts[id]
+	if !ok || c.IsExpired(now) {
+		return domain.Cart{}, fmt.Errorf("cart %s not found", id)
+	}
+	// fn works on a deep copy: readers may still hold the stored cart, and a
+	// failed fn must leave it as it was.
+	c = c.Clone()
+	if err := fn(&c); err != nil {
+		return domain.Cart{}, err
+	}
+	r.carts[id] = c
+	return c, nil
+}
+
+// DeleteExpired removes all carts that have expired at now and returns how many were removed.
+func (r *CartRepository) DeleteExpired(now time.Time) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int
+	for id, c := range r.carts {
+		if c.IsExpired(now) {
+			delete(r.carts, id)
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+func TestCartRepository_UpdateLeavesEarlierCopiesAlone(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	isbn, _ := domain.NewISBN("9780306406157")
+	cart, _ := domain.NewCart("cart_1", "EUR", now, time.Hour)
+	cart.AddLine(isbn, 1)
+	carts := NewCartRepository()
+	carts.Save(cart)
+
+	before, _ := carts.FindByID("cart_1", now)
+	carts.Update("cart_1", now, func(c *domain.Cart) error { return c.AddLine(isbn, 2) })
+	if got := before.Lines()[0].Quantity(); got != 1 {
+		t.Errorf("got %d on a cart read before the update, want 1", got)
+	}
+
+	_, err := carts.Update("cart_1", now, func(c *domain.Cart) error {
+		c.AddLine(isbn, 5)
+		return errors.New("rejected")
+	})
+	if err == nil {
+		t.Fatal("expected error from fn")
+	}
+	if after, _ := carts.FindByID("cart_1", now); after.Lines()[0].Quantity() != 3 {
+		t.Errorf("got %d, want 3: a failed update must not be saved", after.Lines()[0].Quantity())
+	}
+}
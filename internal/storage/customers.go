@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+// CustomerRepository stores customer accounts in memory.
+type CustomerRepository struct {
+	mu        sync.RWMutex
+	customers map[string]domain.Customer // keyed by customer ID
+}
+
+func NewCustomerRepository() *CustomerRepository {
+	return &CustomerRepository{customers: make(map[string]domain.Customer)}
+}
+
+func (r *CustomerRepository) Save(c domain.Customer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.customers[c.ID()] = c
+}
+
+func (r *CustomerRepository) FindByID(id string) (domain.Customer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.customers[id]
+	if !ok {
+		return domain.Customer{}, fmt.Errorf("customer %s not found", id)
+	}
+	return c, nil
+}
@@ -43,6 +43,22 @@ func (r *BookRepository) FindAll() []domain.Book {
 	return result
 }
 
+// Update replaces the stored book with fn's result under the repository lock.
+func (r *BookRepository) Update(isbn string, fn func(domain.Book) (domain.Book, error)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.books[isbn]
+	if !ok {
+		return fmt.Errorf("book %s not found", isbn)
+	}
+	updated, err := fn(b)
+	if err != nil {
+		return err
+	}
+	r.books[isbn] = updated
+	return nil
+}
+
 func (r *BookRepository) Delete(isbn string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"sync"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+// NotificationRepository keeps the allocation notices sent to each customer
+// in memory, oldest first.
+type NotificationRepository struct {
+	mu      sync.RWMutex
+	notices map[string][]domain.Allocation // keyed by customer
+}
+
+func NewNotificationRepository() *NotificationRepository {
+	return &NotificationRepository{notices: make(map[string][]domain.Allocation)}
+}
+
+// Record stores a notice for the backorder's customer. It is meant to be
+// registered with Inventory.OnAllocation.
+func (r *NotificationRepository) Record(a domain.Allocation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	customer := a.Backorder.Customer()
+	r.notices[customer] = append(r.notices[customer], a)
+}
+
+// FindByCustomer returns the notices sent to a customer.
+func (r *NotificationRepository) FindByCustomer(customer string) []domain.Allocation {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]domain.Allocation(nil), r.notices[customer]...)
+}
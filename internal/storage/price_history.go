@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+// PriceHistoryRepository stores a price timeline per book in memory.
+// Timelines outlive the books they belong to.
+type PriceHistoryRepository struct {
+	mu        sync.RWMutex
+	timelines map[string]domain.PriceTimeline // keyed by ISBN string
+}
+
+func NewPriceHistoryRepository() *PriceHistoryRepository {
+	return &PriceHistoryRepository{timelines: make(map[string]domain.PriceTimeline)}
+}
+
+// Record sets the book's price from now on, starting a timeline if it has none.
+func (r *PriceHistoryRepository) Record(book domain.Book, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := book.ISBN().String()
+	t, ok := r.timelines[key]
+	if !ok {
+		r.timelines[key] = domain.NewPriceTimeline(book.ISBN(), book.Price(), now)
+		return nil
+	}
+	if err := t.Schedule(book.Price(), now, time.Time{}, now); err != nil {
+		return err
+	}
+	r.timelines[key] = t
+	return nil
+}
+
+// Schedule adds a future price period to the book's timeline.
+func (r *PriceHistoryRepository) Schedule(isbn string, price domain.Money, from, to, now time.Time) (domain.PriceTimeline, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.timelines[isbn]
+	if !ok {
+		return domain.PriceTimeline{}, fmt.Errorf("no price history for %s", isbn)
+	}
+	if err := t.Schedule(price, from, to, now); err != nil {
+		return domain.PriceTimeline{}, err
+	}
+	r.timelines[isbn] = t
+	return t, nil
+}
+
+func (r *PriceHistoryRepository) FindByISBN(isbn string) (domain.PriceTimeline, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.timelines[isbn]
+	if !ok {
+		return domain.PriceTimeline{}, fmt.Errorf("no price history for %s", isbn)
+	}
+	return t, nil
+}
+
+// PriceAt returns the book's price at the given time.
+func (r *PriceHistoryRepository) PriceAt(isbn string, at time.Time) (domain.Money, error) {
+	t, err := r.FindByISBN(isbn)
+	if err != nil {
+		return domain.Money{}, err
+	}
+	price, ok := t.PriceAt(at)
+	if !ok {
+		return domain.Money{}, fmt.Errorf("%s had no price at %s", isbn, at.Format(time.RFC3339))
+	}
+	return price, nil
+}
+
+// ApplyScheduledPrices updates every stored book whose price differs from
+// its timeline at now, and the book's stock in the inventory, so scheduled
+// changes take effect without manual edits. Returns the number of books
+// updated.
+func ApplyScheduledPrices(books *BookRepository, inventory *domain.Inventory, history *PriceHistoryRepository, now time.Time) int {
+	var updated int
+	for _, b := range books.FindAll() {
+		isbn := b.ISBN().String()
+		price, err := history.PriceAt(isbn, now)
+		if err != nil || price == b.Price() {
+			continue
+		}
+		var repriced domain.Book
+		err = books.Update(isbn, func(current domain.Book) (domain.Book, error) {
+			b, err := current.WithPrice(price)
+			repriced = b
+			return b, err
+		})
+		if err == nil {
+			inventory.UpdateBook(repriced)
+			updated++
+		}
+	}
+	return updated
+}
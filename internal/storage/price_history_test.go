@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+func TestApplyScheduledPrices_RepricesStock(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	isbn, _ := domain.NewISBN("9780306406157")
+	author, _ := domain.NewAuthor("Jane", "Doe")
+	price, _ := domain.NewMoney(1000, "EUR")
+	book, _ := domain.NewBook(isbn, "Stocked", author, price, now, domain.GenreFiction)
+	books := NewBookRepository()
+	books.Save(book)
+	history := NewPriceHistoryRepository()
+	if err := history.Record(book, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	inventory := domain.NewInventory(nil)
+	entry, _ := domain.NewStockEntry(book, 3)
+	if err := inventory.Add(entry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sale, _ := domain.NewMoney(800, "EUR")
+	if _, err := history.Schedule(isbn.String(), sale, now.Add(time.Hour), time.Time{}, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := ApplyScheduledPrices(books, inventory, history, now.Add(2*time.Hour)); n != 1 {
+		t.Fatalf("got %d books updated, want 1", n)
+	}
+
+	if got := inventory.ValueByCurrency("")["EUR"].Amount(); got != 2400 {
+		t.Errorf("got stock value %d, want 2400 at the scheduled price", got)
+	}
+	got, err := inventory.Find(isbn)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Price() != sale {
+		t.Errorf("got price %v, want %v", got.Price(), sale)
+	}
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/calc"
+)
+
+// PriceListRepository stores named price lists in memory.
+type PriceListRepository struct {
+	mu    sync.RWMutex
+	lists map[string]calc.PriceList // keyed by name
+}
+
+func NewPriceListRepository() *PriceListRepository {
+	return &PriceListRepository{lists: make(map[string]calc.PriceList)}
+}
+
+func (r *PriceListRepository) Save(pl calc.PriceList) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lists[pl.Name] = pl.Clone()
+}
+
+func (r *PriceListRepository) FindByName(name string) (calc.PriceList, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	pl, ok := r.lists[name]
+	if !ok {
+		return calc.PriceList{}, fmt.Errorf("price list %s not found", name)
+	}
+	return pl.Clone(), nil
+}
+
+// FindAll returns all price lists sorted by name.
+func (r *PriceListRepository) FindAll() []calc.PriceList {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make([]calc.PriceList, 0, len(r.lists))
+	for _, pl := range r.lists {
+		result = append(result, pl.Clone())
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+func (r *PriceListRepository) Delete(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.lists[name]; !ok {
+		return fmt.Errorf("price list %s not found", name)
+	}
+	delete(r.lists, name)
+	return nil
+}
@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+// ErrPromotionNotFound is returned, wrapped with the code, for a promotion
+// the repository does not hold.
+var ErrPromotionNotFound = errors.New("not found")
+
+// PromotionRepository stores promotions and their redemption counts in memory.
+// Redemptions are checked and recorded under a single lock, so limits hold
+// under concurrent use.
+type PromotionRepository struct {
+	mu          sync.RWMutex
+	promotions  map[string]domain.Promotion // keyed by code
+	redemptions map[string]*redemptionCount
+}
+
+type redemptionCount struct {
+	total      int
+	byCustomer map[string]int
+}
+
+func NewPromotionRepository() *PromotionRepository {
+	return &PromotionRepository{
+		promotions:  make(map[string]domain.Promotion),
+		redemptions: make(map[string]*redemptionCount),
+	}
+}
+
+// Create stores a new promotion unless one with the same code exists.
+func (r *PromotionRepository) Create(p domain.Promotion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.promotions[p.Code()]; ok {
+		return fmt.Errorf("promotion %s already exists", p.Code())
+	}
+	r.promotions[p.Code()] = p
+	r.redemptions[p.Code()] = &redemptionCount{byCustomer: make(map[string]int)}
+	return nil
+}
+
+// Save creates or replaces a promotion. Redemption counts are kept across updates.
+func (r *PromotionRepository) Save(p domain.Promotion) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.promotions[p.Code()] = p
+	if _, ok := r.redemptions[p.Code()]; !ok {
+		r.redemptions[p.Code()] = &redemptionCount{byCustomer: make(map[string]int)}
+	}
+}
+
+func (r *PromotionRepository) FindByCode(code string) (domain.Promotion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.promotions[domain.NormalizePromotionCode(code)]
+	if !ok {
+		return domain.Promotion{}, fmt.Errorf("promotion %s %w", code, ErrPromotionNotFound)
+	}
+	return p, nil
+}
+
+// FindAll returns all promotions sorted by code.
+func (r *PromotionRepository) FindAll() []domain.Promotion {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make([]domain.Promotion, 0, len(r.promotions))
+	for _, p := range r.promotions {
+		result = append(result, p)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Code() < result[j].Code() })
+	return result
+}
+
+func (r *PromotionRepository) Delete(code string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	code = domain.NormalizePromotionCode(code)
+	if _, ok := r.promotions[code]; !ok {
+		return fmt.Errorf("promotion %s not found", code)
+	}
+	delete(r.promotions, code)
+	delete(r.redemptions, code)
+	return nil
+}
+
+// Redemptions returns how often the promotion was redeemed overall and by the customer.
+func (r *PromotionRepository) Redemptions(code, customerID string) (total, byCustomer int) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.redemptions[domain.NormalizePromotionCode(code)]
+	if !ok {
+		return 0, 0
+	}
+	return c.total, c.byCustomer[customerID]
+}
+
+// CheckRedeemable returns an error if the customer cannot redeem the promotion at now.
+func (r *PromotionRepository) CheckRedeemable(code, customerID string, now time.Time) (domain.Promotion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.checkRedeemable(domain.NormalizePromotionCode(code), customerID, now)
+}
+
+// Redeem records a redemption if the promotion's limits allow it.
+func (r *PromotionRepository) Redeem(code, customerID string, now time.Time) (domain.Promotion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	code = domain.NormalizePromotionCode(code)
+	p, err := r.checkRedeemable(code, customerID, now)
+	if err != nil {
+		return domain.Promotion{}, err
+	}
+	c := r.redemptions[code]
+	c.total++
+	c.byCustomer[customerID]++
+	return p, nil
+}
+
+func (r *PromotionRepository) checkRedeemable(code, customerID string, now time.Time) (domain.Promotion, error) {
+	p, ok := r.promotions[code]
+	if !ok {
+		return domain.Promotion{}, fmt.Errorf("promotion %s %w", code, ErrPromotionNotFound)
+	}
+	c := r.redemptions[code]
+	if err := p.CheckRedeemable(now, c.total, c.byCustomer[customerID]); err != nil {
+		return domain.Promotion{}, err
+	}
+	return p, nil
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+func TestPromotionRepository_RedeemRespectsGlobalLimitUnderConcurrency(t *testing.T) {
+	repo := NewPromotionRepository()
+	promo, err := domain.NewPromotion("BOOKWEEK20", domain.PromotionTerms{
+		Kind:           domain.PromotionPercent,
+		Value:          20,
+		MaxRedemptions: 10,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	repo.Save(promo)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := repo.Redeem("bookweek20", fmt.Sprintf("customer-%d", i), time.Now()); err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if succeeded != 10 {
+		t.Errorf("expected 10 successful redemptions, got %d", succeeded)
+	}
+	if total, _ := repo.Redemptions("BOOKWEEK20", ""); total != 10 {
+		t.Errorf("expected 10 recorded redemptions, got %d", total)
+	}
+}
+
+func TestPromotionRepository_RedeemRespectsPerCustomerLimit(t *testing.T) {
+	repo := NewPromotionRepository()
+	promo, _ := domain.NewPromotion("ONCE", domain.PromotionTerms{
+		Kind:           domain.PromotionFixed,
+		Value:          500,
+		MaxPerCustomer: 1,
+	})
+	repo.Save(promo)
+
+	if _, err := repo.Redeem("ONCE", "alice", time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := repo.Redeem("ONCE", "alice", time.Now()); err == nil {
+		t.Fatal("expected error for second redemption by the same customer")
+	}
+	if _, err := repo.Redeem("ONCE", "bob", time.Now()); err != nil {
+		t.Fatalf("unexpected error for another customer: %v", err)
+	}
+}
+
+func TestPromotionRepository_CreateKeepsTheFirst(t *testing.T) {
+	repo := NewPromotionRepository()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	created := 0
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p, _ := domain.NewPromotion("BOOKWEEK", domain.PromotionTerms{Kind: domain.PromotionPercent, Value: i + 1})
+			if repo.Create(p) == nil {
+				mu.Lock()
+				created++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if created != 1 {
+		t.Errorf("got %d creates succeeding, want 1", created)
+	}
+}
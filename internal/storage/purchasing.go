@@ -0,0 +1,244 @@
+package storage
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+// ReorderPolicyRepository stores one reorder policy per book in memory.
+type ReorderPolicyRepository struct {
+	mu       sync.RWMutex
+	policies map[string]domain.ReorderPolicy // keyed by ISBN string
+}
+
+func NewReorderPolicyRepository() *ReorderPolicyRepository {
+	return &ReorderPolicyRepository{policies: make(map[string]domain.ReorderPolicy)}
+}
+
+// Save creates or replaces the book's policy.
+func (r *ReorderPolicyRepository) Save(p domain.ReorderPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.policies[p.ISBN().String()] = p
+}
+
+func (r *ReorderPolicyRepository) FindByISBN(isbn string) (domain.ReorderPolicy, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.policies[isbn]
+	if !ok {
+		return domain.ReorderPolicy{}, fmt.Errorf("no reorder policy for %s", isbn)
+	}
+	return p, nil
+}
+
+// FindAll returns all policies sorted by ISBN.
+func (r *ReorderPolicyRepository) FindAll() []domain.ReorderPolicy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make([]domain.ReorderPolicy, 0, len(r.policies))
+	for _, p := range r.policies {
+		result = append(result, p)
+	}
+	slices.SortFunc(result, func(a, b domain.ReorderPolicy) int { return strings.Compare(a.ISBN().String(), b.ISBN().String()) })
+	return result
+}
+
+func (r *ReorderPolicyRepository) Delete(isbn string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.policies[isbn]; !ok {
+		return fmt.Errorf("no reorder policy for %s", isbn)
+	}
+	delete(r.policies, isbn)
+	return nil
+}
+
+// PurchaseOrderRepository stores purchase orders in memory.
+type PurchaseOrderRepository struct {
+	mu     sync.RWMutex
+	orders map[string]domain.PurchaseOrder // keyed by order ID
+}
+
+func NewPurchaseOrderRepository() *PurchaseOrderRepository {
+	return &PurchaseOrderRepository{orders: make(map[string]domain.PurchaseOrder)}
+}
+
+func (r *PurchaseOrderRepository) Save(o domain.PurchaseOrder) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.orders[o.ID()] = o
+}
+
+func (r *PurchaseOrderRepository) FindByID(id string) (domain.PurchaseOrder, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	o, ok := r.orders[id]
+	if !ok {
+		return domain.PurchaseOrder{}, fmt.Errorf("purchase order %s not found", id)
+	}
+	return o, nil
+}
+
+// FindAll returns all orders, oldest first.
+func (r *PurchaseOrderRepository) FindAll() []domain.PurchaseOrder {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make([]domain.PurchaseOrder, 0, len(r.orders))
+	for _, o := range r.orders {
+		result = append(result, o)
+	}
+	slices.SortFunc(result, func(a, b domain.PurchaseOrder) int {
+		if c := a.CreatedAt().Compare(b.CreatedAt()); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID(), b.ID())
+	})
+	return result
+}
+
+// Update applies fn to the stored order under the repository lock. The
+// order is saved only if fn succeeds.
+func (r *PurchaseOrderRepository) Update(id string, fn func(*domain.PurchaseOrder) error) (domain.PurchaseOrder, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	o, ok := r.orders[id]
+	if !ok {
+		return domain.PurchaseOrder{}, fmt.Errorf("purchase order %s not found", id)
+	}
+	if err := fn(&o); err != nil {
+		return domain.PurchaseOrder{}, err
+	}
+	r.orders[id] = o
+	return o, nil
+}
+
+// OnOrder returns the copies of each book on open orders that have not yet
+// been delivered.
+func (r *PurchaseOrderRepository) OnOrder() map[domain.ISBN]int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make(map[domain.ISBN]int)
+	for _, o := range r.orders {
+		if !o.IsOpen() {
+			continue
+		}
+		for _, l := range o.Lines() {
+			result[l.ISBN()] += l.Outstanding()
+		}
+	}
+	return result
+}
+
+// reorderMu serialises reorder runs so that two runs cannot both see a book
+// as not on order and draft it twice.
+var reorderMu sync.Mutex
+
+// GenerateReorderDrafts drafts purchase orders, one per supplier, for every
+// book whose stock across all locations has fallen to its reorder point and
+// is not already on order. Returns the drafts it saved.
+func GenerateReorderDrafts(inventory *domain.Inventory, policies *ReorderPolicyRepository, orders *PurchaseOrderRepository, now time.Time) []domain.PurchaseOrder {
+	reorderMu.Lock()
+	defer reorderMu.Unlock()
+	drafts := domain.SuggestPurchaseOrders(inventory.EntriesAt(""), policies.FindAll(), orders.OnOrder(), now)
+	for _, o := range drafts {
+		orders.Save(o)
+	}
+	return drafts
+}
+
+// SupplierRepository stores suppliers in memory.
+type SupplierRepository struct {
+	mu        sync.RWMutex
+	suppliers map[string]domain.Supplier // keyed by code
+}
+
+func NewSupplierRepository() *SupplierRepository {
+	return &SupplierRepository{suppliers: make(map[string]domain.Supplier)}
+}
+
+// Create adds a supplier unless one with the same code exists.
+func (r *SupplierRepository) Create(s domain.Supplier) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.suppliers[s.Code()]; ok {
+		return fmt.Errorf("supplier %s already exists", s.Code())
+	}
+	r.suppliers[s.Code()] = s
+	return nil
+}
+
+// Save creates or replaces a supplier.
+func (r *SupplierRepository) Save(s domain.Supplier) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.suppliers[s.Code()] = s
+}
+
+func (r *SupplierRepository) FindByCode(code string) (domain.Supplier, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.suppliers[strings.ToLower(code)]
+	if !ok {
+		return domain.Supplier{}, fmt.Errorf("supplier %s not found", code)
+	}
+	return s, nil
+}
+
+// FindAll returns all suppliers sorted by code.
+func (r *SupplierRepository) FindAll() []domain.Supplier {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make([]domain.Supplier, 0, len(r.suppliers))
+	for _, s := range r.suppliers {
+		result = append(result, s)
+	}
+	slices.SortFunc(result, func(a, b domain.Supplier) int { return strings.Compare(a.Code(), b.Code()) })
+	return result
+}
+
+// ReceivePurchaseOrder books a delivery against an order and puts the copies
+// into stock at the order's delivery location. Copies whose landed cost is
+// in the book's currency arrive as a costed lot; others are restocked
+// uncosted, with the landed cost kept on the order's receipts. The copies
+// are stocked in one go: if any cannot be, none are and the order is left
+// unchanged, so the delivery can safely be booked again.
+func ReceivePurchaseOrder(orders *PurchaseOrderRepository, books *BookRepository, inventory *domain.Inventory, id string, items []domain.StockRequest, charges domain.Money, info domain.MovementInfo, now time.Time) (domain.PurchaseOrder, error) {
+	return orders.Update(id, func(o *domain.PurchaseOrder) error {
+		catalog := make(map[domain.ISBN]domain.Book, len(items))
+		for _, item := range items {
+			book, err := books.FindByISBN(item.ISBN.String())
+			if err != nil {
+				return err
+			}
+			catalog[item.ISBN] = book
+		}
+		received, err := o.Receive(items, charges, now)
+		if err != nil {
+			return err
+		}
+
+		if info.Reason == "" {
+			info.Reason = domain.ReasonSupplierDelivery
+		}
+		info.Reference = o.ID()
+		stock := make([]domain.StockReceipt, 0, len(received))
+		for _, rc := range received {
+			book := catalog[rc.ISBN()]
+			sr := domain.StockReceipt{Book: book, Location: o.Location(), Quantity: rc.Quantity()}
+			if rc.LandedUnitCost().Currency() == book.Price().Currency() {
+				if sr.Lot, err = domain.NewStockLot(rc.Quantity(), rc.LandedUnitCost(), o.Supplier(), now); err != nil {
+					return err
+				}
+			}
+			stock = append(stock, sr)
+		}
+		_, err = inventory.ReceiveMany(stock, info)
+		return err
+	})
+}
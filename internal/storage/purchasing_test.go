@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sergekukharev/agent-test-writer-validator/internal/domain"
+)
+
+func TestReceivePurchaseOrder_StocksCopiesAtLandedCost(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	isbn, _ := domain.NewISBN("9780306406157")
+	author, _ := domain.NewAuthor("Jane", "Doe")
+	price, _ := domain.NewMoney(1000, "EUR")
+	book, _ := domain.NewBook(isbn, "Stocked", author, price, now, domain.GenreFiction)
+	books := NewBookRepository()
+	books.Save(book)
+	inventory := domain.NewInventory(nil)
+
+	minimum, _ := domain.NewMoney(0, "EUR")
+	supplier, _ := domain.NewSupplier("acme", "", 30, 0, minimum)
+	cost, _ := domain.NewMoney(400, "EUR")
+	po := domain.NewPurchaseOrder("acme", now)
+	po.SetLine(isbn, 10, cost, time.Time{})
+	if err := po.Send(supplier, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	orders := NewPurchaseOrderRepository()
+	orders.Save(po)
+
+	freight, _ := domain.NewMoney(500, "EUR")
+	got, err := ReceivePurchaseOrder(orders, books, inventory, po.ID(), []domain.StockRequest{{ISBN: isbn, Quantity: 5}}, freight, domain.MovementInfo{}, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Status() != domain.PurchaseOrderPartiallyReceived {
+		t.Errorf("got status %s, want %s", got.Status(), domain.PurchaseOrderPartiallyReceived)
+	}
+	entry, err := inventory.FindAt(isbn, domain.DefaultLocation)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if avg, _ := entry.AverageUnitCost(); entry.Total() != 5 || avg.Amount() != 500 {
+		t.Errorf("got %d copies at %d, want 5 at 500", entry.Total(), avg.Amount())
+	}
+	if onOrder := orders.OnOrder()[isbn]; onOrder != 5 {
+		t.Errorf("got %d on order, want 5", onOrder)
+	}
+
+	if _, err := ReceivePurchaseOrder(orders, books, inventory, po.ID(), []domain.StockRequest{{ISBN: isbn, Quantity: 6}}, domain.Money{}, domain.MovementInfo{}, now); err == nil {
+		t.Error("expected error receiving more than outstanding")
+	}
+	if entry, _ := inventory.FindAt(isbn, domain.DefaultLocation); entry.Total() != 5 {
+		t.Errorf("a refused delivery changed stock to %d", entry.Total())
+	}
+}